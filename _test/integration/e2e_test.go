@@ -64,7 +64,7 @@ func TestE2E_SMTPConnection(t *testing.T) {
 	mailer := email.New(cfg, store)
 
 	// SendTemplate does real SMTP send
-	msgID, err := mailer.SendTemplate()
+	msgID, err := mailer.SendTemplate(nil)
 	require.NoError(t, err, "SMTP send should succeed")
 	assert.NotEmpty(t, msgID, "should return a Message-ID")
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yhzion/claude-postman/internal/config"
@@ -38,6 +39,10 @@ func newRootCmd() *cobra.Command {
 		newServeCmd(),
 		newDoctorCmd(),
 		newSendTemplateCmd(),
+		newSearchCmd(),
+		newDBCmd(),
+		newTemplateCmd(),
+		newOutboxCmd(),
 		newInstallServiceCmd(),
 		newUninstallServiceCmd(),
 		newUpdateCmd(),
@@ -79,7 +84,7 @@ func sendInitTemplate(cfg *config.Config) {
 
 	mailer := email.New(&cfg.Email, store)
 	fmt.Print("\nSending session template email... ")
-	if _, err := mailer.SendTemplate(); err != nil {
+	if _, err := mailer.SendTemplate(nil); err != nil {
 		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
 		fmt.Fprintln(os.Stderr, "  You can re-run 'claude-postman init' to retry.")
 		return
@@ -90,27 +95,28 @@ func sendInitTemplate(cfg *config.Config) {
 
 func newSendTemplateCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "send-template",
-		Short: "Send a session creation template email",
-		RunE: func(_ *cobra.Command, _ []string) error {
+		Use:   "send-template [name]",
+		Short: "Send a session creation template email, optionally a named preset",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
 			cfg, err := config.Load()
 			if err != nil {
 				return err
 			}
-
-			store, err := storage.New(cfg.General.DataDir)
+			preset, err := templatePreset(cfg, args)
 			if err != nil {
-				return fmt.Errorf("open database: %w", err)
+				return err
 			}
-			defer store.Close()
 
-			if err := store.Migrate(); err != nil {
-				return fmt.Errorf("migrate database: %w", err)
+			store, err := openStore(cfg)
+			if err != nil {
+				return err
 			}
+			defer store.Close()
 
 			mailer := email.New(&cfg.Email, store)
 			fmt.Print("Sending session template email... ")
-			msgID, err := mailer.SendTemplate()
+			msgID, err := mailer.SendTemplate(preset)
 			if err != nil {
 				return fmt.Errorf("send template: %w", err)
 			}
@@ -122,12 +128,116 @@ func newSendTemplateCmd() *cobra.Command {
 	}
 }
 
+func newSearchCmd() *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search past sessions by prompt, result or directory",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			store, err := openStore(cfg)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			results, err := store.Search(strings.Join(args, " "), limit)
+			if err != nil {
+				return fmt.Errorf("search: %w", err)
+			}
+			printSearchResults(results)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "maximum number of matching turns")
+	return cmd
+}
+
+// printSearchResults prints matching turns grouped by session.
+func printSearchResults(results []*storage.SearchResult) {
+	groups := storage.GroupBySession(results)
+	if len(groups) == 0 {
+		fmt.Println("No matching sessions.")
+		return
+	}
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		first := group[0]
+		fmt.Printf("%s  (%s)  session %s\n", first.WorkingDir, first.Status, first.SessionID)
+		for _, r := range group {
+			fmt.Printf("  #%-3d %s  %s\n", r.Seq, r.StartedAt.Local().Format("2006-01-02 15:04"), r.Snippet)
+		}
+	}
+}
+
+func newDBCmd() *cobra.Command {
+	db := &cobra.Command{
+		Use:   "db",
+		Short: "Database maintenance",
+	}
+	db.AddCommand(&cobra.Command{
+		Use:   "gc",
+		Short: "Purge data older than retention_days and compact the database",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			store, err := openStore(cfg)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			mgr := session.New(store, session.NewTmuxRunner())
+			result, err := serve.Maintain(cfg, store, mgr)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Purged data older than %d days:\n", cfg.General.RetentionDays)
+			fmt.Printf("  sessions:    %d (transcripts: %d)\n", result.Sessions, result.Transcripts)
+			fmt.Printf("  outbox:      %d\n", result.Outbox)
+			fmt.Printf("  inbox:       %d\n", result.Inbox)
+			fmt.Printf("  templates:   %d\n", result.Templates)
+			fmt.Println("Database compacted.")
+			return nil
+		},
+	})
+	return db
+}
+
+// openStore opens and migrates the database in the configured data directory.
+func openStore(cfg *config.Config) (*storage.Store, error) {
+	store, err := storage.New(cfg.General.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if err := store.Migrate(); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("migrate database: %w", err)
+	}
+	return store, nil
+}
+
 func newServeCmd() *cobra.Command {
-	return &cobra.Command{
+	var opts serve.Options
+	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Start the relay server",
-		RunE:  runServe,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runServe(opts)
+		},
 	}
+	cmd.Flags().BoolVar(&opts.NoTemplate, "no-template", false, "do not send a session template email on startup")
+	return cmd
 }
 
 func newDoctorCmd() *cobra.Command {
@@ -226,7 +336,7 @@ func runUninstall(yes bool) error {
 	return nil
 }
 
-func runServe(_ *cobra.Command, _ []string) error {
+func runServe(opts serve.Options) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return err
@@ -244,9 +354,13 @@ func runServe(_ *cobra.Command, _ []string) error {
 
 	tmux := session.NewTmuxRunner()
 	mgr := session.New(store, tmux)
+	mgr.SetBudget(session.Budget{
+		SessionUSD: cfg.Budget.SessionUSD,
+		DailyUSD:   cfg.Budget.DailyUSD,
+	})
 	mailer := email.New(&cfg.Email, store)
 
-	return serve.RunServe(context.Background(), cfg, store, mgr, mailer)
+	return serve.RunServe(context.Background(), cfg, store, mgr, mailer, opts)
 }
 
 func runDoctor(fix bool) error {
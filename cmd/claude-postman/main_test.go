@@ -1,11 +1,13 @@
 package main
 
 import (
+	"io"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/config"
 )
 
 func TestRootCmd_HasExpectedSubcommands(t *testing.T) {
@@ -15,7 +17,7 @@ func TestRootCmd_HasExpectedSubcommands(t *testing.T) {
 		names[cmd.Name()] = true
 	}
 
-	expected := []string{"init", "serve", "doctor", "install-service", "uninstall-service", "update", "uninstall"}
+	expected := []string{"init", "serve", "doctor", "search", "db", "template", "outbox", "install-service", "uninstall-service", "update", "uninstall"}
 	for _, name := range expected {
 		assert.True(t, names[name], "missing subcommand: %s", name)
 	}
@@ -53,3 +55,50 @@ func TestDoctorCmd_HasFixFlag(t *testing.T) {
 	f := doctorCmd.Flags().Lookup("fix")
 	assert.NotNil(t, f, "--fix flag should exist")
 }
+
+func TestTemplateRevokeCmd_RequiresRefOrAll(t *testing.T) {
+	for _, args := range [][]string{
+		{"template", "revoke"},
+		{"template", "revoke", "abcd1234", "--all"},
+	} {
+		root := newRootCmd()
+		root.SetArgs(args)
+		root.SetOut(io.Discard)
+		root.SetErr(io.Discard)
+		err := root.Execute()
+		require.Error(t, err, args)
+		assert.Contains(t, err.Error(), "--all")
+	}
+}
+
+func TestOutboxDropCmd_RequiresIDOrAll(t *testing.T) {
+	for _, args := range [][]string{
+		{"outbox", "drop"},
+		{"outbox", "drop", "abcd1234", "--all"},
+	} {
+		root := newRootCmd()
+		root.SetArgs(args)
+		root.SetOut(io.Discard)
+		root.SetErr(io.Discard)
+		err := root.Execute()
+		require.Error(t, err, args)
+		assert.Contains(t, err.Error(), "--all")
+	}
+}
+
+func TestTemplatePreset(t *testing.T) {
+	cfg := &config.Config{Templates: []config.TemplatePreset{{Name: "infra"}, {Name: "frontend"}}}
+
+	preset, err := templatePreset(cfg, nil)
+	require.NoError(t, err)
+	assert.Nil(t, preset, "이름이 없으면 기본 템플릿")
+
+	preset, err = templatePreset(cfg, []string{"infra"})
+	require.NoError(t, err)
+	require.NotNil(t, preset)
+	assert.Equal(t, "infra", preset.Name)
+
+	_, err = templatePreset(cfg, []string{"backend"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "infra, frontend", "사용 가능한 이름을 안내")
+}
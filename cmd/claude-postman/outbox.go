@@ -0,0 +1,186 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yhzion/claude-postman/internal/config"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+func newOutboxCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outbox",
+		Short: "Inspect and retry outgoing emails",
+	}
+	cmd.AddCommand(newOutboxListCmd(), newOutboxShowCmd(), newOutboxRetryCmd(),
+		newOutboxRetryAllCmd(), newOutboxDropCmd())
+	return cmd
+}
+
+func newOutboxListCmd() *cobra.Command {
+	var status string
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List outgoing emails, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return withStore(func(_ *config.Config, store *storage.Store) error {
+				msgs, err := store.ListOutbox(status, limit)
+				if err != nil {
+					return err
+				}
+				if len(msgs) == 0 {
+					fmt.Println("No emails in the outbox.")
+					return nil
+				}
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tSTATUS\tRETRIES\tCREATED\tSUBJECT")
+				for _, msg := range msgs {
+					fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", shortID(msg.ID), msg.Status, msg.RetryCount,
+						msg.CreatedAt.Local().Format("2006-01-02 15:04"), msg.Subject)
+				}
+				return w.Flush()
+			})
+		},
+	}
+	cmd.Flags().StringVar(&status, "status", "", "only list emails with this status (pending, sent, failed)")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 50, "maximum number of emails (0 lists all)")
+	return cmd
+}
+
+func newOutboxShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show an outgoing email with its HTML body",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return withStore(func(_ *config.Config, store *storage.Store) error {
+				msg, err := findOutbox(store, args[0])
+				if err != nil {
+					return err
+				}
+				printOutbox(msg)
+				return nil
+			})
+		},
+	}
+}
+
+func newOutboxRetryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "retry <id>",
+		Short: "Queue a pending or failed email again with a fresh retry count",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return withStore(func(_ *config.Config, store *storage.Store) error {
+				msg, err := findOutbox(store, args[0])
+				if err != nil {
+					return err
+				}
+				if msg.Status == "sent" {
+					return fmt.Errorf("email %s was already sent", shortID(msg.ID))
+				}
+				if err := store.RequeueOutbox(msg.ID); err != nil {
+					return err
+				}
+				fmt.Printf("Queued %s; serve sends it on its next outbox flush.\n", shortID(msg.ID))
+				return nil
+			})
+		},
+	}
+}
+
+func newOutboxRetryAllCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "retry-all",
+		Short: "Queue every failed email again",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return withStore(func(_ *config.Config, store *storage.Store) error {
+				n, err := store.RequeueFailedOutbox()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Queued %d failed email(s); serve sends them on its next outbox flush.\n", n)
+				return nil
+			})
+		},
+	}
+}
+
+func newOutboxDropCmd() *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "drop [id]",
+		Short: "Delete failed emails that will not be retried",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if all == (len(args) == 1) {
+				return errors.New("specify a failed email ID, or --all")
+			}
+			return withStore(func(_ *config.Config, store *storage.Store) error {
+				id := ""
+				if len(args) == 1 {
+					msg, err := findOutbox(store, args[0])
+					if err != nil {
+						return err
+					}
+					if msg.Status != "failed" {
+						return fmt.Errorf("email %s is %s; only failed emails can be dropped", shortID(msg.ID), msg.Status)
+					}
+					id = msg.ID
+				}
+				n, err := store.DropFailedOutbox(id)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Dropped %d failed email(s).\n", n)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "drop every failed email")
+	return cmd
+}
+
+// findOutbox looks up an outbox message by ID or ID prefix, as shown by
+// outbox list.
+func findOutbox(store *storage.Store, ref string) (*storage.OutboxMessage, error) {
+	msg, err := store.FindOutbox(ref)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("no email in the outbox matches %q", ref)
+	case errors.Is(err, storage.ErrAmbiguousID):
+		return nil, fmt.Errorf("%q matches several emails; use a longer ID", ref)
+	}
+	return msg, err
+}
+
+func printOutbox(msg *storage.OutboxMessage) {
+	fmt.Printf("ID:          %s\n", msg.ID)
+	fmt.Printf("Status:      %s\n", msg.Status)
+	fmt.Printf("Subject:     %s\n", msg.Subject)
+	if msg.SessionID != "" {
+		fmt.Printf("Session:     %s\n", msg.SessionID)
+	}
+	if msg.MessageID != nil {
+		fmt.Printf("Message-ID:  %s\n", *msg.MessageID)
+	}
+	fmt.Printf("Created:     %s\n", msg.CreatedAt.Local().Format(time.DateTime))
+	if msg.SentAt != nil {
+		fmt.Printf("Sent:        %s\n", msg.SentAt.Local().Format(time.DateTime))
+	}
+	fmt.Printf("Retries:     %d\n", msg.RetryCount)
+	if msg.NextRetryAt != nil && msg.Status == "pending" {
+		fmt.Printf("Next retry:  %s\n", msg.NextRetryAt.Local().Format(time.DateTime))
+	}
+	fmt.Println()
+	fmt.Println(msg.Body)
+}
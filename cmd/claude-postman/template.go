@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yhzion/claude-postman/internal/config"
+	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+func newTemplateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage session creation templates",
+	}
+	cmd.AddCommand(newTemplateListCmd(), newTemplateRevokeCmd(), newTemplateRotateCmd())
+	return cmd
+}
+
+func newTemplateListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List sent templates and whether they can still create sessions",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return withStore(func(_ *config.Config, store *storage.Store) error {
+				templates, err := store.ListTemplates()
+				if err != nil {
+					return err
+				}
+				if len(templates) == 0 {
+					fmt.Println("No templates sent yet.")
+					return nil
+				}
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tNAME\tCREATED\tEXPIRES\tSTATUS\tMESSAGE-ID")
+				now := time.Now()
+				for _, t := range templates {
+					expires := "never"
+					if t.ExpiresAt != nil {
+						expires = t.ExpiresAt.Local().Format("2006-01-02 15:04")
+					}
+					name := t.Name
+					if name == "" {
+						name = "(default)"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", shortID(t.ID), name,
+						t.CreatedAt.Local().Format("2006-01-02 15:04"), expires, templateStatus(t, now), t.MessageID)
+				}
+				return w.Flush()
+			})
+		},
+	}
+}
+
+func newTemplateRevokeCmd() *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "revoke [id | message-id]",
+		Short: "Revoke templates so replies to them no longer create sessions",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if all == (len(args) == 1) {
+				return errors.New("specify a template ID or Message-ID, or --all")
+			}
+			ref := ""
+			if len(args) == 1 {
+				ref = args[0]
+			}
+			return withStore(func(_ *config.Config, store *storage.Store) error {
+				n, err := store.RevokeTemplates(ref)
+				if err != nil {
+					return err
+				}
+				if n == 0 {
+					return fmt.Errorf("no valid template matches %q", ref)
+				}
+				fmt.Printf("Revoked %d template(s).\n", n)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "revoke every template")
+	return cmd
+}
+
+func newTemplateRotateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate [name]",
+		Short: "Send a fresh template and revoke the previous ones with the same name",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return withStore(func(cfg *config.Config, store *storage.Store) error {
+				preset, err := templatePreset(cfg, args)
+				if err != nil {
+					return err
+				}
+				previous, err := store.ListTemplates()
+				if err != nil {
+					return err
+				}
+
+				fmt.Print("Sending session template email... ")
+				msgID, err := email.New(&cfg.Email, store).SendTemplate(preset)
+				if err != nil {
+					return fmt.Errorf("send template: %w", err)
+				}
+				fmt.Println("✅ sent")
+				fmt.Printf("  Message-ID: %s\n", msgID)
+
+				// Revoke only after the new template is out, so a failed
+				// send never leaves the user without a usable template.
+				revoked := 0
+				for _, t := range previous {
+					if t.Revoked || t.Name != presetName(preset) {
+						continue
+					}
+					n, err := store.RevokeTemplates(t.ID)
+					if err != nil {
+						return err
+					}
+					revoked += int(n)
+				}
+				fmt.Printf("  Revoked %d previous template(s).\n", revoked)
+				return nil
+			})
+		},
+	}
+}
+
+// templatePreset returns the preset named by the optional first argument,
+// or nil for the default template.
+func templatePreset(cfg *config.Config, args []string) (*config.TemplatePreset, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	preset := cfg.Template(args[0])
+	if preset == nil {
+		names := make([]string, len(cfg.Templates))
+		for i, t := range cfg.Templates {
+			names[i] = t.Name
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown template %q: no [[templates]] in config", args[0])
+		}
+		return nil, fmt.Errorf("unknown template %q (available: %s)", args[0], strings.Join(names, ", "))
+	}
+	return preset, nil
+}
+
+func presetName(preset *config.TemplatePreset) string {
+	if preset == nil {
+		return ""
+	}
+	return preset.Name
+}
+
+// withStore loads the config, opens the database and calls fn.
+func withStore(fn func(cfg *config.Config, store *storage.Store) error) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return fn(cfg, store)
+}
+
+func templateStatus(t *storage.Template, now time.Time) string {
+	switch {
+	case t.Revoked:
+		return "revoked"
+	case !t.Valid(now):
+		return "expired"
+	default:
+		return "valid"
+	}
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
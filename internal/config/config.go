@@ -4,9 +4,13 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -15,6 +19,13 @@ import (
 type Config struct {
 	General GeneralConfig `toml:"general"`
 	Email   EmailConfig   `toml:"email"`
+	Budget  BudgetConfig  `toml:"budget"`
+	Digest  DigestConfig  `toml:"digest"`
+	// Reminder는 답을 기다리는 세션의 재알림 설정
+	Reminder ReminderConfig `toml:"reminder"`
+	HTTP     HTTPConfig     `toml:"http"`
+	// Templates는 이름 붙은 템플릿 프리셋 ([[templates]])
+	Templates []TemplatePreset `toml:"templates"`
 }
 
 // GeneralConfig는 일반 설정
@@ -23,6 +34,8 @@ type GeneralConfig struct {
 	DefaultModel      string `toml:"default_model"`
 	PollIntervalSec   int    `toml:"poll_interval_sec"`
 	SessionTimeoutMin int    `toml:"session_timeout_min"`
+	// RetentionDays는 종료된 세션과 발송 완료 메일 등을 보관하는 기간 (일)
+	RetentionDays int `toml:"retention_days"`
 }
 
 // EmailConfig는 이메일 관련 설정
@@ -34,6 +47,94 @@ type EmailConfig struct {
 	IMAPPort    int    `toml:"imap_port"`
 	User        string `toml:"user"`
 	AppPassword string `toml:"app_password"`
+	// TemplateLifetimeDays는 템플릿 메일로 세션을 만들 수 있는 기간 (일)
+	TemplateLifetimeDays int `toml:"template_lifetime_days"`
+	// TemplateRefreshDays는 serve 시작 시 새 템플릿을 보내는 기준 (최신 유효 템플릿의 나이, 일)
+	TemplateRefreshDays int `toml:"template_refresh_days"`
+	// PlusTag는 새 세션용 plus 주소의 태그 (user+<tag>@domain 으로 보낸 새 메일이 세션 생성)
+	PlusTag string `toml:"plus_tag"`
+	// MaxMessageMB는 발송 메시지 최대 크기 (MB). 넘으면 큰 첨부부터 뺀다 (기본값: 프로바이더 프리셋)
+	MaxMessageMB int `toml:"max_message_mb"`
+	// Retry는 outbox 발송 실패 시 재시도 정책 ([email.retry])
+	Retry RetryConfig `toml:"retry"`
+}
+
+// RetryConfig는 일시적인 SMTP 실패(4xx, 네트워크)의 재시도 설정.
+// 영구 실패(5xx)는 재시도하지 않고, 인증 실패는 outbox 전체를 MaxSec 동안 멈춘다
+type RetryConfig struct {
+	// MaxAttempts는 failed로 포기하기 전까지의 최대 발송 시도 횟수
+	MaxAttempts int `toml:"max_attempts"`
+	// BaseSec은 첫 재시도 대기 시간 (초). 시도마다 두 배로 늘어난다
+	BaseSec int `toml:"base_sec"`
+	// MaxSec은 재시도 대기 시간의 상한 (초)
+	MaxSec int `toml:"max_sec"`
+	// Jitter는 대기 시간을 무작위로 흔드는 비율 (0.2면 ±20%, 0이면 흔들지 않음).
+	// 생략하면 0.2
+	Jitter *float64 `toml:"jitter"`
+}
+
+// BudgetConfig는 비용 한도 설정 (0이면 해당 한도 비활성화)
+type BudgetConfig struct {
+	SessionUSD float64 `toml:"session_usd"`
+	DailyUSD   float64 `toml:"daily_usd"`
+}
+
+// DigestConfig는 정기 요약 메일 설정 (세션 현황, 비용, 미발송 메일)
+type DigestConfig struct {
+	// Schedule은 발송 주기: "" (끔), "daily", "weekly"
+	Schedule string `toml:"schedule"`
+	// Time은 발송 시각 (로컬 시간, HH:MM)
+	Time string `toml:"time"`
+	// Weekday는 weekly일 때 발송 요일 (sunday ... saturday)
+	Weekday string `toml:"weekday"`
+}
+
+// ReminderConfig는 waiting 세션의 재알림 설정
+type ReminderConfig struct {
+	// AfterMin은 waiting이 된 뒤 재알림을 보내는 시점 (분, 오름차순).
+	// 생략하면 [60, 240], 빈 배열이면 재알림을 보내지 않는다
+	AfterMin []int `toml:"after_min"`
+}
+
+// HTTPConfig는 serve의 로컬 대시보드와 JSON API 설정
+type HTTPConfig struct {
+	// Listen은 listen 주소: "" (끔), 루프백 주소 ("127.0.0.1:8025", "localhost:8025")
+	// 또는 "unix:<경로>". 인증이 없으므로 원격에서는 SSH 포트 포워딩으로 접속한다
+	Listen string `toml:"listen"`
+	// MetricsListen은 /metrics만 제공하는 별도 listen 주소 ("host:port", 기본 ""는 끔).
+	// 다른 호스트의 Prometheus가 수집할 수 있도록 루프백이 아닌 주소도 허용한다
+	MetricsListen string `toml:"metrics_listen"`
+}
+
+// DigestSchedules는 digest.schedule에 허용되는 값
+var DigestSchedules = []string{"daily", "weekly"}
+
+// Weekdays는 digest.weekday에 허용되는 값 (time.Weekday 순서)
+var Weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// TemplatePreset은 템플릿 메일로 만드는 세션의 기본값
+// (send-template <name>으로 발송)
+type TemplatePreset struct {
+	Name       string `toml:"name"`
+	WorkingDir string `toml:"working_dir"`
+	Model      string `toml:"model"`
+	// SystemPrompt는 기본 시스템 프롬프트 뒤에 덧붙이는 추가 지시
+	SystemPrompt string `toml:"system_prompt"`
+	// PermissionMode는 claude --permission-mode 값 (비어 있으면 권한 확인 생략)
+	PermissionMode string `toml:"permission_mode"`
+}
+
+// PermissionModes는 permission_mode에 허용되는 값
+var PermissionModes = []string{"default", "acceptEdits", "plan", "bypassPermissions"}
+
+// Template은 이름이 name인 프리셋을 반환한다. 없으면 nil.
+func (c *Config) Template(name string) *TemplatePreset {
+	for i := range c.Templates {
+		if c.Templates[i].Name == name {
+			return &c.Templates[i]
+		}
+	}
+	return nil
 }
 
 // Load는 기본 설정 디렉터리에서 설정을 로드한다.
@@ -94,6 +195,46 @@ func applyDefaults(cfg *Config) {
 	if cfg.General.DefaultModel == "" {
 		cfg.General.DefaultModel = "sonnet"
 	}
+	if cfg.General.RetentionDays == 0 {
+		cfg.General.RetentionDays = 30
+	}
+	if cfg.Email.TemplateLifetimeDays == 0 {
+		cfg.Email.TemplateLifetimeDays = 30
+	}
+	if cfg.Email.TemplateRefreshDays == 0 {
+		cfg.Email.TemplateRefreshDays = 7
+	}
+	if cfg.Email.PlusTag == "" {
+		cfg.Email.PlusTag = "claude"
+	}
+	if cfg.Digest.Time == "" {
+		cfg.Digest.Time = "08:00"
+	}
+	if cfg.Digest.Weekday == "" {
+		cfg.Digest.Weekday = "monday"
+	}
+	if cfg.Reminder.AfterMin == nil {
+		cfg.Reminder.AfterMin = []int{60, 240}
+	}
+	if cfg.Email.Retry.MaxAttempts == 0 {
+		cfg.Email.Retry.MaxAttempts = 5
+	}
+	if cfg.Email.Retry.BaseSec == 0 {
+		cfg.Email.Retry.BaseSec = 30
+	}
+	if cfg.Email.Retry.MaxSec == 0 {
+		cfg.Email.Retry.MaxSec = 900
+	}
+	if cfg.Email.Retry.Jitter == nil {
+		jitter := 0.2
+		cfg.Email.Retry.Jitter = &jitter
+	}
+	if cfg.Email.MaxMessageMB == 0 {
+		cfg.Email.MaxMessageMB = defaultMaxMessageMB
+		if p, ok := Presets[cfg.Email.Provider]; ok {
+			cfg.Email.MaxMessageMB = p.MaxMessageMB
+		}
+	}
 }
 
 func applyEnvOverrides(cfg *Config) {
@@ -101,12 +242,28 @@ func applyEnvOverrides(cfg *Config) {
 	envStr("CLAUDE_POSTMAN_MODEL", &cfg.General.DefaultModel)
 	envInt("CLAUDE_POSTMAN_POLL_INTERVAL", &cfg.General.PollIntervalSec)
 	envInt("CLAUDE_POSTMAN_SESSION_TIMEOUT", &cfg.General.SessionTimeoutMin)
+	envInt("CLAUDE_POSTMAN_RETENTION_DAYS", &cfg.General.RetentionDays)
 	envStr("CLAUDE_POSTMAN_EMAIL_USER", &cfg.Email.User)
 	envStr("CLAUDE_POSTMAN_EMAIL_PASSWORD", &cfg.Email.AppPassword)
 	envStr("CLAUDE_POSTMAN_SMTP_HOST", &cfg.Email.SMTPHost)
 	envInt("CLAUDE_POSTMAN_SMTP_PORT", &cfg.Email.SMTPPort)
 	envStr("CLAUDE_POSTMAN_IMAP_HOST", &cfg.Email.IMAPHost)
 	envInt("CLAUDE_POSTMAN_IMAP_PORT", &cfg.Email.IMAPPort)
+	envInt("CLAUDE_POSTMAN_TEMPLATE_LIFETIME_DAYS", &cfg.Email.TemplateLifetimeDays)
+	envInt("CLAUDE_POSTMAN_TEMPLATE_REFRESH_DAYS", &cfg.Email.TemplateRefreshDays)
+	envStr("CLAUDE_POSTMAN_PLUS_TAG", &cfg.Email.PlusTag)
+	envInt("CLAUDE_POSTMAN_MAX_MESSAGE_MB", &cfg.Email.MaxMessageMB)
+	envInt("CLAUDE_POSTMAN_RETRY_MAX_ATTEMPTS", &cfg.Email.Retry.MaxAttempts)
+	envInt("CLAUDE_POSTMAN_RETRY_BASE_SEC", &cfg.Email.Retry.BaseSec)
+	envInt("CLAUDE_POSTMAN_RETRY_MAX_SEC", &cfg.Email.Retry.MaxSec)
+	envFloat("CLAUDE_POSTMAN_RETRY_JITTER", cfg.Email.Retry.Jitter)
+	envFloat("CLAUDE_POSTMAN_BUDGET_SESSION_USD", &cfg.Budget.SessionUSD)
+	envFloat("CLAUDE_POSTMAN_BUDGET_DAILY_USD", &cfg.Budget.DailyUSD)
+	envStr("CLAUDE_POSTMAN_DIGEST_SCHEDULE", &cfg.Digest.Schedule)
+	envStr("CLAUDE_POSTMAN_DIGEST_TIME", &cfg.Digest.Time)
+	envInts("CLAUDE_POSTMAN_REMINDER_AFTER_MIN", &cfg.Reminder.AfterMin)
+	envStr("CLAUDE_POSTMAN_HTTP_LISTEN", &cfg.HTTP.Listen)
+	envStr("CLAUDE_POSTMAN_METRICS_LISTEN", &cfg.HTTP.MetricsListen)
 }
 
 func envStr(key string, dst *string) {
@@ -123,6 +280,35 @@ func envInt(key string, dst *int) {
 	}
 }
 
+// envInts는 쉼표로 구분한 정수 목록을 읽는다. 빈 값("")은 무시하고, "none"은 빈 목록
+func envInts(key string, dst *[]int) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	if v == "none" {
+		*dst = []int{}
+		return
+	}
+	var list []int
+	for _, f := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return
+		}
+		list = append(list, n)
+	}
+	*dst = list
+}
+
+func envFloat(key string, dst *float64) {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			*dst = f
+		}
+	}
+}
+
 func validate(cfg *Config) error {
 	if cfg.General.DataDir == "" {
 		return errors.New("general.data_dir is required")
@@ -142,5 +328,104 @@ func validate(cfg *Config) error {
 	if cfg.Email.IMAPHost == "" {
 		return errors.New("email.imap_host is required")
 	}
+	if cfg.Email.TemplateLifetimeDays < 0 || cfg.Email.TemplateRefreshDays < 0 {
+		return errors.New("email.template_lifetime_days and email.template_refresh_days must not be negative")
+	}
+	if cfg.Email.TemplateRefreshDays > cfg.Email.TemplateLifetimeDays {
+		return errors.New("email.template_refresh_days must not exceed email.template_lifetime_days")
+	}
+	if strings.ContainsAny(cfg.Email.PlusTag, "@+ ") {
+		return errors.New("email.plus_tag must not contain '@', '+' or spaces")
+	}
+	if err := validateRetry(cfg.Email.Retry); err != nil {
+		return err
+	}
+	if cfg.General.RetentionDays < 0 {
+		return errors.New("general.retention_days must not be negative")
+	}
+	if cfg.Budget.SessionUSD < 0 || cfg.Budget.DailyUSD < 0 {
+		return errors.New("budget limits must not be negative")
+	}
+	if err := validateDigest(cfg.Digest); err != nil {
+		return err
+	}
+	if err := validateListen(cfg.HTTP.Listen); err != nil {
+		return err
+	}
+	if cfg.HTTP.MetricsListen != "" {
+		if _, _, err := net.SplitHostPort(cfg.HTTP.MetricsListen); err != nil {
+			return fmt.Errorf("http.metrics_listen must be host:port: %w", err)
+		}
+	}
+	for i, m := range cfg.Reminder.AfterMin {
+		if m <= 0 || (i > 0 && m <= cfg.Reminder.AfterMin[i-1]) {
+			return errors.New("reminder.after_min must be positive and increasing")
+		}
+	}
+	return validateTemplates(cfg.Templates)
+}
+
+// validateListen은 http.listen이 비어 있거나, unix 소켓이거나, 루프백 주소인지 확인한다.
+func validateListen(listen string) error {
+	if listen == "" {
+		return nil
+	}
+	if path, ok := strings.CutPrefix(listen, "unix:"); ok {
+		if path == "" {
+			return errors.New("http.listen: unix socket path is required")
+		}
+		return nil
+	}
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return fmt.Errorf("http.listen must be host:port or unix:<path>: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("http.listen must be a loopback address, not %s: the dashboard has no authentication", host)
+	}
+	return nil
+}
+
+func validateRetry(r RetryConfig) error {
+	if r.MaxAttempts < 1 {
+		return errors.New("email.retry.max_attempts must be at least 1")
+	}
+	if r.BaseSec < 1 || r.MaxSec < r.BaseSec {
+		return errors.New("email.retry.base_sec must be positive and not exceed email.retry.max_sec")
+	}
+	if r.Jitter != nil && (*r.Jitter < 0 || *r.Jitter > 1) {
+		return errors.New("email.retry.jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+func validateDigest(d DigestConfig) error {
+	if d.Schedule != "" && !slices.Contains(DigestSchedules, d.Schedule) {
+		return fmt.Errorf("digest.schedule must be one of %s", strings.Join(DigestSchedules, ", "))
+	}
+	if _, err := time.Parse("15:04", d.Time); err != nil {
+		return fmt.Errorf("digest.time must be HH:MM: %s", d.Time)
+	}
+	if !slices.Contains(Weekdays, d.Weekday) {
+		return fmt.Errorf("digest.weekday must be one of %s", strings.Join(Weekdays, ", "))
+	}
+	return nil
+}
+
+func validateTemplates(templates []TemplatePreset) error {
+	seen := make(map[string]bool)
+	for _, t := range templates {
+		if t.Name == "" {
+			return errors.New("templates.name is required")
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate template name: %s", t.Name)
+		}
+		seen[t.Name] = true
+		if t.PermissionMode != "" && !slices.Contains(PermissionModes, t.PermissionMode) {
+			return fmt.Errorf("template %s: permission_mode must be one of %s",
+				t.Name, strings.Join(PermissionModes, ", "))
+		}
+	}
 	return nil
 }
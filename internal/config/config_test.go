@@ -60,6 +60,7 @@ func TestLoadFrom_ValidConfig(t *testing.T) {
 	assert.Equal(t, 993, cfg.Email.IMAPPort)
 	assert.Equal(t, "test@gmail.com", cfg.Email.User)
 	assert.Equal(t, "test-app-password", cfg.Email.AppPassword)
+	assert.Equal(t, 25, cfg.Email.MaxMessageMB, "gmail 프리셋의 메시지 최대 크기")
 }
 
 func TestLoadFrom_MissingConfigFile(t *testing.T) {
@@ -121,6 +122,14 @@ func TestLoadFrom_EnvVarOverrides(t *testing.T) {
 				assert.Equal(t, 45, c.General.SessionTimeoutMin)
 			},
 		},
+		{
+			name:   "CLAUDE_POSTMAN_RETENTION_DAYS",
+			envKey: "CLAUDE_POSTMAN_RETENTION_DAYS",
+			envVal: "7",
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, 7, c.General.RetentionDays)
+			},
+		},
 		{
 			name:   "CLAUDE_POSTMAN_EMAIL_USER",
 			envKey: "CLAUDE_POSTMAN_EMAIL_USER",
@@ -169,6 +178,80 @@ func TestLoadFrom_EnvVarOverrides(t *testing.T) {
 				assert.Equal(t, 143, c.Email.IMAPPort)
 			},
 		},
+		{
+			name:   "CLAUDE_POSTMAN_BUDGET_SESSION_USD",
+			envKey: "CLAUDE_POSTMAN_BUDGET_SESSION_USD",
+			envVal: "2.5",
+			check: func(t *testing.T, c *Config) {
+				assert.InDelta(t, 2.5, c.Budget.SessionUSD, 1e-9)
+			},
+		},
+		{
+			name:   "CLAUDE_POSTMAN_BUDGET_DAILY_USD",
+			envKey: "CLAUDE_POSTMAN_BUDGET_DAILY_USD",
+			envVal: "20",
+			check: func(t *testing.T, c *Config) {
+				assert.InDelta(t, 20.0, c.Budget.DailyUSD, 1e-9)
+			},
+		},
+		{
+			name:   "CLAUDE_POSTMAN_DIGEST_SCHEDULE",
+			envKey: "CLAUDE_POSTMAN_DIGEST_SCHEDULE",
+			envVal: "weekly",
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "weekly", c.Digest.Schedule)
+			},
+		},
+		{
+			name:   "CLAUDE_POSTMAN_REMINDER_AFTER_MIN",
+			envKey: "CLAUDE_POSTMAN_REMINDER_AFTER_MIN",
+			envVal: "30, 120",
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, []int{30, 120}, c.Reminder.AfterMin)
+			},
+		},
+		{
+			name:   "CLAUDE_POSTMAN_REMINDER_AFTER_MIN none",
+			envKey: "CLAUDE_POSTMAN_REMINDER_AFTER_MIN",
+			envVal: "none",
+			check: func(t *testing.T, c *Config) {
+				assert.Empty(t, c.Reminder.AfterMin)
+			},
+		},
+		{
+			name:   "CLAUDE_POSTMAN_DIGEST_TIME",
+			envKey: "CLAUDE_POSTMAN_DIGEST_TIME",
+			envVal: "18:30",
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "18:30", c.Digest.Time)
+			},
+		},
+		{
+			name:   "CLAUDE_POSTMAN_RETRY_MAX_ATTEMPTS",
+			envKey: "CLAUDE_POSTMAN_RETRY_MAX_ATTEMPTS",
+			envVal: "8",
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, 8, c.Email.Retry.MaxAttempts)
+			},
+		},
+		{
+			name:   "CLAUDE_POSTMAN_RETRY_JITTER",
+			envKey: "CLAUDE_POSTMAN_RETRY_JITTER",
+			envVal: "0.5",
+			check: func(t *testing.T, c *Config) {
+				require.NotNil(t, c.Email.Retry.Jitter)
+				assert.InDelta(t, 0.5, *c.Email.Retry.Jitter, 1e-9)
+			},
+		},
+		{
+			name:   "CLAUDE_POSTMAN_METRICS_LISTEN",
+			envKey: "CLAUDE_POSTMAN_METRICS_LISTEN",
+			envVal: "0.0.0.0:9464",
+			check: func(t *testing.T, c *Config) {
+				// 대시보드와 달리 루프백이 아닌 주소도 허용
+				assert.Equal(t, "0.0.0.0:9464", c.HTTP.MetricsListen)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -290,6 +373,107 @@ smtp_host = "smtp.gmail.com"
 imap_host = ""
 user = "test@gmail.com"
 app_password = "test-password"
+`
+			},
+		},
+		{
+			name: "template_refresh_days가 template_lifetime_days보다 큼",
+			setupTOML: func(t *testing.T, dir string) string {
+				dataDir := filepath.Join(dir, "data")
+				require.NoError(t, os.MkdirAll(dataDir, 0755))
+				return `[general]
+data_dir = "` + dataDir + `"
+
+[email]
+smtp_host = "smtp.gmail.com"
+imap_host = "imap.gmail.com"
+user = "test@gmail.com"
+app_password = "test-password"
+template_lifetime_days = 7
+template_refresh_days = 14
+`
+			},
+		},
+		{
+			name: "템플릿 이름 중복",
+			setupTOML: func(t *testing.T, dir string) string {
+				dataDir := filepath.Join(dir, "data")
+				require.NoError(t, os.MkdirAll(dataDir, 0755))
+				return validConfigTOML(dataDir) + `
+[[templates]]
+name = "infra"
+
+[[templates]]
+name = "infra"
+`
+			},
+		},
+		{
+			name: "알 수 없는 permission_mode",
+			setupTOML: func(t *testing.T, dir string) string {
+				dataDir := filepath.Join(dir, "data")
+				require.NoError(t, os.MkdirAll(dataDir, 0755))
+				return validConfigTOML(dataDir) + `
+[[templates]]
+name = "infra"
+permission_mode = "yolo"
+`
+			},
+		},
+		{
+			name: "알 수 없는 digest.schedule",
+			setupTOML: func(t *testing.T, dir string) string {
+				dataDir := filepath.Join(dir, "data")
+				require.NoError(t, os.MkdirAll(dataDir, 0755))
+				return validConfigTOML(dataDir) + `
+[digest]
+schedule = "hourly"
+`
+			},
+		},
+		{
+			name: "루프백이 아닌 http.listen",
+			setupTOML: func(t *testing.T, dir string) string {
+				dataDir := filepath.Join(dir, "data")
+				require.NoError(t, os.MkdirAll(dataDir, 0755))
+				return validConfigTOML(dataDir) + `
+[http]
+listen = "0.0.0.0:8025"
+`
+			},
+		},
+		{
+			name: "reminder.after_min이 오름차순이 아님",
+			setupTOML: func(t *testing.T, dir string) string {
+				dataDir := filepath.Join(dir, "data")
+				require.NoError(t, os.MkdirAll(dataDir, 0755))
+				return validConfigTOML(dataDir) + `
+[reminder]
+after_min = [240, 60]
+`
+			},
+		},
+		{
+			name: "잘못된 digest.time",
+			setupTOML: func(t *testing.T, dir string) string {
+				dataDir := filepath.Join(dir, "data")
+				require.NoError(t, os.MkdirAll(dataDir, 0755))
+				return validConfigTOML(dataDir) + `
+[digest]
+schedule = "daily"
+time = "8am"
+`
+			},
+		},
+		{
+			name: "잘못된 digest.weekday",
+			setupTOML: func(t *testing.T, dir string) string {
+				dataDir := filepath.Join(dir, "data")
+				require.NoError(t, os.MkdirAll(dataDir, 0755))
+				return validConfigTOML(dataDir) + `
+[digest]
+schedule = "weekly"
+weekday = "Mon"
 `
 			},
 		},
@@ -331,6 +515,16 @@ app_password = "test-password"
 	assert.Equal(t, 30, cfg.General.PollIntervalSec, "poll_interval_sec 기본값은 30")
 	assert.Equal(t, 30, cfg.General.SessionTimeoutMin, "session_timeout_min 기본값은 30")
 	assert.Equal(t, "sonnet", cfg.General.DefaultModel, "default_model 기본값은 sonnet")
+	assert.Equal(t, 30, cfg.General.RetentionDays, "retention_days 기본값은 30")
+	assert.Equal(t, 30, cfg.Email.TemplateLifetimeDays, "template_lifetime_days 기본값은 30")
+	assert.Equal(t, 7, cfg.Email.TemplateRefreshDays, "template_refresh_days 기본값은 7")
+	assert.Equal(t, "claude", cfg.Email.PlusTag, "plus_tag 기본값은 claude")
+	assert.Equal(t, 10, cfg.Email.MaxMessageMB, "프로바이더가 없으면 max_message_mb 기본값은 10")
+	assert.Equal(t, RetryConfig{MaxAttempts: 5, BaseSec: 30, MaxSec: 900, Jitter: floatPtr(0.2)}, cfg.Email.Retry, "email.retry 기본값")
+	assert.Empty(t, cfg.Digest.Schedule, "digest는 기본적으로 꺼져 있음")
+	assert.Equal(t, []int{60, 240}, cfg.Reminder.AfterMin, "reminder.after_min 기본값은 1시간, 4시간")
+	assert.Equal(t, "08:00", cfg.Digest.Time, "digest.time 기본값은 08:00")
+	assert.Equal(t, "monday", cfg.Digest.Weekday, "digest.weekday 기본값은 monday")
 }
 
 func TestConfigDir(t *testing.T) {
@@ -341,3 +535,93 @@ func TestConfigDir(t *testing.T) {
 	expected := filepath.Join(home, ".claude-postman")
 	assert.Equal(t, expected, ConfigDir())
 }
+
+func TestLoadFrom_Templates(t *testing.T) {
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	require.NoError(t, os.MkdirAll(dataDir, 0755))
+	writeTestConfig(t, dir, validConfigTOML(dataDir)+`
+[[templates]]
+name = "frontend"
+working_dir = "~/src/frontend"
+model = "opus"
+
+[[templates]]
+name = "infra"
+working_dir = "~/src/infra"
+system_prompt = "Never apply changes, only plan them."
+permission_mode = "plan"
+`)
+
+	cfg, err := LoadFrom(dir)
+	require.NoError(t, err)
+	require.Len(t, cfg.Templates, 2)
+
+	infra := cfg.Template("infra")
+	require.NotNil(t, infra)
+	assert.Equal(t, "~/src/infra", infra.WorkingDir)
+	assert.Equal(t, "plan", infra.PermissionMode)
+	assert.Equal(t, "Never apply changes, only plan them.", infra.SystemPrompt)
+	assert.Equal(t, "opus", cfg.Template("frontend").Model)
+	assert.Nil(t, cfg.Template("missing"), "없는 이름은 nil")
+}
+
+func TestLoadFrom_RemindersDisabled(t *testing.T) {
+	// after_min = [] 이면 기본값을 적용하지 않고 재알림을 끈다
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	require.NoError(t, os.MkdirAll(dataDir, 0755))
+	writeTestConfig(t, dir, validConfigTOML(dataDir)+`
+[reminder]
+after_min = []
+`)
+
+	cfg, err := LoadFrom(dir)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Reminder.AfterMin)
+}
+
+func TestValidateListen(t *testing.T) {
+	for _, listen := range []string{"", "127.0.0.1:8025", "localhost:8025", "[::1]:8025", "unix:/tmp/postman.sock"} {
+		assert.NoError(t, validateListen(listen), listen)
+	}
+	for _, listen := range []string{"0.0.0.0:8025", ":8025", "example.com:80", "8025", "unix:"} {
+		assert.Error(t, validateListen(listen), listen)
+	}
+}
+
+func TestValidateRetry(t *testing.T) {
+	valid := RetryConfig{MaxAttempts: 5, BaseSec: 30, MaxSec: 900, Jitter: floatPtr(0.2)}
+	assert.NoError(t, validateRetry(valid))
+
+	for name, mutate := range map[string]func(*RetryConfig){
+		"max_attempts 0":     func(r *RetryConfig) { r.MaxAttempts = 0 },
+		"base_sec 음수":        func(r *RetryConfig) { r.BaseSec = -1 },
+		"max_sec < base_sec": func(r *RetryConfig) { r.MaxSec = 10 },
+		"jitter > 1":         func(r *RetryConfig) { r.Jitter = floatPtr(1.5) },
+		"jitter 음수":          func(r *RetryConfig) { r.Jitter = floatPtr(-0.1) },
+	} {
+		r := valid
+		mutate(&r)
+		assert.Error(t, validateRetry(r), name)
+	}
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestLoadFrom_RetryJitterDisabled(t *testing.T) {
+	// jitter = 0은 기본값이 아니라 흔들지 않음을 뜻함
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	require.NoError(t, os.MkdirAll(dataDir, 0755))
+	writeTestConfig(t, dir, validConfigTOML(dataDir)+`
+[email.retry]
+jitter = 0
+`)
+
+	cfg, err := LoadFrom(dir)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Email.Retry.Jitter)
+	assert.Zero(t, *cfg.Email.Retry.Jitter)
+	assert.Equal(t, 5, cfg.Email.Retry.MaxAttempts, "나머지는 기본값")
+}
@@ -6,20 +6,27 @@ type EmailPreset struct {
 	SMTPPort int
 	IMAPHost string
 	IMAPPort int
+	// MaxMessageMB는 프로바이더가 받아 주는 메시지 최대 크기 (MB, 인코딩 후)
+	MaxMessageMB int
 }
 
+// defaultMaxMessageMB는 프리셋이 없는 프로바이더의 메시지 최대 크기 (MB)
+const defaultMaxMessageMB = 10
+
 // Presets는 프로바이더별 이메일 프리셋 맵
 var Presets = map[string]EmailPreset{
 	"gmail": {
-		SMTPHost: "smtp.gmail.com",
-		SMTPPort: 587,
-		IMAPHost: "imap.gmail.com",
-		IMAPPort: 993,
+		SMTPHost:     "smtp.gmail.com",
+		SMTPPort:     587,
+		IMAPHost:     "imap.gmail.com",
+		IMAPPort:     993,
+		MaxMessageMB: 25,
 	},
 	"outlook": {
-		SMTPHost: "smtp.office365.com",
-		SMTPPort: 587,
-		IMAPHost: "outlook.office365.com",
-		IMAPPort: 993,
+		SMTPHost:     "smtp.office365.com",
+		SMTPPort:     587,
+		IMAPHost:     "outlook.office365.com",
+		IMAPPort:     993,
+		MaxMessageMB: 20,
 	},
 }
@@ -0,0 +1,398 @@
+// Package dashboard serves the local status dashboard and its JSON API.
+//
+// The listener has no authentication, so config only allows loopback
+// addresses and unix sockets; remote access goes through SSH port
+// forwarding. Requests whose Host is not a loopback name are refused
+// (DNS rebinding), and actions require a JSON POST, which a cross-site form
+// cannot send.
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/metrics"
+	"github.com/yhzion/claude-postman/internal/session"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+//go:embed static/index.html
+var static embed.FS
+
+// defaultLimit caps session and outbox lists unless ?limit= asks otherwise.
+const defaultLimit = 100
+
+// Sessions is the part of session.Manager the dashboard uses.
+type Sessions interface {
+	CaptureOutput(sessionID string) (string, error)
+	EndWithReport(sessionID, reason string) error
+}
+
+// Outbox is the part of email.Mailer the dashboard uses.
+type Outbox interface {
+	Resend(outboxID string) (string, error)
+}
+
+// Server serves the dashboard page and the JSON API.
+type Server struct {
+	store    *storage.Store
+	sessions Sessions
+	outbox   Outbox
+}
+
+// New creates a dashboard server.
+func New(store *storage.Store, sessions Sessions, outbox Outbox) *Server {
+	return &Server{store: store, sessions: sessions, outbox: outbox}
+}
+
+// Handler returns the dashboard's routes:
+//
+//	GET  /                           dashboard page
+//	GET  /api/sessions               sessions, most recently updated first
+//	GET  /api/sessions/{id}          session with usage and turn history
+//	GET  /api/sessions/{id}/output   live tmux pane output
+//	POST /api/sessions/{id}/end      end the session and mail its output
+//	GET  /api/outbox                 outgoing emails (?status=pending|sent|failed)
+//	GET  /api/outbox/{id}            outgoing email with its HTML body
+//	POST /api/outbox/{id}/retry      put a pending or failed email back in the queue
+//	POST /api/outbox/{id}/resend     queue a copy of an email
+//	GET  /api/inbox                  replies not yet delivered to their session
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.index)
+	mux.HandleFunc("GET /api/sessions", s.listSessions)
+	mux.HandleFunc("GET /api/sessions/{id}", s.getSession)
+	mux.HandleFunc("GET /api/sessions/{id}/output", s.sessionOutput)
+	mux.HandleFunc("POST /api/sessions/{id}/end", s.endSession)
+	mux.HandleFunc("GET /api/outbox", s.listOutbox)
+	mux.HandleFunc("GET /api/outbox/{id}", s.getOutbox)
+	mux.HandleFunc("POST /api/outbox/{id}/retry", s.retryOutbox)
+	mux.HandleFunc("POST /api/outbox/{id}/resend", s.resendOutbox)
+	mux.HandleFunc("GET /api/inbox", s.listInbox)
+	mux.Handle("GET /metrics", metrics.Handler(s.store))
+	return guard(mux)
+}
+
+// Serve listens on listen ("host:port" or "unix:<path>") and serves the
+// dashboard until ctx is done.
+func (s *Server) Serve(ctx context.Context, listen string) error {
+	ln, err := Listen(listen)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("dashboard listening", "address", listen)
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Listen opens a TCP listener, or a unix socket readable only by the owner
+// for "unix:<path>". A socket left behind by a previous run is replaced.
+func Listen(listen string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(listen, "unix:")
+	if !ok {
+		return net.Listen("tcp", listen)
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("expand home dir: %w", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// guard refuses requests addressed to a non-loopback host name and actions
+// that are not JSON requests.
+func guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			writeError(w, http.StatusForbidden, errors.New("the dashboard only answers to localhost"))
+			return
+		}
+		if r.Method == http.MethodPost && !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("actions require Content-Type: application/json"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) index(w http.ResponseWriter, _ *http.Request) {
+	page, err := static.ReadFile("static/index.html")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+type sessionView struct {
+	ID         string    `json:"id"`
+	Label      string    `json:"label"`
+	Name       string    `json:"name"`
+	WorkingDir string    `json:"working_dir"`
+	Model      string    `json:"model"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	CostUSD    float64   `json:"cost_usd"`
+}
+
+type turnView struct {
+	Seq          int        `json:"seq"`
+	Prompt       string     `json:"prompt"`
+	Result       string     `json:"result"`
+	Outcome      string     `json:"outcome"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	InputTokens  int64      `json:"input_tokens"`
+	OutputTokens int64      `json:"output_tokens"`
+	CostUSD      float64    `json:"cost_usd"`
+}
+
+type outboxView struct {
+	ID          string     `json:"id"`
+	SessionID   string     `json:"session_id"`
+	Subject     string     `json:"subject"`
+	Status      string     `json:"status"`
+	RetryCount  int        `json:"retry_count"`
+	NextRetryAt *time.Time `json:"next_retry_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SentAt      *time.Time `json:"sent_at"`
+	Size        int        `json:"size"`
+	Body        string     `json:"body,omitempty"`
+}
+
+type inboxView struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (s *Server) sessionView(sess *storage.Session) (sessionView, error) {
+	usage, err := s.store.SessionUsage(sess.ID)
+	return sessionView{
+		ID: sess.ID, Label: email.SessionLabel(sess), Name: sess.Name,
+		WorkingDir: sess.WorkingDir, Model: sess.Model, Status: sess.Status,
+		CreatedAt: sess.CreatedAt, UpdatedAt: sess.UpdatedAt, CostUSD: usage.CostUSD,
+	}, err
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.store.ListSessions(limit(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]sessionView, 0, len(sessions))
+	for _, sess := range sessions {
+		v, err := s.sessionView(sess)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		views = append(views, v)
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.store.GetSession(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	view, err := s.sessionView(sess)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	turns, err := s.store.ListTurns(sess.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	turnViews := make([]turnView, 0, len(turns))
+	for _, t := range turns {
+		turnViews = append(turnViews, turnView{
+			Seq: t.Seq, Prompt: t.Prompt, Result: t.Result, Outcome: t.Outcome,
+			StartedAt: t.StartedAt, FinishedAt: t.FinishedAt,
+			InputTokens: t.InputTokens, OutputTokens: t.OutputTokens, CostUSD: t.CostUSD,
+		})
+	}
+	writeJSON(w, http.StatusOK, struct {
+		sessionView
+		Turns []turnView `json:"turns"`
+	}{view, turnViews})
+}
+
+func (s *Server) sessionOutput(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.store.GetSession(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if sess.Status == "ended" {
+		writeError(w, http.StatusConflict, session.ErrSessionEnded)
+		return
+	}
+	output, err := s.sessions.CaptureOutput(sess.ID)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"output": output})
+}
+
+func (s *Server) endSession(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.sessions.EndWithReport(id, "Session ended from the dashboard."); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	slog.Info("session ended from the dashboard", "session_id", id)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ended"})
+}
+
+func (s *Server) listOutbox(w http.ResponseWriter, r *http.Request) {
+	msgs, err := s.store.ListOutbox(r.URL.Query().Get("status"), limit(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]outboxView, 0, len(msgs))
+	for _, msg := range msgs {
+		views = append(views, outboxToView(msg))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) getOutbox(w http.ResponseWriter, r *http.Request) {
+	msg, err := s.store.GetOutbox(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	view := outboxToView(msg)
+	view.Body = msg.Body
+	writeJSON(w, http.StatusOK, view)
+}
+
+func (s *Server) retryOutbox(w http.ResponseWriter, r *http.Request) {
+	msg, err := s.store.GetOutbox(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if msg.Status == "sent" {
+		writeError(w, http.StatusConflict, errors.New("email was already sent; resend it instead"))
+		return
+	}
+	if err := s.store.RequeueOutbox(msg.ID); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	slog.Info("outbox email requeued from the dashboard", "outbox_id", msg.ID)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "pending"})
+}
+
+func (s *Server) resendOutbox(w http.ResponseWriter, r *http.Request) {
+	id, err := s.outbox.Resend(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	slog.Info("outbox email resent from the dashboard", "outbox_id", r.PathValue("id"), "copy_id", id)
+	writeJSON(w, http.StatusOK, map[string]string{"id": id})
+}
+
+func (s *Server) listInbox(w http.ResponseWriter, _ *http.Request) {
+	msgs, err := s.store.ListPendingInbox()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	views := make([]inboxView, 0, len(msgs))
+	for _, msg := range msgs {
+		views = append(views, inboxView{ID: msg.ID, SessionID: msg.SessionID, Body: msg.Body, CreatedAt: msg.CreatedAt})
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func outboxToView(msg *storage.OutboxMessage) outboxView {
+	return outboxView{
+		ID: msg.ID, SessionID: msg.SessionID, Subject: msg.Subject, Status: msg.Status,
+		RetryCount: msg.RetryCount, NextRetryAt: msg.NextRetryAt,
+		CreatedAt: msg.CreatedAt, SentAt: msg.SentAt, Size: len(msg.Body),
+	}
+}
+
+func limit(r *http.Request) int {
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		return n
+	}
+	return defaultLimit
+}
+
+// statusFor maps lookup and state errors to HTTP status codes.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, session.ErrSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, session.ErrSessionEnded), errors.Is(err, email.ErrTemplateResend):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("failed to write dashboard response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,199 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/session"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+type fakeSessions struct {
+	ended []string
+}
+
+func (f *fakeSessions) CaptureOutput(sessionID string) (string, error) {
+	return "output of " + sessionID, nil
+}
+
+func (f *fakeSessions) EndWithReport(sessionID, _ string) error {
+	if sessionID == "missing" {
+		return session.ErrSessionNotFound
+	}
+	f.ended = append(f.ended, sessionID)
+	return nil
+}
+
+type fakeOutbox struct {
+	resent []string
+}
+
+func (f *fakeOutbox) Resend(outboxID string) (string, error) {
+	f.resent = append(f.resent, outboxID)
+	return "copy-of-" + outboxID, nil
+}
+
+func newTestDashboard(t *testing.T) (http.Handler, *storage.Store, *fakeSessions, *fakeOutbox) {
+	t.Helper()
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	require.NoError(t, store.Migrate())
+
+	require.NoError(t, store.CreateSession(&storage.Session{
+		ID: "sess-1", WorkingDir: "/home/me/api-server", Name: "Fix flaky test", Model: "sonnet", Status: "waiting",
+	}))
+	require.NoError(t, store.CreateSession(&storage.Session{ID: "sess-2", WorkingDir: "/tmp", Status: "ended"}))
+	require.NoError(t, store.CreateTurn(&storage.Turn{
+		ID: "turn-1", SessionID: "sess-1", Prompt: "Fix it", Result: "Which fix?", Outcome: "waiting",
+		Usage: storage.Usage{CostUSD: 0.25},
+	}))
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{ID: "out-failed", SessionID: "sess-1", Subject: "failed one", Body: "<p>x</p>", Status: "failed", RetryCount: 5}))
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{ID: "out-sent", SessionID: "sess-1", Subject: "sent one", Body: "<p>y</p>", Status: "sent"}))
+	require.NoError(t, store.EnqueueMessage(&storage.InboxMessage{ID: "in-1", SessionID: "sess-1", Body: "Use the second fix"}))
+
+	sessions, outbox := &fakeSessions{}, &fakeOutbox{}
+	return New(store, sessions, outbox).Handler(), store, sessions, outbox
+}
+
+func do(t *testing.T, h http.Handler, method, path string, out any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, "http://localhost:8025"+path, strings.NewReader("{}"))
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out), rec.Body.String())
+	}
+	return rec.Code
+}
+
+func TestSessions(t *testing.T) {
+	h, _, sessions, _ := newTestDashboard(t)
+
+	var list []sessionView
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/api/sessions", &list))
+	require.Len(t, list, 2)
+	byID := map[string]sessionView{list[0].ID: list[0], list[1].ID: list[1]}
+	assert.Equal(t, "api-server: Fix flaky test", byID["sess-1"].Label)
+	assert.InDelta(t, 0.25, byID["sess-1"].CostUSD, 1e-9)
+
+	var detail struct {
+		sessionView
+		Turns []turnView `json:"turns"`
+	}
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/api/sessions/sess-1", &detail))
+	assert.Equal(t, "waiting", detail.Status)
+	require.Len(t, detail.Turns, 1)
+	assert.Equal(t, "Which fix?", detail.Turns[0].Result)
+
+	var output map[string]string
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/api/sessions/sess-1/output", &output))
+	assert.Equal(t, "output of sess-1", output["output"])
+	assert.Equal(t, http.StatusConflict, do(t, h, "GET", "/api/sessions/sess-2/output", nil), "종료된 세션")
+	assert.Equal(t, http.StatusNotFound, do(t, h, "GET", "/api/sessions/missing", nil))
+
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/api/sessions/sess-1/end", nil))
+	assert.Equal(t, []string{"sess-1"}, sessions.ended)
+	assert.Equal(t, http.StatusNotFound, do(t, h, "POST", "/api/sessions/missing/end", nil))
+}
+
+func TestOutboxAndInbox(t *testing.T) {
+	h, store, _, outbox := newTestDashboard(t)
+
+	var list []outboxView
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/api/outbox?status=failed", &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, "out-failed", list[0].ID)
+	assert.Empty(t, list[0].Body, "목록에는 본문 없음")
+
+	var msg outboxView
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/api/outbox/out-failed", &msg))
+	assert.Equal(t, "<p>x</p>", msg.Body)
+
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/api/outbox/out-failed/retry", nil))
+	requeued, err := store.GetOutbox("out-failed")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", requeued.Status)
+	assert.Equal(t, 0, requeued.RetryCount)
+	assert.Equal(t, http.StatusConflict, do(t, h, "POST", "/api/outbox/out-sent/retry", nil), "발송된 메일은 resend")
+	assert.Equal(t, http.StatusNotFound, do(t, h, "POST", "/api/outbox/missing/retry", nil))
+
+	var resent map[string]string
+	require.Equal(t, http.StatusOK, do(t, h, "POST", "/api/outbox/out-sent/resend", &resent))
+	assert.Equal(t, "copy-of-out-sent", resent["id"])
+	assert.Equal(t, []string{"out-sent"}, outbox.resent)
+
+	var inbox []inboxView
+	require.Equal(t, http.StatusOK, do(t, h, "GET", "/api/inbox", &inbox))
+	require.Len(t, inbox, 1)
+	assert.Equal(t, "Use the second fix", inbox[0].Body)
+}
+
+func TestGuard(t *testing.T) {
+	h, _, sessions, _ := newTestDashboard(t)
+
+	req := httptest.NewRequest("GET", "http://evil.example.com/api/sessions", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "루프백이 아닌 Host 거부")
+
+	for _, host := range []string{"localhost:8025", "127.0.0.1:8025", "[::1]:8025", "localhost"} {
+		req := httptest.NewRequest("GET", "/api/sessions", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, host)
+	}
+
+	req = httptest.NewRequest("POST", "http://localhost:8025/api/sessions/sess-1/end", strings.NewReader("x=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code, "폼 POST 거부")
+	assert.Empty(t, sessions.ended)
+}
+
+func TestIndex(t *testing.T) {
+	h, _, _, _ := newTestDashboard(t)
+	req := httptest.NewRequest("GET", "http://localhost:8025/", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/api/sessions")
+}
+
+func TestMetrics(t *testing.T) {
+	h, _, _, _ := newTestDashboard(t)
+	req := httptest.NewRequest("GET", "http://localhost:8025/metrics", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `claude_postman_sessions{status="waiting"} 1`)
+}
+
+func TestListen_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dashboard.sock")
+	// A socket left behind by a crashed run is replaced.
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := Listen("unix:" + path)
+	require.NoError(t, err)
+	defer ln.Close()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "소유자만 접근")
+}
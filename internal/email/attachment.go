@@ -0,0 +1,40 @@
+package email
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Attachment is a file attached to an outgoing email.
+// Attachments are stored as JSON in the outbox attachments column.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// EncodeAttachments serializes attachments for storage in the outbox.
+// Returns nil when there are no attachments.
+func EncodeAttachments(atts []Attachment) (*string, error) {
+	if len(atts) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(atts)
+	if err != nil {
+		return nil, fmt.Errorf("encode attachments: %w", err)
+	}
+	s := string(b)
+	return &s, nil
+}
+
+// DecodeAttachments parses the outbox attachments column.
+func DecodeAttachments(s *string) ([]Attachment, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	var atts []Attachment
+	if err := json.Unmarshal([]byte(*s), &atts); err != nil {
+		return nil, fmt.Errorf("decode attachments: %w", err)
+	}
+	return atts, nil
+}
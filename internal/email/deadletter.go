@@ -0,0 +1,87 @@
+package email
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/yhzion/claude-postman/internal/metrics"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// deadLetterProbeInterval is how often a dead letter summary is attempted
+// while no other mail goes out to show that SMTP works again.
+const deadLetterProbeInterval = 10 * time.Minute
+
+// maxDeadLetters caps the messages listed in one summary.
+const maxDeadLetters = 50
+
+// reportDeadLetters mails a summary of the failed messages not reported yet.
+// It goes straight over SMTP rather than through the outbox, so a summary
+// sent while SMTP is still down does not become a dead letter itself. It is
+// tried right after a flush delivered mail, and otherwise at most every
+// deadLetterProbeInterval. Messages are marked reported only once it is sent.
+func (m *Mailer) reportDeadLetters(delivered bool, now time.Time) {
+	if !delivered && now.Sub(m.deadLetterProbe) < deadLetterProbeInterval {
+		return
+	}
+	msgs, err := m.store.ListUnreportedFailed()
+	if err != nil {
+		slog.Error("failed to list dead letters", "error", err)
+		return
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	m.deadLetterProbe = now
+
+	body, err := RenderHTML(deadLetterMarkdown(msgs))
+	if err != nil {
+		slog.Error("failed to render dead letter summary", "error", err)
+		return
+	}
+	start := time.Now()
+	err = m.smtp.Send(m.cfg.User, m.cfg.User, nil, deadLetterSubject(len(msgs)), body, NewMessageID(), "", nil)
+	metrics.SMTPSendDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.SMTPSendErrors.WithLabelValues(classifyFailure(err).String()).Inc()
+		slog.Warn("dead letter summary not sent", "error", err)
+		return
+	}
+
+	ids := make([]string, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID
+	}
+	if err := m.store.MarkReported(ids); err != nil {
+		slog.Error("failed to mark dead letters reported", "error", err)
+	}
+}
+
+func deadLetterSubject(n int) string {
+	if n == 1 {
+		return subjectPrefix + " 1 email could not be delivered"
+	}
+	return fmt.Sprintf("%s %d emails could not be delivered", subjectPrefix, n)
+}
+
+func deadLetterMarkdown(msgs []*storage.OutboxMessage) string {
+	var b strings.Builder
+	b.WriteString("These emails failed after every retry while the mail server was unreachable. " +
+		"They are kept in the outbox until you retry or drop them.\n\n")
+	for i, msg := range msgs {
+		if i == maxDeadLetters {
+			fmt.Fprintf(&b, "- …and %d more\n", len(msgs)-maxDeadLetters)
+			break
+		}
+		fmt.Fprintf(&b, "- `%s` %s — %s\n", shortID(msg.ID),
+			msg.CreatedAt.Local().Format("Jan 2 15:04"), msg.Subject)
+	}
+	b.WriteString("\nOn the relay host:\n\n" +
+		"    claude-postman outbox retry-all    # send them all again\n" +
+		"    claude-postman outbox retry <id>   # send one again\n" +
+		"    claude-postman outbox show <id>    # read one\n" +
+		"    claude-postman outbox drop --all   # discard them\n")
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package email
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+func createFailed(t *testing.T, store *storage.Store, id, subject string) {
+	t.Helper()
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{
+		ID: id, Subject: subject, Body: "<p>result</p>", Status: "failed", RetryCount: 5,
+	}))
+}
+
+func TestFlushOutbox_DeadLetterSummary(t *testing.T) {
+	smtp := &mockSMTPSender{err: errors.New("connection refused")}
+	m, store := testMailer(t, &mockIMAPClient{}, smtp)
+	createFailed(t, store, "dead-1111", "[claude-postman] api: done")
+	createFailed(t, store, "dead-2222", "[claude-postman] web: done")
+
+	require.NoError(t, m.FlushOutbox())
+	assert.Empty(t, smtp.sent, "SMTP가 아직 안 되면 보내지 못함")
+	msgs, err := store.ListUnreportedFailed()
+	require.NoError(t, err)
+	assert.Len(t, msgs, 2, "보내지 못했으면 보고하지 않은 상태로 남음")
+
+	// SMTP 복구 후 다른 메일이 나가면 바로 요약 발송
+	smtp.err = nil
+	require.NoError(t, m.Send("", "notice", "<p>hi</p>"))
+	require.NoError(t, m.FlushOutbox())
+	require.Len(t, smtp.sent, 2)
+	summary := smtp.sent[1]
+	assert.Equal(t, "[claude-postman] 2 emails could not be delivered", summary.subject)
+	assert.Contains(t, summary.body, "dead-111")
+	assert.Contains(t, summary.body, "api: done")
+	assert.Contains(t, summary.body, "claude-postman outbox retry-all")
+
+	// 한 번만 보고
+	require.NoError(t, m.Send("", "notice", "<p>hi</p>"))
+	require.NoError(t, m.FlushOutbox())
+	assert.Len(t, smtp.sent, 3)
+	msgs, err = store.ListUnreportedFailed()
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+}
+
+func TestReportDeadLetters_Probe(t *testing.T) {
+	smtp := &mockSMTPSender{err: errors.New("connection refused")}
+	m, store := testMailer(t, &mockIMAPClient{}, smtp)
+	createFailed(t, store, "dead-1", "result")
+	now := time.Now()
+
+	m.reportDeadLetters(false, now)
+	assert.Equal(t, now, m.deadLetterProbe, "보낼 메일이 없으면 요약 자체로 SMTP를 확인")
+
+	smtp.err = nil
+	m.reportDeadLetters(false, now.Add(time.Minute))
+	assert.Empty(t, smtp.sent, "확인 간격 전에는 다시 시도하지 않음")
+
+	m.reportDeadLetters(false, now.Add(deadLetterProbeInterval))
+	require.Len(t, smtp.sent, 1)
+	assert.Equal(t, "[claude-postman] 1 email could not be delivered", smtp.sent[0].subject)
+	assert.True(t, IsRelayMessageID(smtp.sent[0].messageID), "직접 보낸 요약도 Poll이 알아보는 Message-ID")
+}
+
+func TestDeadLetterMarkdown_Truncates(t *testing.T) {
+	msgs := make([]*storage.OutboxMessage, maxDeadLetters+3)
+	for i := range msgs {
+		msgs[i] = &storage.OutboxMessage{ID: "id", Subject: "s"}
+	}
+	assert.Contains(t, deadLetterMarkdown(msgs), "…and 3 more")
+}
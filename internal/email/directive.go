@@ -0,0 +1,181 @@
+package email
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yhzion/claude-postman/internal/config"
+)
+
+// Directives are optional session settings written as "Key: value" lines at
+// the top of a session request, next to Directory and Model.
+type Directives struct {
+	Name    string        // human-readable session title
+	Branch  string        // git branch to check out (created if missing)
+	Timeout time.Duration // end the session this long after it started
+	Effort  string        // thinking effort: low, medium or high
+	Mode    string        // claude --permission-mode
+	Notify  string        // "progress" also mails periodic progress
+	CC      []string      // extra recipients of the session's emails
+	Budget  float64       // session budget in USD
+}
+
+const maxNameLength = 80
+
+var (
+	// directiveLineRe matches a "Key: value" header line. A value starting
+	// with "//" is not one, so a leading URL such as "https://..." is not
+	// taken for a key.
+	directiveLineRe = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*):(\s*(?:[^\s/].*|/(?:[^/].*)?)?)$`)
+	branchRe        = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+)
+
+var (
+	effortLevels  = []string{"low", "medium", "high"}
+	notifyOptions = []string{"done", "progress"}
+)
+
+// ParseDirectives reads the directives at the top of prompt: the leading
+// "Key: value" lines, up to the first line that is neither such a line nor
+// blank. Directives are removed from the prompt and validated; invalid values
+// are described in errs.
+//
+// Unknown keys among the directives are listed in unknown and removed too, so
+// a mistyped key such as "Mdoe: plan" is reported rather than silently
+// dropped. The directive block ends at the last known key, plus any unknown
+// lines right below it; an unknown line after a blank line stays in the
+// prompt. Lines that start with an unknown key and contain no valid directive,
+// such as a pasted log ("Error: ..."), are not directives at all.
+func ParseDirectives(prompt string) (d Directives, rest string, unknown, errs []string) {
+	type entry struct {
+		line  int
+		key   string
+		known bool
+		valid bool
+		gap   bool // a blank line comes before it
+	}
+	lines := strings.Split(prompt, "\n")
+	var entries []entry
+	gap := false
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			gap = len(entries) > 0
+			continue
+		}
+		m := directiveLineRe.FindStringSubmatch(line)
+		if m == nil {
+			break
+		}
+		known, err := d.set(strings.ToLower(m[1]), strings.TrimSpace(m[2]))
+		if known && err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", m[1], err))
+		}
+		entries = append(entries, entry{line: i, key: m[1], known: known, valid: known && err == nil, gap: gap})
+		gap = false
+	}
+
+	last, anyValid := -1, false
+	for i, e := range entries {
+		if e.known {
+			last = i
+			anyValid = anyValid || e.valid
+		}
+	}
+	if last < 0 || (!entries[0].known && !anyValid) {
+		return Directives{}, strings.TrimSpace(prompt), nil, nil
+	}
+	end := last + 1
+	for end < len(entries) && !entries[end].gap {
+		end++
+	}
+	for _, e := range entries[:end] {
+		if !e.known {
+			unknown = append(unknown, e.key)
+		}
+		lines[e.line] = ""
+	}
+	return d, strings.TrimSpace(strings.Join(lines, "\n")), unknown, errs
+}
+
+// set parses a single directive. Returns false if key is not a directive.
+func (d *Directives) set(key, value string) (bool, error) {
+	switch key {
+	case "name":
+		if value == "" {
+			return true, fmt.Errorf("must not be empty")
+		}
+		if len([]rune(value)) > maxNameLength {
+			return true, fmt.Errorf("must be at most %d characters", maxNameLength)
+		}
+		d.Name = value
+	case "branch":
+		if !branchRe.MatchString(value) || strings.HasPrefix(value, "-") ||
+			strings.Contains(value, "..") || strings.HasSuffix(value, ".lock") {
+			return true, fmt.Errorf("%q is not a valid branch name", value)
+		}
+		d.Branch = value
+	case "timeout":
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return true, err
+		}
+		d.Timeout = timeout
+	case "effort":
+		value = strings.ToLower(value)
+		if !slices.Contains(effortLevels, value) {
+			return true, fmt.Errorf("must be one of %s", strings.Join(effortLevels, ", "))
+		}
+		d.Effort = value
+	case "mode":
+		if !slices.Contains(config.PermissionModes, value) {
+			return true, fmt.Errorf("must be one of %s", strings.Join(config.PermissionModes, ", "))
+		}
+		d.Mode = value
+	case "notify":
+		value = strings.ToLower(value)
+		if !slices.Contains(notifyOptions, value) {
+			return true, fmt.Errorf("must be one of %s", strings.Join(notifyOptions, ", "))
+		}
+		d.Notify = value
+	case "cc":
+		addrs, err := mail.ParseAddressList(value)
+		if err != nil {
+			return true, fmt.Errorf("invalid address list: %v", err)
+		}
+		d.CC = d.CC[:0]
+		for _, a := range addrs {
+			d.CC = append(d.CC, a.Address)
+		}
+	case "budget":
+		budget, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+		if err != nil || budget <= 0 {
+			return true, fmt.Errorf("%q is not a positive amount in USD", value)
+		}
+		d.Budget = budget
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// parseTimeout accepts a Go duration ("90m", "2h") or a bare number of minutes.
+func parseTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		minutes, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("%q is not a duration like 90m or 2h", value)
+		}
+		timeout = time.Duration(minutes) * time.Minute
+	}
+	if timeout < time.Minute {
+		return 0, fmt.Errorf("must be at least 1m")
+	}
+	return timeout, nil
+}
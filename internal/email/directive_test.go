@@ -0,0 +1,110 @@
+package email
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirectives(t *testing.T) {
+	t.Run("parses all directives", func(t *testing.T) {
+		prompt := "Name: Login bug\nBranch: fix/login\nTimeout: 2h\nEffort: High\nMode: plan\n" +
+			"Notify: progress\nCC: Alice <alice@example.com>, bob@example.com\nBudget: $2.50\n\n" +
+			"Fix the login bug"
+
+		d, rest, unknown, errs := ParseDirectives(prompt)
+		assert.Empty(t, errs)
+		assert.Empty(t, unknown)
+		assert.Equal(t, "Fix the login bug", rest)
+		assert.Equal(t, Directives{
+			Name:    "Login bug",
+			Branch:  "fix/login",
+			Timeout: 2 * time.Hour,
+			Effort:  "high",
+			Mode:    "plan",
+			Notify:  "progress",
+			CC:      []string{"alice@example.com", "bob@example.com"},
+			Budget:  2.5,
+		}, d)
+	})
+
+	t.Run("only leading lines are directives", func(t *testing.T) {
+		d, rest, unknown, errs := ParseDirectives("Fix the bug.\nBranch: main\n")
+		assert.Empty(t, errs)
+		assert.Empty(t, unknown)
+		assert.Empty(t, d.Branch, "본문 중간의 Key: value는 지시어가 아님")
+		assert.Equal(t, "Fix the bug.\nBranch: main", rest)
+	})
+
+	t.Run("timeout accepts bare minutes", func(t *testing.T) {
+		d, _, _, errs := ParseDirectives("Timeout: 45\n\ntask")
+		assert.Empty(t, errs)
+		assert.Equal(t, 45*time.Minute, d.Timeout)
+	})
+
+	t.Run("unknown key between directives is reported", func(t *testing.T) {
+		d, rest, unknown, errs := ParseDirectives("Effort: low\nPriority: high\nBranch: main\n\ntask")
+		assert.Empty(t, errs)
+		assert.Equal(t, []string{"Priority"}, unknown)
+		assert.Equal(t, "low", d.Effort)
+		assert.Equal(t, "main", d.Branch, "모르는 키 뒤의 지시어도 적용")
+		assert.Equal(t, "task", rest)
+	})
+
+	t.Run("mistyped first key is reported", func(t *testing.T) {
+		d, rest, unknown, errs := ParseDirectives("Mdoe: plan\nBranch: x\n\ntask")
+		assert.Empty(t, errs)
+		assert.Equal(t, []string{"Mdoe"}, unknown, "첫 줄의 오타도 보고되어야 함")
+		assert.Empty(t, d.Mode)
+		assert.Equal(t, "x", d.Branch)
+		assert.Equal(t, "task", rest)
+	})
+
+	t.Run("unknown key right below the last directive is reported", func(t *testing.T) {
+		_, rest, unknown, _ := ParseDirectives("Name: Login bug\nMdoe: plan\n\ntask")
+		assert.Equal(t, []string{"Mdoe"}, unknown)
+		assert.Equal(t, "task", rest)
+	})
+
+	t.Run("unknown key after a blank line stays in the prompt", func(t *testing.T) {
+		d, rest, unknown, errs := ParseDirectives("Mode: plan\n\nError: build failed\nWhy?")
+		assert.Empty(t, errs)
+		assert.Empty(t, unknown, "빈 줄 뒤의 Key: value는 프롬프트")
+		assert.Equal(t, "plan", d.Mode)
+		assert.Equal(t, "Error: build failed\nWhy?", rest)
+	})
+
+	t.Run("log-style prompt is not parsed", func(t *testing.T) {
+		prompt := "Error: build failed\nTimeout: lock wait exceeded\n\nWhy does the build fail?"
+		d, rest, unknown, errs := ParseDirectives(prompt)
+		assert.Empty(t, errs, "로그 줄의 Timeout을 지시어로 읽지 않음")
+		assert.Empty(t, unknown)
+		assert.Zero(t, d.Timeout)
+		assert.Equal(t, prompt, rest)
+	})
+
+	t.Run("URL-first prompt is not parsed", func(t *testing.T) {
+		prompt := "https://github.com/yhzion/claude-postman/issues/1\nEffort: high\n\nFix this issue"
+		d, rest, unknown, errs := ParseDirectives(prompt)
+		assert.Empty(t, errs)
+		assert.Empty(t, unknown, "URL의 scheme을 키로 보지 않음")
+		assert.Empty(t, d.Effort)
+		assert.Equal(t, prompt, rest)
+	})
+
+	t.Run("value may start with a single slash", func(t *testing.T) {
+		d, rest, _, errs := ParseDirectives("Branch: /x\n\ntask")
+		assert.Empty(t, errs)
+		assert.Equal(t, "/x", d.Branch, "//로 시작하는 값만 지시어가 아님")
+		assert.Equal(t, "task", rest)
+	})
+
+	t.Run("invalid values are reported", func(t *testing.T) {
+		_, _, _, errs := ParseDirectives("Branch: -delete\nTimeout: forever\nEffort: extreme\nMode: yolo\n" +
+			"Notify: always\nCC: not an address\nBudget: -5\nName:\n\ntask")
+		assert.Len(t, errs, 8)
+		assert.Contains(t, errs[0], "Branch:")
+		assert.Contains(t, errs[1], "Timeout:")
+	})
+}
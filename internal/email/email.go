@@ -2,18 +2,20 @@
 package email
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/yhzion/claude-postman/internal/config"
+	"github.com/yhzion/claude-postman/internal/metrics"
 	"github.com/yhzion/claude-postman/internal/storage"
 )
 
-const maxRetries = 5
-
 // IncomingMessage represents a parsed incoming email.
 type IncomingMessage struct {
 	From         string
@@ -24,6 +26,16 @@ type IncomingMessage struct {
 	IsNewSession bool   // template reply detected
 	WorkingDir   string // parsed from template (IsNewSession=true)
 	Model        string // parsed from template (IsNewSession=true)
+	Template     string // preset name of the replied template, "" for the default (IsNewSession=true)
+	Command      string // relay command such as "commit" (existing session replies)
+	CommandArg   string // argument of Command, e.g. the commit message
+
+	// Session settings from "Key: value" lines (IsNewSession=true).
+	// UnknownDirectives lists keys that are not directives (kept in the
+	// prompt); DirectiveErrors describes invalid directive values.
+	Directives        Directives
+	UnknownDirectives []string
+	DirectiveErrors   []string
 }
 
 // Mailer handles email sending and receiving.
@@ -32,6 +44,10 @@ type Mailer struct {
 	store *storage.Store
 	imap  func() (IMAPClient, error) // factory for per-poll connections
 	smtp  SMTPSender
+
+	random          func() float64 // jitter source, in [0, 1)
+	pausedUntil     time.Time      // sending is paused until then after an auth failure
+	deadLetterProbe time.Time      // last dead letter summary attempt
 }
 
 // New creates a new Mailer with real IMAP/SMTP implementations.
@@ -42,20 +58,33 @@ func New(cfg *config.EmailConfig, store *storage.Store) *Mailer {
 		imap: func() (IMAPClient, error) {
 			return newIMAPClient(cfg)
 		},
-		smtp: newSMTPSender(cfg),
+		smtp:   newSMTPSender(cfg),
+		random: rand.Float64,
 	}
 }
 
 // Poll fetches unread emails from IMAP and parses them into IncomingMessages.
 // It does NOT write to the database — only reads for session matching.
 func (m *Mailer) Poll() ([]*IncomingMessage, error) {
+	start := time.Now()
+	msgs, err := m.poll()
+	metrics.IMAPPollDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.IMAPPollErrors.Inc()
+		return nil, err
+	}
+	metrics.LastPoll.SetToCurrentTime()
+	return msgs, nil
+}
+
+func (m *Mailer) poll() ([]*IncomingMessage, error) {
 	client, err := m.imap()
 	if err != nil {
 		return nil, err
 	}
 	defer client.Close()
 
-	raws, err := client.FetchUnread("[claude-postman]")
+	raws, err := client.FetchUnread(subjectPrefix, m.plusAddress())
 	if err != nil {
 		return nil, err
 	}
@@ -68,11 +97,12 @@ func (m *Mailer) Poll() ([]*IncomingMessage, error) {
 			continue
 		}
 
-		// Filter out self-received template emails
-		if ok, _ := m.store.IsValidTemplateRef(raw.MessageID); ok {
-			slog.Debug("ignoring self-received template", "message_id", raw.MessageID)
+		// Filter out the relay's own emails (templates, session results,
+		// digests, alerts): they carry the subject prefix too.
+		if IsRelayMessageID(raw.MessageID) {
+			slog.Debug("ignoring self-received email", "message_id", raw.MessageID)
 			if markErr := client.MarkRead(raw.UID); markErr != nil {
-				slog.Warn("failed to mark template as read", "uid", raw.UID, "error", markErr)
+				slog.Warn("failed to mark own email as read", "uid", raw.UID, "error", markErr)
 			}
 			continue
 		}
@@ -89,9 +119,23 @@ func (m *Mailer) Poll() ([]*IncomingMessage, error) {
 	return msgs, nil
 }
 
+// relayMessageIDSuffix ends the Message-ID of every email the relay sends.
+const relayMessageIDSuffix = "@claude-postman>"
+
+// NewMessageID returns a fresh Message-ID for an email sent by the relay.
+func NewMessageID() string {
+	return "<" + uuid.New().String() + relayMessageIDSuffix
+}
+
+// IsRelayMessageID reports whether messageID was made by NewMessageID, i.e.
+// the email was sent by the relay itself.
+func IsRelayMessageID(messageID string) bool {
+	return strings.HasSuffix(messageID, relayMessageIDSuffix)
+}
+
 // Send inserts an email into the outbox for later delivery by FlushOutbox.
 func (m *Mailer) Send(sessionID, subject, htmlBody string) error {
-	msgID := fmt.Sprintf("<%s@claude-postman>", uuid.New().String())
+	msgID := NewMessageID()
 	return m.store.CreateOutbox(&storage.OutboxMessage{
 		ID:        uuid.New().String(),
 		SessionID: sessionID,
@@ -102,55 +146,191 @@ func (m *Mailer) Send(sessionID, subject, htmlBody string) error {
 	})
 }
 
-// FlushOutbox sends all pending outbox messages via SMTP.
-// Uses exponential backoff on failure (30s * 2^(retry-1), max 5 retries).
+// ErrTemplateResend is returned by Resend for template emails: a copy would
+// carry a Message-ID that no template record knows.
+var ErrTemplateResend = errors.New("template emails cannot be resent; use send-template")
+
+// Resend queues a copy of an outbox message with a new Message-ID and
+// returns the copy's outbox ID. A copy is sent because clients drop a
+// second message with a Message-ID they already have.
+func (m *Mailer) Resend(outboxID string) (string, error) {
+	orig, err := m.store.GetOutbox(outboxID)
+	if err != nil {
+		return "", err
+	}
+	if orig.MessageID != nil {
+		tmpl, err := m.store.GetTemplateByMessageID(*orig.MessageID)
+		if err != nil {
+			return "", err
+		}
+		if tmpl != nil {
+			return "", ErrTemplateResend
+		}
+	}
+	msgID := NewMessageID()
+	msg := &storage.OutboxMessage{
+		ID:          uuid.New().String(),
+		SessionID:   orig.SessionID,
+		MessageID:   &msgID,
+		Subject:     orig.Subject,
+		Body:        orig.Body,
+		Attachments: orig.Attachments,
+		Status:      "pending",
+	}
+	return msg.ID, m.store.CreateOutbox(msg)
+}
+
+// FlushOutbox sends all pending outbox messages via SMTP. Failures are
+// handled by kind (see handleFailure): transient ones are retried with
+// backoff per email.retry, permanent ones fail right away, and an auth
+// failure pauses the outbox. Once a flush sends without errors, messages
+// that ran out of retries are reported in a dead letter summary.
 func (m *Mailer) FlushOutbox() error {
+	if m.outboxPaused(time.Now()) {
+		return nil
+	}
 	msgs, err := m.store.GetPendingOutbox()
 	if err != nil {
 		return err
 	}
+	delivered, failed := 0, 0
 	for _, msg := range msgs {
-		m.flushOne(msg)
+		if m.outboxPaused(time.Now()) {
+			return nil
+		}
+		if m.flushOne(msg) {
+			delivered++
+		} else {
+			failed++
+		}
+	}
+	if failed == 0 {
+		m.reportDeadLetters(delivered > 0, time.Now())
 	}
 	return nil
 }
 
-func (m *Mailer) flushOne(msg *storage.OutboxMessage) {
-	messageID := ""
+// flushOne sends one outbox message and reports whether SMTP accepted it.
+func (m *Mailer) flushOne(msg *storage.OutboxMessage) bool {
+	// Every outbox message is queued with a Message-ID; NewMessageID covers
+	// older rows so that Poll still recognizes the email as the relay's own.
+	messageID := NewMessageID()
 	if msg.MessageID != nil {
 		messageID = *msg.MessageID
 	}
 
-	err := m.smtp.Send(m.cfg.User, m.cfg.User, msg.Subject, msg.Body, messageID, "")
+	attachments, err := DecodeAttachments(msg.Attachments)
 	if err != nil {
-		slog.Warn("smtp send failed", "outbox_id", msg.ID, "error", err)
-		m.handleRetry(msg)
-		return
+		slog.Warn("dropping undecodable attachments", "outbox_id", msg.ID, "error", err)
 	}
 
+	body, attachments := fitMessage(msg.Body, attachments, m.cfg.MaxMessageMB<<20)
+
+	cc, inReplyTo := m.sessionHeaders(msg.SessionID)
+	start := time.Now()
+	err = m.smtp.Send(m.cfg.User, m.cfg.User, cc, msg.Subject, body, messageID, inReplyTo, attachments)
+	metrics.SMTPSendDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.handleFailure(msg, err, time.Now())
+		return false
+	}
+	m.resumeOutbox()
+
 	if markErr := m.store.MarkSent(msg.ID); markErr != nil {
 		slog.Error("failed to mark outbox as sent", "id", msg.ID, "error", markErr)
 	}
+	return true
+}
+
+// sessionHeaders returns the extra recipients set for a session with the
+// CC: directive and the Message-ID of the email that started the session,
+// which every session email replies to so they share one thread. Both are
+// empty for mail that belongs to no session.
+func (m *Mailer) sessionHeaders(sessionID string) (cc []string, inReplyTo string) {
+	if sessionID == "" {
+		return nil, ""
+	}
+	if session, err := m.store.GetSession(sessionID); err == nil {
+		cc = session.CC
+	}
+	turns, err := m.store.ListTurns(sessionID)
+	if err == nil && len(turns) > 0 {
+		inReplyTo = turns[0].SourceMessageID
+	}
+	return cc, inReplyTo
 }
 
-func (m *Mailer) handleRetry(msg *storage.OutboxMessage) {
-	retryCount := msg.RetryCount + 1
-	if retryCount >= maxRetries {
-		if err := m.store.MarkFailed(msg.ID, retryCount, nil); err != nil {
-			slog.Error("failed to mark outbox as failed", "id", msg.ID, "error", err)
+// templateSubject is the subject of session creation template emails.
+const templateSubject = subjectPrefix + " New Session"
+
+// SendTemplate sends the session creation template email right away and
+// returns its Message-ID. Used by commands that must report SMTP errors.
+// A nil preset sends the default template.
+func (m *Mailer) SendTemplate(preset *config.TemplatePreset) (string, error) {
+	tmpl, htmlBody, err := m.newTemplate(preset)
+	if err != nil {
+		return "", err
+	}
+	if err := m.smtp.Send(m.cfg.User, m.cfg.User, nil, templateSubjectFor(tmpl.Name), htmlBody, tmpl.MessageID, "", nil); err != nil {
+		return "", fmt.Errorf("send template: %w", err)
+	}
+	if err := m.store.SaveTemplate(tmpl); err != nil {
+		return "", fmt.Errorf("save template: %w", err)
+	}
+	return tmpl.MessageID, nil
+}
+
+// QueueTemplate queues the default session creation template email in the
+// outbox, so it is delivered (and retried) by FlushOutbox like any other
+// message. The template is valid from the moment it is queued.
+func (m *Mailer) QueueTemplate() (string, error) {
+	tmpl, htmlBody, err := m.newTemplate(nil)
+	if err != nil {
+		return "", err
+	}
+	err = m.store.Tx(context.Background(), func(tx *storage.Store) error {
+		if err := tx.SaveTemplate(tmpl); err != nil {
+			return fmt.Errorf("save template: %w", err)
 		}
-		return
+		return tx.CreateOutbox(&storage.OutboxMessage{
+			ID:        uuid.New().String(),
+			MessageID: &tmpl.MessageID,
+			Subject:   templateSubject,
+			Body:      htmlBody,
+			Status:    "pending",
+		})
+	})
+	if err != nil {
+		return "", err
 	}
-	backoff := 30 * time.Second * (1 << (retryCount - 1))
-	nextRetry := time.Now().Add(backoff)
-	if err := m.store.UpdateRetry(msg.ID, retryCount, &nextRetry); err != nil {
-		slog.Error("failed to update retry", "id", msg.ID, "error", err)
+	return tmpl.MessageID, nil
+}
+
+// templateSubjectFor returns the subject of the template named name.
+func templateSubjectFor(name string) string {
+	if name == "" {
+		return templateSubject
 	}
+	return templateSubject + ": " + name
 }
 
-// SendTemplate sends the session creation template email and returns its Message-ID.
-func (m *Mailer) SendTemplate() (string, error) {
-	templateBody := `How to create a new Claude Code session
+// newTemplate builds a template record and its rendered HTML body,
+// prefilled with the preset's directory and model when preset is not nil.
+func (m *Mailer) newTemplate(preset *config.TemplatePreset) (*storage.Template, string, error) {
+	name, workingDir, model := "", "~", "sonnet"
+	var presetInfo string
+	if preset != nil {
+		name = preset.Name
+		if preset.WorkingDir != "" {
+			workingDir = preset.WorkingDir
+		}
+		if preset.Model != "" {
+			model = preset.Model
+		}
+		presetInfo = templatePresetInfo(preset)
+	}
+
+	templateBody := `%sHow to create a new Claude Code session
 ========================================
 
 IMPORTANT — Do NOT change:
@@ -166,9 +346,9 @@ You CAN edit:
 
 ────────────────────────────────────
 
-Directory: ~
+Directory: %s
 
-Model: sonnet
+Model: %s
 
 (Write your task here)
 
@@ -177,43 +357,46 @@ Model: sonnet
 Tips:
   - You can reply to this email multiple times
     — each reply creates a new session
-  - A fresh template is sent every time the server starts`
-	htmlBody, err := RenderHTML(templateBody)
+  - A fresh template is sent when the server starts and the latest one is getting old
+  - This template expires on %s; use a newer one after that`
+	expiresAt := time.Now().AddDate(0, 0, m.cfg.TemplateLifetimeDays)
+	htmlBody, err := RenderHTML(fmt.Sprintf(templateBody, presetInfo, workingDir, model, expiresAt.Format("2006-01-02")))
 	if err != nil {
-		return "", fmt.Errorf("render template: %w", err)
+		return nil, "", fmt.Errorf("render template: %w", err)
 	}
+	return &storage.Template{
+		ID:        uuid.New().String(),
+		MessageID: NewMessageID(),
+		Name:      name,
+		ExpiresAt: &expiresAt,
+	}, htmlBody, nil
+}
 
-	messageID := fmt.Sprintf("<%s@claude-postman>", uuid.New().String())
-	subject := "[claude-postman] New Session"
-
-	if err := m.smtp.Send(m.cfg.User, m.cfg.User, subject, htmlBody, messageID, ""); err != nil {
-		return "", fmt.Errorf("send template: %w", err)
+// templatePresetInfo describes the settings a preset applies to new sessions.
+func templatePresetInfo(preset *config.TemplatePreset) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Template: %s\n", preset.Name)
+	if preset.PermissionMode != "" {
+		fmt.Fprintf(&b, "  - Permission mode: %s\n", preset.PermissionMode)
 	}
-
-	templateID := uuid.New().String()
-	if err := m.store.SaveTemplate(&storage.Template{
-		ID:        templateID,
-		MessageID: messageID,
-	}); err != nil {
-		return "", fmt.Errorf("save template: %w", err)
+	if preset.SystemPrompt != "" {
+		fmt.Fprintf(&b, "  - Extra instructions: %s\n", preset.SystemPrompt)
 	}
-
-	return messageID, nil
+	return b.String() + "\n"
 }
 
-// isTemplateRef checks if any of the message references match a known template.
-func (m *Mailer) isTemplateRef(inReplyTo string, references []string) bool {
-	if inReplyTo != "" {
-		if ok, _ := m.store.IsValidTemplateRef(inReplyTo); ok {
-			return true
+// findTemplateRef returns the template referenced by In-Reply-To or
+// References, including revoked and expired ones, or nil if none matches.
+func (m *Mailer) findTemplateRef(inReplyTo string, references []string) *storage.Template {
+	for _, ref := range append([]string{inReplyTo}, references...) {
+		if ref == "" {
+			continue
 		}
-	}
-	for _, ref := range references {
-		if ok, _ := m.store.IsValidTemplateRef(ref); ok {
-			return true
+		if tmpl, _ := m.store.GetTemplateByMessageID(ref); tmpl != nil {
+			return tmpl
 		}
 	}
-	return false
+	return nil
 }
 
 // matchByMessageID tries to find a session ID by matching outbox Message-IDs.
@@ -241,23 +424,96 @@ func (m *Mailer) classifyRawEmail(raw *RawEmail) *IncomingMessage {
 		MessageID: raw.MessageID,
 	}
 
-	if m.isTemplateRef(raw.InReplyTo, raw.References) {
+	if tmpl := m.findTemplateRef(raw.InReplyTo, raw.References); tmpl != nil {
+		if !tmpl.Valid(time.Now()) {
+			// A leaked or outdated template must not spawn sessions.
+			slog.Warn("ignoring reply to expired or revoked template", "template_id", tmpl.ID)
+			return msg
+		}
+		msg.IsNewSession = true
+		msg.Template = tmpl.Name
+		parseSessionRequest(msg, raw.Body)
+	} else if dir, ok := m.composedSessionRequest(raw); ok {
+		if authFailed(raw.AuthResults) {
+			// Without a template reference the sender check is all that
+			// stands between a spoofed email and a new session.
+			slog.Warn("ignoring session request that failed sender authentication",
+				"message_id", raw.MessageID, "auth", raw.AuthResults)
+			return msg
+		}
 		msg.IsNewSession = true
-		body := raw.Body
-		if looksLikeHTML(body) {
-			body = ExtractTextFromHTML(body)
+		parseSessionRequest(msg, raw.Body)
+		if msg.WorkingDir == "" {
+			msg.WorkingDir = dir
 		}
-		msg.WorkingDir, msg.Model, msg.Body = ParseTemplate(body)
 	} else {
+		// The Session-ID is usually in the quoted result email, so look
+		// for it before the quote is stripped.
 		msg.SessionID = ParseSessionID(raw.Body)
 		if msg.SessionID == "" {
 			msg.SessionID = m.matchByMessageID(raw.InReplyTo, raw.References)
 		}
+		msg.Body = ExtractReply(raw.Body)
+		msg.Command, msg.CommandArg = ParseCommand(msg.Body)
 	}
 
 	return msg
 }
 
+// parseSessionRequest fills in the directory, model, directives and prompt
+// of a new session request from its body.
+func parseSessionRequest(msg *IncomingMessage, body string) {
+	if looksLikeHTML(body) {
+		body = HTMLToMarkdown(body)
+	}
+	msg.WorkingDir, msg.Model, msg.Body = ParseTemplate(body)
+	msg.Body = stripSignature(msg.Body)
+	msg.Directives, msg.Body, msg.UnknownDirectives, msg.DirectiveErrors = ParseDirectives(msg.Body)
+}
+
+// plusAddress returns the address that new sessions can be requested at,
+// e.g. me+claude@example.com, or "" if the user address has no domain.
+func (m *Mailer) plusAddress() string {
+	at := strings.LastIndex(m.cfg.User, "@")
+	if at <= 0 || m.cfg.PlusTag == "" {
+		return ""
+	}
+	return m.cfg.User[:at] + "+" + m.cfg.PlusTag + m.cfg.User[at:]
+}
+
+// composedSessionRequest reports whether raw is a freshly composed email
+// (not a reply) asking for a new session: sent to the plus-address, or with
+// a "[claude-postman] new: <dir>" subject. Returns the directory from the
+// subject, if any.
+func (m *Mailer) composedSessionRequest(raw *RawEmail) (workingDir string, ok bool) {
+	if raw.InReplyTo != "" || len(raw.References) > 0 {
+		return "", false
+	}
+	if dir, ok := ParseNewSessionSubject(raw.Subject); ok {
+		return dir, true
+	}
+	plus := m.plusAddress()
+	for _, to := range raw.To {
+		if plus != "" && strings.EqualFold(to, plus) {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// authFailed reports whether an Authentication-Results header records a
+// failed SPF, DKIM or DMARC check. A missing header is not a failure:
+// mail a user sends to themselves is often delivered without one.
+func authFailed(results string) bool {
+	lower := strings.ToLower(results)
+	for _, check := range []string{"spf=fail", "spf=softfail", "dkim=fail", "dmarc=fail"} {
+		if strings.Contains(lower, check) {
+			return true
+		}
+	}
+	return false
+}
+
 func looksLikeHTML(s string) bool {
 	lower := strings.ToLower(s)
 	return strings.Contains(lower, "<html") || strings.Contains(lower, "<body") ||
@@ -1,6 +1,7 @@
 package email
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 	"time"
@@ -15,12 +16,14 @@ import (
 // --- Mock IMAP ---
 
 type mockIMAPClient struct {
-	emails []*RawEmail
-	marked []imap.UID
-	err    error
+	emails  []*RawEmail
+	marked  []imap.UID
+	err     error
+	fetchTo string
 }
 
-func (m *mockIMAPClient) FetchUnread(_ string) ([]*RawEmail, error) {
+func (m *mockIMAPClient) FetchUnread(_, to string) ([]*RawEmail, error) {
+	m.fetchTo = to
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -37,19 +40,29 @@ func (m *mockIMAPClient) Close() error { return nil }
 // --- Mock SMTP ---
 
 type mockSMTPSender struct {
-	sent []sentEmail
-	err  error
+	sent     []sentEmail
+	err      error
+	fail     func(subject string) error // per-message error, checked after err
+	attempts int
 }
 
 type sentEmail struct {
 	from, to, subject, body, messageID, inReplyTo string
+	cc                                            []string
+	attachments                                   []Attachment
 }
 
-func (m *mockSMTPSender) Send(from, to, subject, body, messageID, inReplyTo string) error {
+func (m *mockSMTPSender) Send(from, to string, cc []string, subject, body, messageID, inReplyTo string, attachments []Attachment) error {
+	m.attempts++
 	if m.err != nil {
 		return m.err
 	}
-	m.sent = append(m.sent, sentEmail{from, to, subject, body, messageID, inReplyTo})
+	if m.fail != nil {
+		if err := m.fail(subject); err != nil {
+			return err
+		}
+	}
+	m.sent = append(m.sent, sentEmail{from, to, subject, body, messageID, inReplyTo, cc, attachments})
 	return nil
 }
 
@@ -64,7 +77,10 @@ func testMailer(t *testing.T, imapClient *mockIMAPClient, smtpSender *mockSMTPSe
 	t.Cleanup(func() { store.Close() })
 
 	cfg := &config.EmailConfig{
-		User: "user@example.com",
+		User:                 "user@example.com",
+		TemplateLifetimeDays: 30,
+		PlusTag:              "claude",
+		Retry:                config.RetryConfig{MaxAttempts: 5, BaseSec: 30, MaxSec: 900},
 	}
 
 	m := &Mailer{
@@ -73,7 +89,8 @@ func testMailer(t *testing.T, imapClient *mockIMAPClient, smtpSender *mockSMTPSe
 		imap: func() (IMAPClient, error) {
 			return imapClient, nil
 		},
-		smtp: smtpSender,
+		smtp:   smtpSender,
+		random: func() float64 { return 0.5 },
 	}
 	return m, store
 }
@@ -111,6 +128,37 @@ func TestSend(t *testing.T) {
 	})
 }
 
+func TestResend(t *testing.T) {
+	m, store := testMailer(t, &mockIMAPClient{}, &mockSMTPSender{})
+	sessionID := "11111111-1111-1111-1111-111111111111"
+	createTestSession(t, store, sessionID)
+	origID := "<orig@claude-postman>"
+	atts := `[{"filename":"a.patch"}]`
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{
+		ID: "orig", SessionID: sessionID, MessageID: &origID, Subject: "[claude-postman] x — done",
+		Body: "<p>Done</p>", Attachments: &atts, Status: "failed", RetryCount: 5,
+	}))
+
+	id, err := m.Resend("orig")
+	require.NoError(t, err)
+	cp, err := store.GetOutbox(id)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", cp.Status)
+	assert.Equal(t, 0, cp.RetryCount)
+	assert.Equal(t, sessionID, cp.SessionID)
+	assert.Equal(t, "<p>Done</p>", cp.Body)
+	assert.Equal(t, atts, *cp.Attachments)
+	assert.NotEqual(t, origID, *cp.MessageID, "새 Message-ID")
+
+	tmplID := "<tmpl@claude-postman>"
+	require.NoError(t, store.SaveTemplate(&storage.Template{ID: "t1", MessageID: tmplID}))
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{
+		ID: "tmpl", MessageID: &tmplID, Subject: "[claude-postman] New Session", Body: "b", Status: "sent",
+	}))
+	_, err = m.Resend("tmpl")
+	assert.ErrorIs(t, err, ErrTemplateResend)
+}
+
 func TestFlushOutbox(t *testing.T) {
 	t.Run("sends pending and marks sent", func(t *testing.T) {
 		smtp := &mockSMTPSender{}
@@ -132,6 +180,36 @@ func TestFlushOutbox(t *testing.T) {
 		assert.Empty(t, msgs)
 	})
 
+	t.Run("copies session CC recipients", func(t *testing.T) {
+		smtp := &mockSMTPSender{}
+		m, store := testMailer(t, &mockIMAPClient{}, smtp)
+		require.NoError(t, store.CreateSession(&storage.Session{
+			ID: "cc-session", TmuxName: "session-cc", WorkingDir: "/tmp", Model: "sonnet",
+			Status: "idle", CC: []string{"lead@example.com"},
+		}))
+
+		require.NoError(t, m.Send("cc-session", "result", "<p>body</p>"))
+		require.NoError(t, m.FlushOutbox())
+		require.Len(t, smtp.sent, 1)
+		assert.Equal(t, []string{"lead@example.com"}, smtp.sent[0].cc)
+	})
+
+	t.Run("session emails reply to the request", func(t *testing.T) {
+		smtp := &mockSMTPSender{}
+		m, store := testMailer(t, &mockIMAPClient{}, smtp)
+		sessionID := "44444444-4444-4444-4444-444444444444"
+		createTestSession(t, store, sessionID)
+		require.NoError(t, store.CreateTurn(&storage.Turn{
+			ID: "turn-1", SessionID: sessionID, Prompt: "task", SourceMessageID: "<request@mail>",
+		}))
+
+		require.NoError(t, m.Send(sessionID, "result", "<p>body</p>"))
+		require.NoError(t, m.FlushOutbox())
+		require.Len(t, smtp.sent, 1)
+		assert.Equal(t, "<request@mail>", smtp.sent[0].inReplyTo, "같은 스레드로 묶이도록 요청 메일에 답장")
+		assert.NotEmpty(t, smtp.sent[0].messageID)
+	})
+
 	t.Run("failure increments retry with backoff", func(t *testing.T) {
 		smtp := &mockSMTPSender{err: errors.New("smtp error")}
 		m, store := testMailer(t, &mockIMAPClient{}, smtp)
@@ -161,7 +239,7 @@ func TestFlushOutbox(t *testing.T) {
 		sessionID := "44444444-4444-4444-4444-444444444444"
 		createTestSession(t, store, sessionID)
 
-		// Create outbox with retry_count = maxRetries-1 (4)
+		// Create outbox with retry_count = max_attempts-1 (4)
 		msgID := "<test@claude-postman>"
 		require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{
 			ID:         "outbox-max-retry",
@@ -170,7 +248,7 @@ func TestFlushOutbox(t *testing.T) {
 			Subject:    "test",
 			Body:       "<p>body</p>",
 			Status:     "pending",
-			RetryCount: maxRetries - 1,
+			RetryCount: m.cfg.Retry.MaxAttempts - 1,
 		}))
 
 		err := m.FlushOutbox()
@@ -183,12 +261,101 @@ func TestFlushOutbox(t *testing.T) {
 	})
 }
 
+func TestFlushOutbox_Attachments(t *testing.T) {
+	smtp := &mockSMTPSender{}
+	m, store := testMailer(t, &mockIMAPClient{}, smtp)
+	sessionID := "66666666-6666-6666-6666-666666666666"
+	createTestSession(t, store, sessionID)
+
+	atts, err := EncodeAttachments([]Attachment{
+		{Filename: "changes.patch", ContentType: "text/x-diff", Data: []byte("+added")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{
+		ID:          "outbox-att",
+		SessionID:   sessionID,
+		Subject:     "result",
+		Body:        "<p>body</p>",
+		Attachments: atts,
+		Status:      "pending",
+	}))
+
+	require.NoError(t, m.FlushOutbox())
+	require.Len(t, smtp.sent, 1)
+	require.Len(t, smtp.sent[0].attachments, 1)
+	assert.Equal(t, "changes.patch", smtp.sent[0].attachments[0].Filename)
+	assert.Equal(t, "+added", string(smtp.sent[0].attachments[0].Data))
+}
+
+func TestFlushOutbox_MaxMessageSize(t *testing.T) {
+	smtp := &mockSMTPSender{}
+	m, store := testMailer(t, &mockIMAPClient{}, smtp)
+	m.cfg.MaxMessageMB = 1
+	sessionID := "77777777-7777-7777-7777-777777777777"
+	createTestSession(t, store, sessionID)
+
+	atts, err := EncodeAttachments([]Attachment{
+		{Filename: "small.patch", Data: []byte("+added")},
+		{Filename: "huge.md", Data: bytes.Repeat([]byte("x"), 1<<20)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{
+		ID:          "outbox-big",
+		SessionID:   sessionID,
+		Subject:     "result",
+		Body:        "<html><body><p>body</p></body></html>",
+		Attachments: atts,
+		Status:      "pending",
+	}))
+
+	require.NoError(t, m.FlushOutbox())
+	require.Len(t, smtp.sent, 1)
+	require.Len(t, smtp.sent[0].attachments, 1, "큰 첨부만 제외")
+	assert.Equal(t, "small.patch", smtp.sent[0].attachments[0].Filename)
+	assert.Contains(t, smtp.sent[0].body, "huge.md (1.0 MB) was not attached")
+}
+
+func TestBuildMessage(t *testing.T) {
+	t.Run("single html part without attachments", func(t *testing.T) {
+		raw, err := buildMessage("a@x", "a@x", nil, "subj", "<p>hi</p>", "<id@x>", "", nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "Content-Type: text/html; charset=utf-8\r\n\r\n<p>hi</p>")
+		assert.NotContains(t, string(raw), "multipart")
+		assert.NotContains(t, string(raw), "Cc:")
+	})
+
+	t.Run("non-ASCII subject is encoded", func(t *testing.T) {
+		raw, err := buildMessage("a@x", "a@x", nil, "[claude-postman] api: 버그 수정 — done", "<p>hi</p>", "", "", nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "Subject: =?utf-8?q?")
+		assert.NotContains(t, string(raw), "버그")
+	})
+
+	t.Run("cc header", func(t *testing.T) {
+		raw, err := buildMessage("a@x", "a@x", []string{"b@x", "c@x"}, "subj", "<p>hi</p>", "", "", nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "Cc: b@x, c@x\r\n")
+	})
+
+	t.Run("multipart mixed with attachments", func(t *testing.T) {
+		raw, err := buildMessage("a@x", "a@x", nil, "subj", "<p>hi</p>", "", "", []Attachment{
+			{Filename: "changes.patch", ContentType: "text/x-diff", Data: []byte("+added")},
+		})
+		require.NoError(t, err)
+		s := string(raw)
+		assert.Contains(t, s, "Content-Type: multipart/mixed; boundary=")
+		assert.Contains(t, s, "<p>hi</p>")
+		assert.Contains(t, s, `attachment; filename="changes.patch"`)
+		assert.Contains(t, s, "K2FkZGVk") // base64("+added")
+	})
+}
+
 func TestSendTemplate(t *testing.T) {
 	t.Run("sends template email and returns messageID", func(t *testing.T) {
 		smtp := &mockSMTPSender{}
 		m, _ := testMailer(t, &mockIMAPClient{}, smtp)
 
-		messageID, err := m.SendTemplate()
+		messageID, err := m.SendTemplate(nil)
 		require.NoError(t, err)
 		assert.NotEmpty(t, messageID)
 		assert.Contains(t, messageID, "@claude-postman>")
@@ -200,11 +367,26 @@ func TestSendTemplate(t *testing.T) {
 		assert.Equal(t, "user@example.com", smtp.sent[0].to)
 	})
 
+	t.Run("records expiry from the configured lifetime", func(t *testing.T) {
+		smtp := &mockSMTPSender{}
+		m, store := testMailer(t, &mockIMAPClient{}, smtp)
+
+		messageID, err := m.SendTemplate(nil)
+		require.NoError(t, err)
+
+		tmpl, err := store.GetTemplateByMessageID(messageID)
+		require.NoError(t, err)
+		require.NotNil(t, tmpl)
+		require.NotNil(t, tmpl.ExpiresAt)
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), *tmpl.ExpiresAt, time.Minute)
+		assert.Contains(t, smtp.sent[0].body, "expires on "+tmpl.ExpiresAt.Format("2006-01-02"))
+	})
+
 	t.Run("template body instructs reply not forward", func(t *testing.T) {
 		smtp := &mockSMTPSender{}
 		m, _ := testMailer(t, &mockIMAPClient{}, smtp)
 
-		_, err := m.SendTemplate()
+		_, err := m.SendTemplate(nil)
 		require.NoError(t, err)
 
 		require.Len(t, smtp.sent, 1)
@@ -215,6 +397,57 @@ func TestSendTemplate(t *testing.T) {
 	})
 }
 
+func TestSendTemplate_Preset(t *testing.T) {
+	smtp := &mockSMTPSender{}
+	imapMock := &mockIMAPClient{}
+	m, store := testMailer(t, imapMock, smtp)
+
+	messageID, err := m.SendTemplate(&config.TemplatePreset{
+		Name: "infra", WorkingDir: "~/src/infra", Model: "opus", PermissionMode: "plan",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, smtp.sent, 1)
+	assert.Equal(t, "[claude-postman] New Session: infra", smtp.sent[0].subject)
+	assert.Contains(t, smtp.sent[0].body, "Directory: ~/src/infra")
+	assert.Contains(t, smtp.sent[0].body, "Model: opus")
+	assert.Contains(t, smtp.sent[0].body, "Permission mode: plan")
+
+	tmpl, err := store.GetTemplateByMessageID(messageID)
+	require.NoError(t, err)
+	require.NotNil(t, tmpl)
+	assert.Equal(t, "infra", tmpl.Name)
+
+	// 답장은 어떤 프리셋 템플릿에 대한 것인지 알려줌
+	imapMock.emails = []*RawEmail{
+		{From: "user@example.com", Subject: "Re: [claude-postman] New Session: infra", Body: "Upgrade the cluster", InReplyTo: messageID, UID: 1},
+	}
+	msgs, err := m.Poll()
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.True(t, msgs[0].IsNewSession)
+	assert.Equal(t, "infra", msgs[0].Template)
+}
+
+func TestQueueTemplate(t *testing.T) {
+	smtp := &mockSMTPSender{}
+	m, _ := testMailer(t, &mockIMAPClient{}, smtp)
+
+	messageID, err := m.QueueTemplate()
+	require.NoError(t, err)
+	assert.Empty(t, smtp.sent, "큐에만 넣고 바로 보내지 않음")
+
+	tmpl := m.findTemplateRef(messageID, nil)
+	require.NotNil(t, tmpl, "큐에 넣은 즉시 답장으로 참조 가능")
+	assert.True(t, tmpl.Valid(time.Now()), "큐에 넣은 즉시 유효")
+
+	require.NoError(t, m.FlushOutbox())
+	require.Len(t, smtp.sent, 1)
+	assert.Equal(t, "[claude-postman] New Session", smtp.sent[0].subject)
+	assert.Equal(t, messageID, smtp.sent[0].messageID)
+	assert.Contains(t, smtp.sent[0].body, "REPLY")
+}
+
 func TestPoll(t *testing.T) {
 	t.Run("filters by sender", func(t *testing.T) {
 		imap := &mockIMAPClient{
@@ -238,7 +471,7 @@ func TestPoll(t *testing.T) {
 		m, _ := testMailer(t, imapMock, smtp)
 
 		// First, send a template to create a reference
-		messageID, err := m.SendTemplate()
+		messageID, err := m.SendTemplate(nil)
 		require.NoError(t, err)
 
 		// Now simulate a reply to the template email
@@ -261,6 +494,123 @@ func TestPoll(t *testing.T) {
 		assert.Equal(t, "Build a feature", msgs[0].Body)
 	})
 
+	t.Run("ignores reply to revoked template", func(t *testing.T) {
+		imapMock := &mockIMAPClient{}
+		m, store := testMailer(t, imapMock, &mockSMTPSender{})
+
+		messageID, err := m.SendTemplate(nil)
+		require.NoError(t, err)
+		_, err = store.RevokeTemplates(messageID)
+		require.NoError(t, err)
+
+		imapMock.emails = []*RawEmail{
+			{
+				From:      "user@example.com",
+				Subject:   "[claude-postman] New Session",
+				Body:      "Directory: /home/test\n\nrm -rf everything",
+				InReplyTo: messageID,
+				UID:       1,
+			},
+		}
+
+		msgs, err := m.Poll()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.False(t, msgs[0].IsNewSession, "폐기된 템플릿은 세션을 만들 수 없음")
+		assert.Empty(t, msgs[0].SessionID)
+	})
+
+	t.Run("ignores reply to expired template", func(t *testing.T) {
+		imapMock := &mockIMAPClient{}
+		m, store := testMailer(t, imapMock, &mockSMTPSender{})
+
+		expired := time.Now().Add(-time.Hour)
+		require.NoError(t, store.SaveTemplate(&storage.Template{
+			ID: "old", MessageID: "<old@claude-postman>", ExpiresAt: &expired,
+		}))
+		imapMock.emails = []*RawEmail{
+			{
+				From:       "user@example.com",
+				Subject:    "Re: [claude-postman] New Session",
+				Body:       "Build a feature",
+				References: []string{"<old@claude-postman>"},
+				UID:        1,
+			},
+		}
+
+		msgs, err := m.Poll()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.False(t, msgs[0].IsNewSession, "만료된 템플릿은 세션을 만들 수 없음")
+	})
+
+	t.Run("creates session from composed email with new: subject", func(t *testing.T) {
+		imapMock := &mockIMAPClient{
+			emails: []*RawEmail{
+				{From: "user@example.com", To: []string{"user@example.com"}, Subject: "[claude-postman] new: ~/api", Body: "Model: opus\n\nFix the login bug", UID: 1},
+			},
+		}
+		m, _ := testMailer(t, imapMock, &mockSMTPSender{})
+
+		msgs, err := m.Poll()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.True(t, msgs[0].IsNewSession)
+		assert.Equal(t, "~/api", msgs[0].WorkingDir)
+		assert.Equal(t, "opus", msgs[0].Model)
+		assert.Equal(t, "Fix the login bug", msgs[0].Body)
+		assert.Empty(t, msgs[0].Template)
+	})
+
+	t.Run("creates session from composed email to plus-address", func(t *testing.T) {
+		imapMock := &mockIMAPClient{
+			emails: []*RawEmail{
+				{From: "user@example.com", To: []string{"User+Claude@example.com"}, Subject: "login bug", Body: "Directory: ~/web\n\nFix it", UID: 1},
+			},
+		}
+		m, _ := testMailer(t, imapMock, &mockSMTPSender{})
+
+		msgs, err := m.Poll()
+		require.NoError(t, err)
+		assert.Equal(t, "user+claude@example.com", imapMock.fetchTo, "plus 주소로 온 메일도 가져와야 함")
+		require.Len(t, msgs, 1)
+		assert.True(t, msgs[0].IsNewSession)
+		assert.Equal(t, "~/web", msgs[0].WorkingDir)
+		assert.Equal(t, "Fix it", msgs[0].Body)
+	})
+
+	t.Run("composed request still requires authorized sender", func(t *testing.T) {
+		imapMock := &mockIMAPClient{
+			emails: []*RawEmail{
+				{From: "attacker@example.com", To: []string{"user+claude@example.com"}, Subject: "[claude-postman] new: ~", Body: "rm -rf ~", UID: 1},
+				{
+					From: "user@example.com", Subject: "[claude-postman] new: ~", Body: "rm -rf ~", UID: 2,
+					AuthResults: "mx.google.com; spf=fail smtp.mailfrom=user@example.com; dmarc=fail",
+				},
+			},
+		}
+		m, _ := testMailer(t, imapMock, &mockSMTPSender{})
+
+		msgs, err := m.Poll()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1, "다른 발신자는 걸러짐")
+		assert.False(t, msgs[0].IsNewSession, "SPF/DMARC 실패 메일은 세션을 만들 수 없음")
+	})
+
+	t.Run("reply with new: subject is not a composed request", func(t *testing.T) {
+		imapMock := &mockIMAPClient{
+			emails: []*RawEmail{
+				{From: "user@example.com", Subject: "[claude-postman] new: ~/api", Body: "again", InReplyTo: "<unknown@mail>", UID: 1},
+			},
+		}
+		m, _ := testMailer(t, imapMock, &mockSMTPSender{})
+
+		msgs, err := m.Poll()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.False(t, msgs[0].IsNewSession)
+	})
+
 	t.Run("matches existing session by Session-ID", func(t *testing.T) {
 		imap := &mockIMAPClient{
 			emails: []*RawEmail{
@@ -281,13 +631,35 @@ func TestPoll(t *testing.T) {
 		assert.Equal(t, "aabbccdd-1122-3344-5566-778899001122", msgs[0].SessionID)
 	})
 
+	t.Run("strips quoted result from existing session reply", func(t *testing.T) {
+		imap := &mockIMAPClient{
+			emails: []*RawEmail{
+				{
+					From:    "user@example.com",
+					Subject: "Re: [claude-postman] project: task — done",
+					Body: "Use approach B\n\nSent from my iPhone\n\n" +
+						"On Mon, Feb 23, 2026 at 10:00 AM <bot@example.com> wrote:\n" +
+						"> Finished.\n> Session-ID: aabbccdd-1122-3344-5566-778899001122",
+					UID: 1,
+				},
+			},
+		}
+		m, _ := testMailer(t, imap, &mockSMTPSender{})
+
+		msgs, err := m.Poll()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "aabbccdd-1122-3344-5566-778899001122", msgs[0].SessionID, "인용문 속 Session-ID로 매칭")
+		assert.Equal(t, "Use approach B", msgs[0].Body)
+	})
+
 	t.Run("ignores self-received template email", func(t *testing.T) {
 		smtp := &mockSMTPSender{}
 		imapMock := &mockIMAPClient{}
 		m, _ := testMailer(t, imapMock, smtp)
 
 		// Send template to create DB record
-		messageID, err := m.SendTemplate()
+		messageID, err := m.SendTemplate(nil)
 		require.NoError(t, err)
 
 		// Simulate the template email arriving back via IMAP
@@ -307,6 +679,23 @@ func TestPoll(t *testing.T) {
 		assert.Contains(t, imapMock.marked, imap.UID(1), "should still mark as read")
 	})
 
+	t.Run("ignores the relay's own session and alert emails", func(t *testing.T) {
+		imapMock := &mockIMAPClient{}
+		m, _ := testMailer(t, imapMock, &mockSMTPSender{})
+
+		imapMock.emails = []*RawEmail{
+			{From: "user@example.com", Subject: "[claude-postman] api: Fix flaky test", Body: "**done**", MessageID: NewMessageID(), UID: 1},
+			{From: "user@example.com", Subject: "[claude-postman] 1 email could not be delivered", Body: "...", MessageID: NewMessageID(), UID: 2},
+			{From: "user@example.com", Subject: "Re: [claude-postman] api: Fix flaky test", Body: "Looks good", MessageID: "<reply@mail.example.com>", UID: 3},
+		}
+
+		msgs, err := m.Poll()
+		require.NoError(t, err)
+		require.Len(t, msgs, 1, "릴레이가 보낸 메일은 unmatched로 세지 않고 건너뜀")
+		assert.Equal(t, "<reply@mail.example.com>", msgs[0].MessageID)
+		assert.ElementsMatch(t, []imap.UID{1, 2, 3}, imapMock.marked, "건너뛴 메일도 읽음 처리")
+	})
+
 	t.Run("matches existing session by outbox Message-ID", func(t *testing.T) {
 		imap := &mockIMAPClient{}
 		m, store := testMailer(t, imap, &mockSMTPSender{})
@@ -0,0 +1,445 @@
+package email
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLToMarkdown converts an HTML email body to Markdown so that code
+// blocks, lists, links, quotes and tables reach Claude intact:
+// <pre> becomes a fenced code block, <a> becomes [text](url), lists keep
+// their bullets and numbers, <blockquote> is prefixed with "> " and data
+// tables become pipe tables.
+func HTMLToMarkdown(s string) string {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	w := &mdWriter{}
+	w.children(doc)
+	return strings.TrimSpace(w.b.String())
+}
+
+// mdWriter renders a node tree as Markdown. Whitespace in text is
+// collapsed as a browser would; block elements request line breaks that
+// are written only once the next text arrives, so empty elements do not
+// leave blank lines behind.
+type mdWriter struct {
+	b      strings.Builder
+	nl     int  // line breaks to write before the next text
+	space  bool // a space is pending before the next text
+	inList bool // rendering the content of a list item
+}
+
+// skippedElements have no readable content.
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Style: true, atom.Script: true, atom.Title: true,
+	atom.Meta: true, atom.Link: true, atom.Noscript: true, atom.Template: true,
+}
+
+// paragraphElements are separated by a blank line; other block elements by
+// a single line break.
+var paragraphElements = map[atom.Atom]bool{
+	atom.P: true, atom.Section: true, atom.Article: true, atom.Header: true,
+	atom.Footer: true, atom.Figure: true, atom.Dl: true,
+}
+
+var lineElements = map[atom.Atom]bool{
+	atom.Div: true, atom.Body: true, atom.Html: true, atom.Center: true,
+	atom.Main: true, atom.Nav: true, atom.Aside: true, atom.Address: true,
+	atom.Dt: true, atom.Dd: true, atom.Figcaption: true, atom.Form: true,
+	atom.Fieldset: true, atom.Details: true, atom.Summary: true,
+	atom.Tbody: true, atom.Thead: true, atom.Tfoot: true, atom.Tr: true,
+	atom.Td: true, atom.Th: true, atom.Caption: true,
+}
+
+var headingLevel = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// breakLine requests at least n line breaks before the next text.
+func (w *mdWriter) breakLine(n int) {
+	if w.b.Len() > 0 && w.nl < n {
+		w.nl = n
+	}
+}
+
+// text writes inline text, emitting pending line breaks or a space first.
+func (w *mdWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	if w.b.Len() > 0 {
+		if w.nl > 0 {
+			w.b.WriteString(strings.Repeat("\n", w.nl))
+		} else if w.space {
+			w.b.WriteByte(' ')
+		}
+	}
+	w.nl, w.space = 0, false
+	w.b.WriteString(s)
+}
+
+// block writes pre-rendered Markdown as a block separated by n line breaks.
+func (w *mdWriter) block(s string, n int) {
+	if s == "" {
+		return
+	}
+	w.breakLine(n)
+	w.text(s)
+	w.breakLine(n)
+}
+
+// sub renders the children of n with a fresh writer.
+func (w *mdWriter) sub(n *html.Node) string {
+	s := &mdWriter{inList: w.inList}
+	s.children(n)
+	return strings.TrimSpace(s.b.String())
+}
+
+func (w *mdWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *mdWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.plainText(n.Data)
+		return
+	case html.DocumentNode:
+		w.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	a := n.DataAtom
+	switch {
+	case skippedElements[a]:
+	case a == atom.Br:
+		// Each <br> ends a line; two in a row leave a blank line.
+		if w.b.Len() > 0 && w.nl < 2 {
+			w.nl++
+		}
+	case a == atom.Hr:
+		w.block("---", 2)
+	case headingLevel[a] > 0:
+		if s := w.sub(n); s != "" {
+			w.block(strings.Repeat("#", headingLevel[a])+" "+oneLine(s), 2)
+		}
+	case a == atom.Pre:
+		w.block(fencedCode(n), 2)
+	case a == atom.Blockquote:
+		w.block(prefixLines(w.sub(n), "> ", "> "), 2)
+	case a == atom.Ul || a == atom.Ol:
+		if w.inList {
+			w.block(w.list(n), 1)
+		} else {
+			w.block(w.list(n), 2)
+		}
+	case a == atom.Li:
+		// A list item outside of a list.
+		w.block(prefixLines(w.sub(n), "- ", "  "), 1)
+	case a == atom.Table:
+		w.table(n)
+	case a == atom.A:
+		w.link(n)
+	case a == atom.Img:
+		w.image(n)
+	case a == atom.Code || a == atom.Kbd || a == atom.Samp || a == atom.Tt:
+		w.inline(n, inlineCode(oneLine(textContent(n))))
+	case a == atom.B || a == atom.Strong:
+		w.inline(n, wrap(oneLine(w.sub(n)), "**"))
+	case a == atom.I || a == atom.Em:
+		w.inline(n, wrap(oneLine(w.sub(n)), "*"))
+	case a == atom.S || a == atom.Strike || a == atom.Del:
+		w.inline(n, wrap(oneLine(w.sub(n)), "~~"))
+	case paragraphElements[a]:
+		w.breakLine(2)
+		w.children(n)
+		w.breakLine(2)
+	case lineElements[a]:
+		w.breakLine(1)
+		w.children(n)
+		w.breakLine(1)
+	default:
+		w.children(n)
+	}
+}
+
+// plainText writes a text node with its whitespace collapsed.
+func (w *mdWriter) plainText(s string) {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		if s != "" {
+			w.space = true
+		}
+		return
+	}
+	if startsWithSpace(s) {
+		w.space = true
+	}
+	w.text(strings.Join(words, " "))
+	if endsWithSpace(s) {
+		w.space = true
+	}
+}
+
+// inline writes formatted inline content, keeping the whitespace around
+// the element's text.
+func (w *mdWriter) inline(n *html.Node, s string) {
+	raw := textContent(n)
+	if startsWithSpace(raw) {
+		w.space = true
+	}
+	w.text(s)
+	if endsWithSpace(raw) {
+		w.space = true
+	}
+}
+
+func (w *mdWriter) link(n *html.Node) {
+	text := oneLine(w.sub(n))
+	href := strings.TrimSpace(attr(n, "href"))
+	switch {
+	case href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:"):
+		w.inline(n, text)
+	case text == "":
+		w.inline(n, href)
+	case text == href || "mailto:"+text == href:
+		w.inline(n, text)
+	default:
+		w.inline(n, "["+text+"]("+strings.ReplaceAll(href, " ", "%20")+")")
+	}
+}
+
+func (w *mdWriter) image(n *html.Node) {
+	alt := oneLine(attr(n, "alt"))
+	src := attr(n, "src")
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		w.text("![" + alt + "](" + src + ")")
+		return
+	}
+	// Inline (cid:) and data images cannot be linked.
+	w.text(alt)
+}
+
+// list renders a <ul> or <ol> with nested lists indented under their item.
+func (w *mdWriter) list(n *html.Node) string {
+	number := 1
+	if s, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = s
+	}
+	var items []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		s := &mdWriter{inList: true}
+		s.children(c)
+		content := strings.TrimSpace(s.b.String())
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table renders a data table as a pipe table. Tables used for layout, as
+// many HTML emails do, have a single column or contain other tables; their
+// cells are rendered as ordinary blocks.
+func (w *mdWriter) table(n *html.Node) {
+	var rows [][]*html.Node
+	var walk func(*html.Node)
+	walk = func(p *html.Node) {
+		for c := p.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Tr:
+				var cells []*html.Node
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						cells = append(cells, cell)
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			}
+		}
+	}
+	walk(n)
+
+	columns := 0
+	for _, r := range rows {
+		columns = max(columns, len(r))
+	}
+	if columns < 2 || len(rows) < 2 || hasDescendant(n, atom.Table) {
+		w.breakLine(1)
+		w.children(n)
+		w.breakLine(1)
+		return
+	}
+
+	var b strings.Builder
+	for i, r := range rows {
+		b.WriteString("|")
+		for c := range columns {
+			cell := ""
+			if c < len(r) {
+				cell = strings.ReplaceAll(oneLine(w.sub(r[c])), "|", `\|`)
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	w.block(strings.TrimSuffix(b.String(), "\n"), 2)
+}
+
+// fencedCode renders a <pre> element as a fenced code block, taking the
+// language from a "language-x" or "lang-x" class on it or its <code>.
+func fencedCode(n *html.Node) string {
+	code := textContent(n)
+	code = strings.TrimPrefix(code, "\n")
+	code = strings.TrimRight(code, " \t\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+
+	lang := codeLanguage(n)
+	if lang == "" {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Code {
+				lang = codeLanguage(c)
+				break
+			}
+		}
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+func codeLanguage(n *html.Node) string {
+	for _, class := range strings.Fields(attr(n, "class")) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if lang, ok := strings.CutPrefix(class, prefix); ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+// inlineCode wraps s in enough backticks to contain the backticks in it.
+func inlineCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+func wrap(s, marker string) string {
+	if s == "" {
+		return ""
+	}
+	return marker + s + marker
+}
+
+// prefixLines prefixes the first line of s with first and the others with
+// rest. Blank lines get the trimmed prefix.
+func prefixLines(s, first, rest string) string {
+	if s == "" {
+		return ""
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		p := rest
+		if i == 0 {
+			p = first
+		}
+		if l == "" {
+			lines[i] = strings.TrimRight(p, " ")
+		} else {
+			lines[i] = p + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// textContent returns the text of n and its descendants as is.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Br {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func hasDescendant(n *html.Node, a atom.Atom) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == a || hasDescendant(c, a) {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// oneLine collapses all whitespace in s, line breaks included.
+func oneLine(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return s != "" && unicode.IsSpace(r)
+}
+
+func endsWithSpace(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return s != "" && unicode.IsSpace(r)
+}
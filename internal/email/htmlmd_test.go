@@ -0,0 +1,120 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	t.Run("strips HTML tags", func(t *testing.T) {
+		html := "<html><body><p>Hello <b>world</b></p><br/><p>Test</p></body></html>"
+		text := HTMLToMarkdown(html)
+		assert.Contains(t, text, "Hello **world**")
+		assert.Contains(t, text, "Test")
+		assert.NotContains(t, text, "<")
+	})
+
+	t.Run("decodes HTML entities", func(t *testing.T) {
+		html := "<p>A &amp; B &lt; C</p>"
+		text := HTMLToMarkdown(html)
+		assert.Contains(t, text, "A & B < C")
+	})
+
+	t.Run("preserves line breaks from block elements", func(t *testing.T) {
+		html := "<div>Line 1</div><div>Line 2</div>"
+		assert.Equal(t, "Line 1\nLine 2", HTMLToMarkdown(html))
+	})
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "pre becomes fenced code",
+			html: "<p>Fix this:</p><pre>func main() {\n\tpanic(\"x\")\n}</pre>",
+			want: "Fix this:\n\n```\nfunc main() {\n\tpanic(\"x\")\n}\n```",
+		},
+		{
+			name: "code language from class",
+			html: `<pre><code class="language-go">x := 1</code></pre>`,
+			want: "```go\nx := 1\n```",
+		},
+		{
+			name: "stack trace with br keeps its lines",
+			html: "<pre>panic: boom<br>goroutine 1 [running]:<br>main.main()</pre>",
+			want: "```\npanic: boom\ngoroutine 1 [running]:\nmain.main()\n```",
+		},
+		{
+			name: "fence longer than backticks in code",
+			html: "<pre>```\nnested\n```</pre>",
+			want: "````\n```\nnested\n```\n````",
+		},
+		{
+			name: "inline code",
+			html: "<p>Run <code>go  test ./...</code> first</p>",
+			want: "Run `go test ./...` first",
+		},
+		{
+			name: "link",
+			html: `<p>See <a href="https://example.com/docs">the docs</a>.</p>`,
+			want: "See [the docs](https://example.com/docs).",
+		},
+		{
+			name: "bare link and mailto",
+			html: `<a href="https://example.com">https://example.com</a> <a href="mailto:me@example.com">me@example.com</a>`,
+			want: "https://example.com me@example.com",
+		},
+		{
+			name: "unordered list",
+			html: "<ul><li>one</li><li>two</li></ul>",
+			want: "- one\n- two",
+		},
+		{
+			name: "ordered list with start and nesting",
+			html: `<ol start="3"><li>first<ul><li>sub</li></ul></li><li>second</li></ol>`,
+			want: "3. first\n   - sub\n4. second",
+		},
+		{
+			name: "blockquote",
+			html: "<p>You said:</p><blockquote><p>a</p><p>b</p></blockquote><p>Agreed</p>",
+			want: "You said:\n\n> a\n>\n> b\n\nAgreed",
+		},
+		{
+			name: "headings and rule",
+			html: "<h2>Plan</h2><hr><p>Step</p>",
+			want: "## Plan\n\n---\n\nStep",
+		},
+		{
+			name: "data table",
+			html: "<table><tr><th>Name</th><th>Value</th></tr><tr><td>a|b</td><td>1</td></tr></table>",
+			want: "| Name | Value |\n| --- | --- |\n| a\\|b | 1 |",
+		},
+		{
+			name: "layout table is unwrapped",
+			html: "<table><tr><td><p>Hello</p></td></tr><tr><td>World</td></tr></table>",
+			want: "Hello\n\nWorld",
+		},
+		{
+			name: "style and script are dropped",
+			html: "<html><head><style>p{color:red}</style></head><body><script>x()</script><p>Body</p></body></html>",
+			want: "Body",
+		},
+		{
+			name: "nbsp and spacing around inline elements",
+			html: "<div>Use&nbsp;<i>this </i>one</div>",
+			want: "Use *this* one",
+		},
+		{
+			name: "br lines",
+			html: "<div>a<br>b<br><br>c</div>",
+			want: "a\nb\n\nc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HTMLToMarkdown(tt.html))
+		})
+	}
+}
@@ -16,17 +16,23 @@ import (
 // RawEmail holds the parsed fields from a fetched IMAP message.
 type RawEmail struct {
 	From       string
+	To         []string
 	Subject    string
 	Body       string
 	MessageID  string
 	InReplyTo  string
 	References []string
-	UID        imap.UID
+	// AuthResults is the Authentication-Results header added by the
+	// receiving server (SPF/DKIM/DMARC verdicts), if any.
+	AuthResults string
+	UID         imap.UID
 }
 
 // IMAPClient abstracts IMAP operations for testability.
 type IMAPClient interface {
-	FetchUnread(subject string) ([]*RawEmail, error)
+	// FetchUnread returns unread messages whose subject contains subject
+	// or, when to is not empty, whose To header contains to.
+	FetchUnread(subject, to string) ([]*RawEmail, error)
 	MarkRead(uid imap.UID) error
 	Close() error
 }
@@ -53,12 +59,18 @@ func newIMAPClient(cfg *config.EmailConfig) (IMAPClient, error) {
 	return &imapClient{client: c}, nil
 }
 
-func (ic *imapClient) FetchUnread(subject string) ([]*RawEmail, error) {
-	criteria := &imap.SearchCriteria{
-		NotFlag: []imap.Flag{imap.FlagSeen},
-		Header: []imap.SearchCriteriaHeaderField{
-			{Key: "SUBJECT", Value: subject},
-		},
+func (ic *imapClient) FetchUnread(subject, to string) ([]*RawEmail, error) {
+	bySubject := imap.SearchCriteria{
+		Header: []imap.SearchCriteriaHeaderField{{Key: "SUBJECT", Value: subject}},
+	}
+	criteria := &imap.SearchCriteria{NotFlag: []imap.Flag{imap.FlagSeen}}
+	if to == "" {
+		criteria.And(&bySubject)
+	} else {
+		byTo := imap.SearchCriteria{
+			Header: []imap.SearchCriteriaHeaderField{{Key: "TO", Value: to}},
+		}
+		criteria.Or = [][2]imap.SearchCriteria{{bySubject, byTo}}
 	}
 	searchData, err := ic.client.UIDSearch(criteria, nil).Wait()
 	if err != nil {
@@ -117,22 +129,25 @@ func bufferToRawEmail(buf *imapclient.FetchMessageBuffer) *RawEmail {
 		if len(env.From) > 0 {
 			raw.From = env.From[0].Addr()
 		}
+		for _, addr := range env.To {
+			raw.To = append(raw.To, addr.Addr())
+		}
 	}
 
-	// Extract body and References header from body section
+	// Extract body, References and Authentication-Results from body section
 	bodySection := &imap.FetchItemBodySection{Specifier: imap.PartSpecifierNone}
 	if data := buf.FindBodySection(bodySection); data != nil {
-		raw.Body, raw.References = parseEmailBody(bytes.NewReader(data))
+		raw.Body, raw.References, raw.AuthResults = parseEmailBody(bytes.NewReader(data))
 	}
 
 	return raw
 }
 
-func parseEmailBody(r io.Reader) (body string, refs []string) {
+func parseEmailBody(r io.Reader) (body string, refs []string, authResults string) {
 	gomessage.CharsetReader = nil
 	mr, err := mail.CreateReader(r)
 	if err != nil {
-		return "", nil
+		return "", nil, ""
 	}
 	defer mr.Close()
 
@@ -140,6 +155,7 @@ func parseEmailBody(r io.Reader) (body string, refs []string) {
 	if refHeader, err := mr.Header.Text("References"); err == nil && refHeader != "" {
 		refs = strings.Fields(refHeader)
 	}
+	authResults = mr.Header.Get("Authentication-Results")
 
 	// Read body parts
 	for {
@@ -1,7 +1,6 @@
 package email
 
 import (
-	"html"
 	"regexp"
 	"strings"
 )
@@ -10,12 +9,24 @@ var (
 	sessionIDRe = regexp.MustCompile(`Session-ID:\s*([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`)
 	dirRe       = regexp.MustCompile(`(?m)^Directory:\s*(.+)$`)
 	modelRe     = regexp.MustCompile(`(?m)^Model:\s*(.+)$`)
-	tagRe       = regexp.MustCompile(`<[^>]*>`)
-	blockRe     = regexp.MustCompile(`(?i)<\s*(?:br|/p|/div|/tr|/li)\s*/?\s*>`)
 	// Gmail reply citation: line containing <email> and ending with ":"
 	replyCiteRe = regexp.MustCompile(`(?m)^.*<\S+@\S+>.*:\s*$`)
+	commandRe   = regexp.MustCompile(`^/([a-z]+)(?:\s+(.*))?$`)
+	// Subject of a freshly composed session request, e.g. "[claude-postman] new: ~/app"
+	newSubjectRe = regexp.MustCompile(`(?i)^\s*\[claude-postman\]\s*new(?:\s*:\s*(.*?))?\s*$`)
 )
 
+// replyCommands are handled by the relay itself instead of being sent to Claude.
+var replyCommands = map[string]bool{
+	"commit": true,
+	"push":   true,
+	"revert": true,
+	"pr":     true,
+	// continue resumes a session paused by the budget
+	"continue": true,
+	"search":   true,
+}
+
 const forwardedMarker = "---------- Forwarded message ----------"
 
 // ParseSessionID extracts a Session-ID UUID from the email body.
@@ -76,23 +87,36 @@ func ParseTemplate(body string) (workingDir, model, prompt string) {
 	return
 }
 
-// ExtractTextFromHTML strips HTML tags and returns plain text.
-func ExtractTextFromHTML(s string) string {
-	// Replace block-level closing tags and <br> with newlines
-	text := blockRe.ReplaceAllString(s, "\n")
-	// Remove all remaining tags
-	text = tagRe.ReplaceAllString(text, "")
-	// Decode HTML entities
-	text = html.UnescapeString(text)
-	// Normalize whitespace within lines
-	lines := strings.Split(text, "\n")
-	for i, line := range lines {
-		lines[i] = strings.TrimSpace(line)
+// ParseNewSessionSubject checks whether subject asks for a new session, as in
+// "[claude-postman] new: <dir>". Returns the directory (empty if omitted) and
+// whether the subject matched. Replies ("Re: ...") do not match.
+func ParseNewSessionSubject(subject string) (workingDir string, ok bool) {
+	m := newSubjectRe.FindStringSubmatch(subject)
+	if m == nil {
+		return "", false
 	}
-	text = strings.Join(lines, "\n")
-	// Collapse multiple blank lines
-	for strings.Contains(text, "\n\n\n") {
-		text = strings.ReplaceAll(text, "\n\n\n", "\n\n")
+	return m[1], true
+}
+
+// ParseCommand checks whether the first non-empty line of a reply is a relay
+// command such as `/commit "message"` or `/push`.
+// Returns the command name (without the slash) and its argument with
+// surrounding quotes removed, or empty strings if the reply is not a command.
+func ParseCommand(body string) (name, arg string) {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		m := commandRe.FindStringSubmatch(line)
+		if m == nil || !replyCommands[m[1]] {
+			return "", ""
+		}
+		arg = strings.TrimSpace(m[2])
+		if len(arg) >= 2 && (arg[0] == '"' || arg[0] == '\'') && arg[len(arg)-1] == arg[0] {
+			arg = arg[1 : len(arg)-1]
+		}
+		return m[1], arg
 	}
-	return strings.TrimSpace(text)
+	return "", ""
 }
@@ -84,25 +84,50 @@ func TestParseTemplate(t *testing.T) {
 	})
 }
 
-func TestExtractTextFromHTML(t *testing.T) {
-	t.Run("strips HTML tags", func(t *testing.T) {
-		html := "<html><body><p>Hello <b>world</b></p><br/><p>Test</p></body></html>"
-		text := ExtractTextFromHTML(html)
-		assert.Contains(t, text, "Hello world")
-		assert.Contains(t, text, "Test")
-		assert.NotContains(t, text, "<")
-	})
-
-	t.Run("decodes HTML entities", func(t *testing.T) {
-		html := "<p>A &amp; B &lt; C</p>"
-		text := ExtractTextFromHTML(html)
-		assert.Contains(t, text, "A & B < C")
-	})
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantName string
+		wantArg  string
+	}{
+		{"commit with quoted message", "/commit \"Fix flaky test\"\n\n> quoted result", "commit", "Fix flaky test"},
+		{"commit with bare message", "/commit fix it", "commit", "fix it"},
+		{"push", "\n  /push\n", "push", ""},
+		{"revert", "/revert", "revert", ""},
+		{"pr", "/pr", "pr", ""},
+		{"search", "/search migration bug", "search", "migration bug"},
+		{"unknown command", "/deploy now", "", ""},
+		{"plain reply", "Please also update the README\n/push", "", ""},
+		{"empty", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, arg := ParseCommand(tt.body)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantArg, arg)
+		})
+	}
+}
 
-	t.Run("preserves line breaks from block elements", func(t *testing.T) {
-		html := "<div>Line 1</div><div>Line 2</div>"
-		text := ExtractTextFromHTML(html)
-		assert.Contains(t, text, "Line 1")
-		assert.Contains(t, text, "Line 2")
-	})
+func TestParseNewSessionSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		wantDir string
+		wantOK  bool
+	}{
+		{"[claude-postman] new: ~/my-project", "~/my-project", true},
+		{"[claude-postman] New:/srv/api ", "/srv/api", true},
+		{"[claude-postman] new", "", true},
+		{"Re: [claude-postman] new: ~/my-project", "", false},
+		{"[claude-postman] New Session", "", false},
+		{"new: ~/my-project", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			dir, ok := ParseNewSessionSubject(tt.subject)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantDir, dir)
+		})
+	}
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
 )
 
 var ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
@@ -18,25 +19,58 @@ func StripANSI(s string) string {
 
 var md = goldmark.New(
 	goldmark.WithExtensions(
+		extension.GFM,
 		highlighting.NewHighlighting(
 			highlighting.WithStyle("github"),
 		),
 	),
 )
 
+// htmlTemplate centres the content in a fixed-width table, which Outlook
+// honours where it ignores max-width on a <div>.
 const htmlTemplate = `<!DOCTYPE html>
 <html>
-<head><meta charset="utf-8"></head>
-<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;line-height:1.6;color:#333;max-width:800px;margin:0 auto;padding:20px;">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="color-scheme" content="light dark">
+<meta name="supported-color-schemes" content="light dark">
+<style>
 %s
+</style>
+</head>
+<body style="margin:0;padding:0;">
+<table role="presentation" width="100%%" cellpadding="0" cellspacing="0" border="0" class="pm-body" style="background-color:#ffffff;color:#24292f;">
+<tr><td align="center" style="padding:20px;">
+<table role="presentation" width="100%%" cellpadding="0" cellspacing="0" border="0" style="max-width:800px;">
+<tr><td class="pm-body" style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;font-size:15px;line-height:1.6;color:#24292f;text-align:left;word-wrap:break-word;">
+%s
+</td></tr>
+</table>
+</td></tr>
+</table>
 </body>
 </html>`
 
 // RenderHTML converts Markdown text to a complete HTML email document.
+// GitHub Flavored Markdown (tables, task lists, strikethrough, autolinks)
+// is supported, tool calls in captured Claude Code output are rendered as
+// separate blocks, and all styles are inlined for email clients.
 func RenderHTML(markdown string) (string, error) {
 	var buf bytes.Buffer
-	if err := md.Convert([]byte(markdown), &buf); err != nil {
-		return "", fmt.Errorf("markdown conversion failed: %w", err)
+	for _, seg := range splitToolCalls(markdown) {
+		if seg.tool != nil {
+			buf.WriteString(renderToolCall(seg.tool))
+			buf.WriteString("\n")
+			continue
+		}
+		if err := md.Convert([]byte(seg.markdown), &buf); err != nil {
+			return "", fmt.Errorf("markdown conversion failed: %w", err)
+		}
+	}
+	body, err := inlineStyles(buf.String())
+	if err != nil {
+		return "", fmt.Errorf("inline styles: %w", err)
 	}
-	return fmt.Sprintf(htmlTemplate, buf.String()), nil
+	return fmt.Sprintf(htmlTemplate, darkModeCSS, body), nil
 }
@@ -1,6 +1,10 @@
 package email
 
 import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,3 +59,40 @@ func TestRenderHTML(t *testing.T) {
 		assert.Contains(t, html, "<body")
 	})
 }
+
+func TestRenderHTML_Styles(t *testing.T) {
+	html, err := RenderHTML("Run `make` then read [docs](https://example.com)")
+	require.NoError(t, err)
+	assert.Contains(t, html, `<code class="pm-code" style="`, "인라인 코드에 스타일 인라인")
+	assert.Contains(t, html, `<a href="https://example.com" style="color:#0969da;`)
+	assert.Contains(t, html, "prefers-color-scheme: dark")
+}
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/render")
+
+// TestRenderHTML_Golden renders each testdata/render/*.md and compares it
+// with the .golden.html next to it. Run with -update after an intended
+// change and review the diff.
+func TestRenderHTML_Golden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "render", "*.md"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".md")
+		t.Run(name, func(t *testing.T) {
+			markdown, err := os.ReadFile(file)
+			require.NoError(t, err)
+			html, err := RenderHTML(string(markdown))
+			require.NoError(t, err)
+
+			golden := strings.TrimSuffix(file, ".md") + ".golden.html"
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(html), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), html)
+		})
+	}
+}
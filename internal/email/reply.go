@@ -0,0 +1,81 @@
+package email
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// htmlQuoteRe finds where the quoted part of an HTML reply starts:
+	// Gmail, Thunderbird and Yahoo quote containers, Apple Mail cite
+	// blockquotes and Outlook's reply header. Other blockquotes are the
+	// user's own and are kept.
+	htmlQuoteRe = regexp.MustCompile(`(?i)<div[^>]*\bclass="[^"]*\b(?:gmail_quote|gmail_signature|moz-cite-prefix|yahoo_quoted)\b|<blockquote[^>]*\btype="cite"|<div[^>]*\bid="(?:divRplyFwdMsg|appendonsend)"`)
+
+	// replyHeaderRes find the line that introduces the quoted message.
+	replyHeaderRes = []*regexp.Regexp{
+		// Gmail and Apple Mail: "On Mon, Feb 23, 2026 at 10:00 AM Me <me@x.com> wrote:",
+		// which Gmail may wrap onto a second line.
+		regexp.MustCompile(`(?m)^On [^\n]*(?:\n[^\n]*)?wrote:[ \t]*$`),
+		// Gmail (Korean): "2026년 2월 23일 (월) 오전 10:00, Me <me@x.com>님이 작성:"
+		regexp.MustCompile(`(?m)^[^\n]*님이 작성:[ \t]*$`),
+		// Any other client: a line with an address in angle brackets ending in ':'.
+		replyCiteRe,
+		// Outlook: "From: ..." directly followed by "Sent:" or "Date:",
+		// optionally after a line of underscores.
+		regexp.MustCompile(`(?m)^(?:_{10,}[ \t]*\n)?\*?(?:From|보낸 사람):[^\n]*\n\*?(?:Sent|Date|보낸 날짜):`),
+		regexp.MustCompile(`(?m)^-{2,}[ \t]*Original Message[ \t]*-{2,}[ \t]*$`),
+	}
+
+	// signatureRe matches the "-- " signature delimiter and the stock
+	// signatures of mobile mail apps.
+	signatureRe = regexp.MustCompile(`(?m)^(?:--[ \t]?|Sent from my [^\n]+|Sent from [^\n]+ for (?:Windows|Android|iOS)|Get Outlook for [^\n]+|Sent with (?:Proton Mail|Spark|BlackBerry)[^\n]*|(?:iPhone|iPad|Galaxy|Android|모바일)[^\n]*에서 보냄)[ \t]*$`)
+)
+
+// ExtractReply returns only the text written in a reply: HTML is converted
+// to Markdown, and the quoted previous message, forwarded content and the
+// signature are removed.
+func ExtractReply(body string) string {
+	isHTML := looksLikeHTML(body)
+	if isHTML {
+		if loc := htmlQuoteRe.FindStringIndex(body); loc != nil {
+			body = body[:loc[0]]
+		}
+		body = HTMLToMarkdown(body)
+		// Outlook draws a rule above its reply header.
+		body = strings.TrimSuffix(strings.TrimSpace(body), "---")
+	}
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	if idx := strings.Index(body, forwardedMarker); idx >= 0 {
+		body = body[:idx]
+	}
+	for _, re := range replyHeaderRes {
+		if loc := re.FindStringIndex(body); loc != nil {
+			body = body[:loc[0]]
+		}
+	}
+
+	if isHTML {
+		// Blockquotes left after cutting the quote container were written
+		// by the user.
+		return stripSignature(body)
+	}
+	// Whatever quoted lines remain (inline replies) are not the user's text.
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, ">") {
+			lines = append(lines, line)
+		}
+	}
+	return stripSignature(strings.Join(lines, "\n"))
+}
+
+// stripSignature removes everything from the signature delimiter or a
+// mobile "Sent from" line on, and trims the result.
+func stripSignature(text string) string {
+	if loc := signatureRe.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,101 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractReply(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "plain reply",
+			body: "Looks good, ship it",
+			want: "Looks good, ship it",
+		},
+		{
+			name: "Gmail text quote",
+			body: "Use the second approach\n\nOn Mon, Feb 23, 2026 at 10:00 AM Me <me@example.com> wrote:\n> Result of the task\n> Session-ID: aabbccdd-1122-3344-5566-778899001122",
+			want: "Use the second approach",
+		},
+		{
+			name: "Gmail quote header wrapped onto two lines",
+			body: "Yes\n\nOn Mon, Feb 23, 2026 at 10:00 AM Claude Postman <\nbot@example.com> wrote:\n\nPrevious result",
+			want: "Yes",
+		},
+		{
+			name: "Gmail Korean quote header",
+			body: "두 번째 방법으로 해줘\n\n2026년 2월 23일 (월) 오전 10:00, Me <me@example.com>님이 작성:\n\n이전 결과",
+			want: "두 번째 방법으로 해줘",
+		},
+		{
+			name: "Gmail HTML",
+			body: `<div dir="ltr">Run the <b>tests</b></div><br><div class="gmail_quote"><div class="gmail_attr">On Mon wrote:</div><blockquote>Previous result</blockquote></div>`,
+			want: "Run the **tests**",
+		},
+		{
+			name: "HTML blockquote written by the user is kept",
+			body: `<div>About this:</div><blockquote style="margin:0 0 0 40px">old plan</blockquote><div>Drop step 2</div><div class="gmail_quote">On Mon wrote:</div>`,
+			want: "About this:\n\n> old plan\n\nDrop step 2",
+		},
+		{
+			name: "Apple Mail HTML",
+			body: `<html><body><div>Go ahead</div><div><br><blockquote type="cite"><div>Previous result</div></blockquote></div></body></html>`,
+			want: "Go ahead",
+		},
+		{
+			name: "Outlook reply header",
+			body: "Please retry\r\n\r\n________________________________\r\nFrom: Claude Postman <bot@example.com>\r\nSent: Monday, February 23, 2026 10:00 AM\r\nTo: Me\r\nSubject: RE: [claude-postman] done\r\n\r\nPrevious result",
+			want: "Please retry",
+		},
+		{
+			name: "Outlook HTML reply header",
+			body: `<div>Please retry</div><hr><div id="divRplyFwdMsg"><b>From:</b> bot</div><div>Previous result</div>`,
+			want: "Please retry",
+		},
+		{
+			name: "original message delimiter",
+			body: "Stop here\n\n-----Original Message-----\nFrom: bot@example.com\nPrevious result",
+			want: "Stop here",
+		},
+		{
+			name: "forwarded message",
+			body: "FYI\n---------- Forwarded message ----------\nOriginal content",
+			want: "FYI",
+		},
+		{
+			name: "signature delimiter",
+			body: "Continue\n\n-- \nJane Doe\nACME Corp",
+			want: "Continue",
+		},
+		{
+			name: "mobile signature",
+			body: "Continue\n\nSent from my iPhone\n\nOn Mon, Feb 23, 2026 at 10:00 AM Me <me@example.com> wrote:\n> old",
+			want: "Continue",
+		},
+		{
+			name: "Korean mobile signature",
+			body: "계속해\n\nGalaxy S24에서 보냄",
+			want: "계속해",
+		},
+		{
+			name: "inline quoted lines",
+			body: "> Should I use approach A?\nNo, use B\n> Anything else?\nAdd tests",
+			want: "No, use B\nAdd tests",
+		},
+		{
+			name: "markdown separator is not a signature",
+			body: "First part\n---\nSecond part",
+			want: "First part\n---\nSecond part",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExtractReply(tt.body))
+		})
+	}
+}
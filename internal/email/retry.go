@@ -0,0 +1,161 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/yhzion/claude-postman/internal/metrics"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// failure classifies a failed SMTP send.
+type failure int
+
+const (
+	failureTransient failure = iota // 4xx replies and network errors: retry with backoff
+	failurePermanent                // other 5xx replies: the server will never take this message
+	failureAuth                     // the login was refused: no message can be sent
+)
+
+func (f failure) String() string {
+	switch f {
+	case failurePermanent:
+		return "permanent"
+	case failureAuth:
+		return "auth"
+	default:
+		return "transient"
+	}
+}
+
+// classifyFailure tells why a send failed. Replies 530, 534 and 535 mean
+// the credentials are missing or wrong even when they arrive after login;
+// errors without an SMTP reply are taken as network trouble.
+func classifyFailure(err error) failure {
+	if errors.Is(err, ErrSMTPAuth) {
+		return failureAuth
+	}
+	var reply *textproto.Error
+	if !errors.As(err, &reply) {
+		return failureTransient
+	}
+	switch {
+	case reply.Code == 530 || reply.Code == 534 || reply.Code == 535:
+		return failureAuth
+	case reply.Code >= 500:
+		return failurePermanent
+	default:
+		return failureTransient
+	}
+}
+
+// handleFailure reacts to a failed send according to its classification.
+func (m *Mailer) handleFailure(msg *storage.OutboxMessage, err error, now time.Time) {
+	kind := classifyFailure(err)
+	metrics.SMTPSendErrors.WithLabelValues(kind.String()).Inc()
+	switch kind {
+	case failureAuth:
+		m.pauseOutbox(err, now)
+	case failurePermanent:
+		m.failPermanently(msg, err)
+	default:
+		slog.Warn("smtp send failed", "outbox_id", msg.ID, "error", err)
+		m.handleRetry(msg, now)
+	}
+}
+
+// handleRetry schedules another attempt with backoff, or marks the message
+// failed once it has used email.retry.max_attempts.
+func (m *Mailer) handleRetry(msg *storage.OutboxMessage, now time.Time) {
+	attempts := msg.RetryCount + 1
+	if attempts >= m.cfg.Retry.MaxAttempts {
+		if err := m.store.MarkFailed(msg.ID, attempts, nil); err != nil {
+			slog.Error("failed to mark outbox as failed", "id", msg.ID, "error", err)
+		}
+		return
+	}
+	metrics.OutboxRetries.Inc()
+	nextRetry := now.Add(m.backoff(attempts))
+	if err := m.store.UpdateRetry(msg.ID, attempts, &nextRetry); err != nil {
+		slog.Error("failed to update retry", "id", msg.ID, "error", err)
+	}
+}
+
+// backoff returns the wait after the given number of failed attempts:
+// base_sec doubled per attempt up to max_sec, spread by ±jitter so that
+// messages that failed together do not retry together.
+func (m *Mailer) backoff(attempts int) time.Duration {
+	r := m.cfg.Retry
+	maxDelay := time.Duration(r.MaxSec) * time.Second
+	delay := time.Duration(r.BaseSec) * time.Second
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	if r.Jitter == nil || *r.Jitter == 0 {
+		return delay
+	}
+	return time.Duration(float64(delay) * (1 + *r.Jitter*(2*m.random()-1)))
+}
+
+// failPermanently marks a message the server rejected for good as failed
+// and mails an alert about it right away. The alert goes straight over SMTP
+// into the session's thread; once it is out the message is not repeated in
+// the dead letter summary.
+func (m *Mailer) failPermanently(msg *storage.OutboxMessage, sendErr error) {
+	slog.Error("smtp rejected message, not retrying", "outbox_id", msg.ID, "error", sendErr)
+	if err := m.store.MarkFailed(msg.ID, msg.RetryCount+1, nil); err != nil {
+		slog.Error("failed to mark outbox as failed", "id", msg.ID, "error", err)
+		return
+	}
+
+	markdown := fmt.Sprintf("The mail server rejected this email, so it will not be retried:\n\n"+
+		"- Subject: %s\n- Outbox ID: `%s`\n\nServer reply:\n\n    %v\n\n"+
+		"On the relay host, `claude-postman outbox show %s` shows the email and "+
+		"`claude-postman outbox retry %s` sends it again.\n",
+		msg.Subject, shortID(msg.ID), sendErr, shortID(msg.ID), shortID(msg.ID))
+	body, err := RenderHTML(markdown)
+	if err != nil {
+		slog.Error("failed to render rejection alert", "error", err)
+		return
+	}
+	_, inReplyTo := m.sessionHeaders(msg.SessionID)
+	// Outbox subjects already carry the prefix; don't repeat it.
+	subject := subjectPrefix + " Email rejected: " + strings.TrimSpace(strings.TrimPrefix(msg.Subject, subjectPrefix))
+	if err := m.smtp.Send(m.cfg.User, m.cfg.User, nil, subject, body, NewMessageID(), inReplyTo, nil); err != nil {
+		slog.Warn("rejection alert not sent", "outbox_id", msg.ID, "error", err)
+		return
+	}
+	if err := m.store.MarkReported([]string{msg.ID}); err != nil {
+		slog.Error("failed to mark outbox reported", "id", msg.ID, "error", err)
+	}
+}
+
+// pauseOutbox stops all sending for email.retry.max_sec after the server
+// refused the login. Retries are not counted against messages meanwhile:
+// every one of them would fail the same way.
+func (m *Mailer) pauseOutbox(err error, now time.Time) {
+	m.pausedUntil = now.Add(time.Duration(m.cfg.Retry.MaxSec) * time.Second)
+	metrics.OutboxPaused.Set(1)
+	slog.Error("smtp authentication failed, outbox paused; check email.user and email.app_password",
+		"until", m.pausedUntil.Format(time.DateTime), "error", err)
+}
+
+// outboxPaused reports whether sending is paused after an auth failure.
+func (m *Mailer) outboxPaused(now time.Time) bool {
+	return now.Before(m.pausedUntil)
+}
+
+// resumeOutbox clears the pause once a send went through.
+func (m *Mailer) resumeOutbox() {
+	if m.pausedUntil.IsZero() {
+		return
+	}
+	m.pausedUntil = time.Time{}
+	metrics.OutboxPaused.Set(0)
+	slog.Info("smtp authentication works again, outbox resumed")
+}
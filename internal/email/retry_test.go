@@ -0,0 +1,116 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want failure
+	}{
+		{errors.New("dial tcp 10.0.0.1:587: connect: connection refused"), failureTransient},
+		{&textproto.Error{Code: 421, Msg: "Service not available"}, failureTransient},
+		{&textproto.Error{Code: 452, Msg: "Insufficient system storage"}, failureTransient},
+		{&textproto.Error{Code: 454, Msg: "Temporary authentication failure"}, failureTransient},
+		{&textproto.Error{Code: 550, Msg: "Mailbox unavailable"}, failurePermanent},
+		{&textproto.Error{Code: 552, Msg: "Mailbox full"}, failurePermanent},
+		{&textproto.Error{Code: 554, Msg: "Message rejected"}, failurePermanent},
+		{&textproto.Error{Code: 535, Msg: "Username and Password not accepted"}, failureAuth},
+		{&textproto.Error{Code: 530, Msg: "Authentication required"}, failureAuth},
+		{fmt.Errorf("%w: %w", ErrSMTPAuth, errors.New("unencrypted connection")), failureAuth},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, classifyFailure(tt.err), tt.err.Error())
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	m, _ := testMailer(t, &mockIMAPClient{}, &mockSMTPSender{})
+	jitter := 0.2
+	m.cfg.Retry.Jitter = &jitter
+
+	for attempts, want := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		6:  15 * time.Minute, // 16분 → max_sec 상한
+		30: 15 * time.Minute,
+	} {
+		assert.Equal(t, want, m.backoff(attempts), "attempts=%d", attempts)
+	}
+
+	m.random = func() float64 { return 0 }
+	assert.Equal(t, 24*time.Second, m.backoff(1), "jitter 하한 -20%")
+	m.random = func() float64 { return 1 }
+	assert.Equal(t, 36*time.Second, m.backoff(1), "jitter 상한 +20%")
+
+	jitter = 0
+	assert.Equal(t, 30*time.Second, m.backoff(1), "jitter 0이면 흔들지 않음")
+}
+
+func TestFlushOutbox_PermanentFailure(t *testing.T) {
+	const subject = "[claude-postman] api-server: Fix flaky test"
+	smtp := &mockSMTPSender{fail: func(s string) error {
+		if s == subject {
+			return &textproto.Error{Code: 552, Msg: "5.3.4 Message size exceeds fixed limit"}
+		}
+		return nil
+	}}
+	m, store := testMailer(t, &mockIMAPClient{}, smtp)
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{
+		ID: "rejected-1", Subject: subject, Body: "<p>big</p>", Status: "pending",
+	}))
+
+	require.NoError(t, m.FlushOutbox())
+
+	msg, err := store.GetOutbox("rejected-1")
+	require.NoError(t, err)
+	assert.Equal(t, "failed", msg.Status, "영구 실패는 재시도 없이 바로 failed")
+	assert.Equal(t, 1, msg.RetryCount)
+
+	require.Len(t, smtp.sent, 1)
+	assert.Equal(t, "[claude-postman] Email rejected: api-server: Fix flaky test", smtp.sent[0].subject,
+		"접두사를 두 번 붙이지 않음")
+	assert.Contains(t, smtp.sent[0].body, "Message size exceeds fixed limit")
+	assert.Contains(t, smtp.sent[0].body, "outbox retry rejected")
+
+	unreported, err := store.ListUnreportedFailed()
+	require.NoError(t, err)
+	assert.Empty(t, unreported, "알림을 보냈으면 dead letter 요약에 다시 넣지 않음")
+}
+
+func TestFlushOutbox_AuthFailurePausesOutbox(t *testing.T) {
+	smtp := &mockSMTPSender{err: &textproto.Error{Code: 535, Msg: "5.7.8 Username and Password not accepted"}}
+	m, store := testMailer(t, &mockIMAPClient{}, smtp)
+	require.NoError(t, m.Send("", "first", "<p>1</p>"))
+	require.NoError(t, m.Send("", "second", "<p>2</p>"))
+
+	require.NoError(t, m.FlushOutbox())
+	assert.Equal(t, 1, smtp.attempts, "인증 실패 후 나머지 메일은 시도하지 않음")
+	assert.True(t, m.outboxPaused(time.Now()))
+
+	pending, err := store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, pending, 2, "재시도 횟수를 쓰지 않고 그대로 대기")
+	assert.Zero(t, pending[0].RetryCount)
+
+	require.NoError(t, m.FlushOutbox())
+	assert.Equal(t, 1, smtp.attempts, "멈춘 동안은 발송하지 않음")
+
+	// 멈춤이 끝나고 인증이 되면 다시 발송
+	smtp.err = nil
+	m.pausedUntil = time.Now().Add(-time.Second)
+	require.NoError(t, m.FlushOutbox())
+	assert.Len(t, smtp.sent, 2)
+	assert.False(t, m.outboxPaused(time.Now()))
+	assert.True(t, m.pausedUntil.IsZero())
+}
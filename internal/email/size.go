@@ -0,0 +1,214 @@
+package email
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"html"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxBodyBytes is the size budget of a rendered HTML body. Gmail clips
+// messages larger than 102KB and hides the rest behind a link.
+const MaxBodyBytes = 100 << 10
+
+// gzipThreshold is the size above which the full output is attached gzipped.
+const gzipThreshold = 1 << 20
+
+// RenderReport renders markdown as an HTML email body that fits in
+// MaxBodyBytes. Output that does not fit is cut to its head and tail with
+// a note in between, and the full text is returned as an attachment named
+// name.md (name.md.gz when larger than gzipThreshold).
+func RenderReport(markdown, name string) (string, []Attachment, error) {
+	// The HTML is never smaller than the Markdown, so output over the
+	// budget is not rendered in full.
+	if len(markdown) <= MaxBodyBytes {
+		body, err := RenderHTML(markdown)
+		if err != nil || len(body) <= MaxBodyBytes {
+			return body, nil, err
+		}
+	}
+
+	att := outputAttachment(markdown, name)
+	// Highlighting and inline styles make the HTML several times larger
+	// than the Markdown, so shrink the excerpt until it fits.
+	for keep := MaxBodyBytes / 2; keep >= 1<<10; keep /= 2 {
+		body, err := RenderHTML(excerpt(markdown, keep/2, keep/2, att.Filename))
+		if err != nil {
+			return "", nil, err
+		}
+		if len(body) <= MaxBodyBytes {
+			return body, []Attachment{att}, nil
+		}
+	}
+	body, err := RenderHTML(omittedNote(strings.Count(markdown, "\n")+1, len(markdown), att.Filename))
+	return body, []Attachment{att}, err
+}
+
+// excerpt keeps about headBytes from the start and tailBytes from the end
+// of markdown, cut at line breaks where possible. A code block cut in two
+// is closed before the note and reopened after it.
+func excerpt(markdown string, headBytes, tailBytes int, attachment string) string {
+	if headBytes+tailBytes >= len(markdown) {
+		return markdown
+	}
+	head := cutPoint(markdown, headBytes, false)
+	tail := cutPoint(markdown, len(markdown)-tailBytes, true)
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(markdown[:head], "\n"))
+	if fence := openFence(markdown[:head]); fence != "" {
+		b.WriteString("\n" + fenceMarker(fence))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(omittedNote(strings.Count(markdown[head:tail], "\n"), tail-head, attachment))
+	b.WriteString("\n\n")
+	if fence := openFence(markdown[:tail]); fence != "" {
+		b.WriteString(fence + "\n")
+	}
+	b.WriteString(strings.TrimLeft(markdown[tail:], "\n"))
+	return b.String()
+}
+
+func omittedNote(lines, size int, attachment string) string {
+	return fmt.Sprintf("---\n\n*%d lines (%s) of output omitted — the full output is attached as `%s`.*\n\n---",
+		lines, formatBytes(size), attachment)
+}
+
+// cutPoint moves i to a nearby line start: back for the end of the head,
+// forward for the start of the tail. Lines longer than half the window are
+// cut at a character boundary instead.
+func cutPoint(s string, i int, forward bool) int {
+	if forward {
+		window := (len(s) - i) / 2
+		if j := strings.IndexByte(s[i:], '\n'); j >= 0 && j <= window {
+			return i + j + 1
+		}
+		for i < len(s) && !utf8.RuneStart(s[i]) {
+			i++
+		}
+		return i
+	}
+	if j := strings.LastIndexByte(s[:i], '\n'); j >= 0 && j >= i/2 {
+		return j + 1
+	}
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// openFence returns the opening line of the code block s ends inside, or
+// "" when s does not end inside a fenced code block.
+func openFence(s string) string {
+	open := ""
+	for _, line := range strings.Split(s, "\n") {
+		open = nextFence(open, line)
+	}
+	return open
+}
+
+// nextFence returns the fence line that is open after line, given the one
+// open before it ("" for none).
+func nextFence(open, line string) string {
+	t := strings.TrimLeft(line, " ")
+	marker := fenceMarker(t)
+	switch {
+	case marker == "":
+	case open == "":
+		return t
+	default:
+		// A closing fence uses the same character, is at least as long
+		// as the opening one and has no info string.
+		opening := fenceMarker(open)
+		if marker[0] == opening[0] && len(marker) >= len(opening) && strings.TrimSpace(t) == marker {
+			return ""
+		}
+	}
+	return open
+}
+
+// fenceMarker returns the run of backticks or tildes a fence line starts
+// with, or "" for other lines.
+func fenceMarker(line string) string {
+	for _, c := range []byte{'`', '~'} {
+		n := 0
+		for n < len(line) && line[n] == c {
+			n++
+		}
+		if n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
+
+// outputAttachment returns the full output as a Markdown attachment,
+// gzipped when it is large.
+func outputAttachment(markdown, name string) Attachment {
+	if len(markdown) > gzipThreshold {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err := zw.Write([]byte(markdown))
+		if err == nil {
+			err = zw.Close()
+		}
+		if err == nil {
+			return Attachment{Filename: name + ".md.gz", ContentType: "application/gzip", Data: buf.Bytes()}
+		}
+		slog.Warn("gzip output attachment failed", "name", name, "error", err)
+	}
+	return Attachment{Filename: name + ".md", ContentType: "text/markdown; charset=utf-8", Data: []byte(markdown)}
+}
+
+// messageOverhead approximates the headers and MIME part headers of a
+// message, per message and per attachment.
+const messageOverhead = 4 << 10
+
+// encodedSize estimates the size of a message on the wire: attachments
+// are base64 encoded in 76-character lines.
+func encodedSize(htmlBody string, attachments []Attachment) int {
+	size := len(htmlBody) + messageOverhead
+	for _, att := range attachments {
+		b64 := (len(att.Data) + 2) / 3 * 4
+		size += b64 + b64/76*2 + messageOverhead
+	}
+	return size
+}
+
+// fitMessage drops the largest attachments until the message fits in
+// maxBytes and notes each dropped attachment at the end of the body.
+func fitMessage(htmlBody string, attachments []Attachment, maxBytes int) (string, []Attachment) {
+	if maxBytes <= 0 || encodedSize(htmlBody, attachments) <= maxBytes {
+		return htmlBody, attachments
+	}
+	kept := append([]Attachment(nil), attachments...)
+	sort.SliceStable(kept, func(i, j int) bool { return len(kept[i].Data) > len(kept[j].Data) })
+
+	var notes strings.Builder
+	for len(kept) > 0 && encodedSize(htmlBody+notes.String(), kept) > maxBytes {
+		dropped := kept[0]
+		kept = kept[1:]
+		fmt.Fprintf(&notes, `<p style="margin:12px 20px;color:#cf222e;">%s (%s) was not attached: the message would exceed the %s limit of the mail provider.</p>`,
+			html.EscapeString(dropped.Filename), formatBytes(len(dropped.Data)), formatBytes(maxBytes))
+		slog.Warn("attachment dropped to fit the message size limit",
+			"filename", dropped.Filename, "size", len(dropped.Data), "max", maxBytes)
+	}
+	if i := strings.LastIndex(htmlBody, "</body>"); i >= 0 {
+		return htmlBody[:i] + notes.String() + htmlBody[i:], kept
+	}
+	return htmlBody + notes.String(), kept
+}
+
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,122 @@
+package email
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logLines returns n numbered log lines.
+func logLines(n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "2026-02-23T10:00:00Z INFO request %06d handled in 12ms\n", i)
+	}
+	return b.String()
+}
+
+func TestRenderReport(t *testing.T) {
+	t.Run("small output is rendered as is", func(t *testing.T) {
+		body, atts, err := RenderReport("All tests pass", "abcd1234-output")
+		require.NoError(t, err)
+		assert.Contains(t, body, "All tests pass")
+		assert.Empty(t, atts)
+	})
+
+	t.Run("large output keeps head and tail and attaches the rest", func(t *testing.T) {
+		markdown := "Running the load test:\n\n" + logLines(10000) + "\nDone: 10000 requests"
+		body, atts, err := RenderReport(markdown, "abcd1234-output")
+		require.NoError(t, err)
+
+		assert.LessOrEqual(t, len(body), MaxBodyBytes)
+		assert.Contains(t, body, "Running the load test:", "앞부분 유지")
+		assert.Contains(t, body, "Done: 10000 requests", "뒷부분 유지")
+		assert.Contains(t, body, "of output omitted")
+		assert.Contains(t, body, "abcd1234-output.md")
+
+		require.Len(t, atts, 1)
+		assert.Equal(t, "abcd1234-output.md", atts[0].Filename)
+		assert.Equal(t, markdown, string(atts[0].Data), "첨부는 전체 출력")
+	})
+
+	t.Run("large code block stays within the budget", func(t *testing.T) {
+		markdown := "```go\n" + strings.Repeat("fmt.Println(\"hello\", 1, 2, 3)\n", 20000) + "```\n\nEnd"
+		body, atts, err := RenderReport(markdown, "abcd1234-output")
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(body), MaxBodyBytes)
+		assert.Contains(t, body, "End")
+		assert.Len(t, atts, 1)
+	})
+
+	t.Run("huge output is gzipped", func(t *testing.T) {
+		markdown := logLines(30000)
+		require.Greater(t, len(markdown), gzipThreshold)
+		_, atts, err := RenderReport(markdown, "abcd1234-output")
+		require.NoError(t, err)
+		require.Len(t, atts, 1)
+		assert.Equal(t, "abcd1234-output.md.gz", atts[0].Filename)
+		assert.Equal(t, "application/gzip", atts[0].ContentType)
+
+		zr, err := gzip.NewReader(bytes.NewReader(atts[0].Data))
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Equal(t, markdown, string(data))
+	})
+}
+
+func TestExcerpt(t *testing.T) {
+	t.Run("cuts at line breaks", func(t *testing.T) {
+		got := excerpt("one\ntwo\nthree\nfour\nfive\nsix\n", 9, 9, "out.md")
+		assert.True(t, strings.HasPrefix(got, "one\ntwo\n\n---"), got)
+		assert.True(t, strings.HasSuffix(got, "---\n\nsix\n"), got)
+		assert.Contains(t, got, "*3 lines (16 B) of output omitted")
+	})
+
+	t.Run("closes and reopens a code block cut in two", func(t *testing.T) {
+		markdown := "Log:\n```text\n" + strings.Repeat("line\n", 100) + "```\nafter"
+		got := excerpt(markdown, 40, 30, "out.md")
+		head, tail, ok := strings.Cut(got, "of output omitted")
+		require.True(t, ok)
+		assert.Contains(t, head, "line\n```\n\n---", "잘린 코드 블록을 닫음")
+		assert.Contains(t, tail, "---\n\n```text\nline", "뒷부분에서 다시 엶")
+	})
+
+	t.Run("long single line is cut at a character boundary", func(t *testing.T) {
+		got := excerpt(strings.Repeat("가", 1000), 100, 100, "out.md")
+		assert.True(t, strings.HasPrefix(got, strings.Repeat("가", 33)), "UTF-8 문자를 깨지 않음")
+		assert.True(t, strings.HasSuffix(got, "가"))
+	})
+}
+
+func TestOpenFence(t *testing.T) {
+	assert.Equal(t, "", openFence("text\n```go\ncode\n```\n"))
+	assert.Equal(t, "```go", openFence("text\n```go\ncode\n"))
+	assert.Equal(t, "````", openFence("````\n```\nstill code\n"), "짧은 펜스로는 닫히지 않음")
+	assert.Equal(t, "", openFence("~~~\ncode\n~~~"))
+}
+
+func TestFitMessage(t *testing.T) {
+	small := Attachment{Filename: "a.patch", Data: []byte("+x")}
+	big := Attachment{Filename: "out.md", Data: bytes.Repeat([]byte("x"), 600<<10)}
+
+	t.Run("fits unchanged", func(t *testing.T) {
+		body, atts := fitMessage("<p>hi</p>", []Attachment{small, big}, 10<<20)
+		assert.Equal(t, "<p>hi</p>", body)
+		assert.Len(t, atts, 2)
+	})
+
+	t.Run("drops the largest attachment", func(t *testing.T) {
+		body, atts := fitMessage("<html><body><p>hi</p></body></html>", []Attachment{small, big}, 200<<10)
+		require.Len(t, atts, 1)
+		assert.Equal(t, "a.patch", atts[0].Filename)
+		assert.Contains(t, body, "out.md (600.0 KB) was not attached")
+		assert.True(t, strings.HasSuffix(body, "</body></html>"), "안내는 body 안에")
+	})
+}
@@ -1,16 +1,27 @@
 package email
 
 import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"strings"
 
 	"github.com/yhzion/claude-postman/internal/config"
 )
 
+// ErrSMTPAuth wraps errors from logging in to the SMTP server.
+var ErrSMTPAuth = errors.New("smtp authentication failed")
+
 // SMTPSender abstracts SMTP sending for testability.
 type SMTPSender interface {
-	Send(from, to, subject, htmlBody, messageID, inReplyTo string) error
+	// Send delivers a message to to, with copies to cc.
+	Send(from, to string, cc []string, subject, htmlBody, messageID, inReplyTo string, attachments []Attachment) error
 }
 
 // smtpSender is the real SMTP implementation using net/smtp.
@@ -30,13 +41,64 @@ func newSMTPSender(cfg *config.EmailConfig) SMTPSender {
 	}
 }
 
-func (s *smtpSender) Send(from, to, subject, htmlBody, messageID, inReplyTo string) error {
-	var b strings.Builder
+func (s *smtpSender) Send(from, to string, cc []string, subject, htmlBody, messageID, inReplyTo string, attachments []Attachment) error {
+	msg, err := buildMessage(from, to, cc, subject, htmlBody, messageID, inReplyTo, attachments)
+	if err != nil {
+		return err
+	}
+
+	c, err := smtp.Dial(fmt.Sprintf("%s:%d", s.host, s.port))
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// The steps of smtp.SendMail, so a failed login can be told apart from
+	// a rejected message.
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := c.Extension("AUTH"); !ok {
+		return fmt.Errorf("%w: server does not offer AUTH", ErrSMTPAuth)
+	}
+	if err := c.Auth(smtp.PlainAuth("", s.user, s.password, s.host)); err != nil {
+		return fmt.Errorf("%w: %w", ErrSMTPAuth, err)
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range append([]string{to}, cc...) {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMessage assembles the raw RFC 5322 message.
+// Without attachments the body is a single text/html part;
+// otherwise a multipart/mixed message is produced.
+func buildMessage(from, to string, cc []string, subject, htmlBody, messageID, inReplyTo string, attachments []Attachment) ([]byte, error) {
+	var b bytes.Buffer
 	b.WriteString("From: " + from + "\r\n")
 	b.WriteString("To: " + to + "\r\n")
-	b.WriteString("Subject: " + subject + "\r\n")
+	if len(cc) > 0 {
+		b.WriteString("Cc: " + strings.Join(cc, ", ") + "\r\n")
+	}
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
 	b.WriteString("MIME-Version: 1.0\r\n")
-	b.WriteString("Content-Type: text/html; charset=utf-8\r\n")
 	if messageID != "" {
 		b.WriteString("Message-ID: " + messageID + "\r\n")
 	}
@@ -44,10 +106,64 @@ func (s *smtpSender) Send(from, to, subject, htmlBody, messageID, inReplyTo stri
 		b.WriteString("In-Reply-To: " + inReplyTo + "\r\n")
 		b.WriteString("References: " + inReplyTo + "\r\n")
 	}
+
+	if len(attachments) == 0 {
+		b.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(htmlBody)
+		return b.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	b.WriteString("Content-Type: multipart/mixed; boundary=" + mw.Boundary() + "\r\n")
 	b.WriteString("\r\n")
-	b.WriteString(htmlBody)
 
-	addr := fmt.Sprintf("%s:%d", s.host, s.port)
-	auth := smtp.PlainAuth("", s.user, s.password, s.host)
-	return smtp.SendMail(addr, auth, from, []string{to}, []byte(b.String()))
+	htmlPart, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/html; charset=utf-8"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := htmlPart.Write([]byte(htmlBody)); err != nil {
+		return nil, err
+	}
+
+	for _, att := range attachments {
+		if err := writeAttachment(mw, att); err != nil {
+			return nil, fmt.Errorf("attach %s: %w", att.Filename, err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+func writeAttachment(mw *multipart.Writer, att Attachment) error {
+	contentType := att.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", att.Filename)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Wrap base64 at 76 characters per RFC 2045.
+	encoded := base64.StdEncoding.EncodeToString(att.Data)
+	var lines strings.Builder
+	for len(encoded) > 76 {
+		lines.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	lines.WriteString(encoded + "\r\n")
+	_, err = part.Write([]byte(lines.String()))
+	return err
 }
@@ -0,0 +1,149 @@
+package email
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Many email clients (Gmail for non-Google accounts, Outlook) drop <style>
+// blocks, so every element carries its style inline. The <style> block in
+// the template only adds dark mode on clients that support it; its rules
+// use !important to override the inline light colours.
+
+const (
+	monoFont   = `ui-monospace,SFMono-Regular,Menlo,Consolas,'Liberation Mono',monospace`
+	mutedColor = "#57606a"
+	lineColor  = "#d0d7de"
+	codeBg     = "#f6f8fa"
+)
+
+// tagStyles are the inline styles of rendered Markdown elements.
+var tagStyles = map[atom.Atom]string{
+	atom.H1:         "font-size:24px;line-height:1.25;margin:24px 0 16px;padding-bottom:6px;border-bottom:1px solid " + lineColor + ";",
+	atom.H2:         "font-size:20px;line-height:1.25;margin:24px 0 16px;padding-bottom:6px;border-bottom:1px solid " + lineColor + ";",
+	atom.H3:         "font-size:16px;line-height:1.25;margin:20px 0 12px;",
+	atom.H4:         "font-size:14px;line-height:1.25;margin:20px 0 12px;",
+	atom.P:          "margin:0 0 12px;",
+	atom.A:          "color:#0969da;text-decoration:underline;",
+	atom.Ul:         "margin:0 0 12px;padding-left:24px;",
+	atom.Ol:         "margin:0 0 12px;padding-left:24px;",
+	atom.Li:         "margin:4px 0;",
+	atom.Blockquote: "margin:0 0 12px;padding:0 12px;color:" + mutedColor + ";border-left:4px solid " + lineColor + ";",
+	atom.Hr:         "border:0;border-top:1px solid " + lineColor + ";margin:20px 0;",
+	atom.Table:      "border-collapse:collapse;margin:0 0 12px;",
+	atom.Th:         "border:1px solid " + lineColor + ";padding:6px 12px;background-color:" + codeBg + ";font-weight:600;text-align:left;",
+	atom.Td:         "border:1px solid " + lineColor + ";padding:6px 12px;",
+	atom.Pre:        "background-color:" + codeBg + ";padding:12px;border-radius:6px;overflow-x:auto;font-family:" + monoFont + ";font-size:13px;line-height:1.45;margin:0 0 12px;white-space:pre-wrap;word-break:break-word;",
+	atom.Img:        "max-width:100%;",
+}
+
+// inlineCodeStyle applies to <code> outside of <pre>.
+const inlineCodeStyle = "background-color:#eff1f3;padding:2px 4px;border-radius:4px;font-family:" + monoFont + ";font-size:90%;"
+
+// classStyles are the inline styles of the elements renderToolCall emits,
+// applied after the tag's own style.
+var classStyles = map[string]string{
+	"pm-tool":       "margin:0 0 12px;border:1px solid " + lineColor + ";border-left-width:4px;border-radius:6px;background-color:" + codeBg + ";",
+	"pm-tool-shell": "border-left-color:#8250df;",
+	"pm-tool-edit":  "border-left-color:#bf8700;",
+	"pm-tool-read":  "border-left-color:#0969da;",
+	"pm-tool-other": "border-left-color:" + mutedColor + ";",
+	"pm-tool-head":  "padding:6px 10px;font-size:13px;cursor:pointer;",
+	"pm-tool-out":   "margin:0;border-radius:0 0 6px 0;background-color:#ffffff;color:#24292f;border-top:1px solid " + lineColor + ";",
+}
+
+// darkModeCSS switches the colours of the inline styles on clients that
+// support prefers-color-scheme. Highlighted code keeps its light background
+// so the token colours stay readable.
+const darkModeCSS = `:root{color-scheme:light dark;supported-color-schemes:light dark;}
+@media (prefers-color-scheme: dark){
+.pm-body{background-color:#0d1117 !important;color:#e6edf3 !important;}
+.pm-body a{color:#4493f8 !important;}
+.pm-body h1,.pm-body h2,.pm-body hr,.pm-body th,.pm-body td{border-color:#3d444d !important;}
+.pm-body th{background-color:#151b23 !important;}
+.pm-body blockquote{color:#9198a1 !important;border-color:#3d444d !important;}
+.pm-code{background-color:#262c36 !important;}
+.pm-tool{background-color:#151b23 !important;border-color:#3d444d !important;}
+.pm-tool-shell{border-left-color:#ab7df8 !important;}
+.pm-tool-edit{border-left-color:#d29922 !important;}
+.pm-tool-read{border-left-color:#4493f8 !important;}
+.pm-tool-out{background-color:#0d1117 !important;color:#e6edf3 !important;border-color:#3d444d !important;}
+}`
+
+// inlineStyles adds the inline styles to an HTML fragment.
+func inlineStyles(fragment string) (string, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), body)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		styleNode(n, false)
+		if err := html.Render(&b, n); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func styleNode(n *html.Node, inPre bool) {
+	if n.Type == html.ElementNode {
+		style := tagStyles[n.DataAtom]
+		switch {
+		case n.DataAtom == atom.Code && !inPre:
+			style = inlineCodeStyle
+			addClass(n, "pm-code")
+		case n.DataAtom == atom.Input && attr(n, "type") == "checkbox":
+			checkboxToText(n)
+		}
+		for _, class := range strings.Fields(attr(n, "class")) {
+			style += classStyles[class]
+		}
+		if style != "" {
+			// Styles already on the element (e.g. from the highlighter)
+			// come last so they win.
+			setAttr(n, "style", style+attr(n, "style"))
+		}
+		if n.DataAtom == atom.Pre {
+			inPre = true
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		styleNode(c, inPre)
+	}
+}
+
+// checkboxToText replaces a task list checkbox, which email clients do not
+// render, with a ballot box character.
+func checkboxToText(n *html.Node) {
+	box := "☐"
+	for _, a := range n.Attr {
+		if a.Key == "checked" {
+			box = "☑"
+		}
+	}
+	*n = html.Node{
+		Parent: n.Parent, PrevSibling: n.PrevSibling, NextSibling: n.NextSibling,
+		Type: html.TextNode, Data: box,
+	}
+}
+
+func addClass(n *html.Node, class string) {
+	if c := attr(n, "class"); c != "" {
+		class = c + " " + class
+	}
+	setAttr(n, "class", class)
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
@@ -0,0 +1,73 @@
+package email
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// subjectPrefix marks every email sent by the relay. Poll only fetches
+// emails whose subject contains it, so replies keep reaching the relay.
+const subjectPrefix = "[claude-postman]"
+
+// maxTitleLength is the maximum length of a generated session title, in runes.
+const maxTitleLength = 50
+
+// SessionTitle summarizes a prompt into a short session title: its first
+// non-empty line, cut at a word boundary to at most maxTitleLength runes.
+func SessionTitle(prompt string) string {
+	var line string
+	for _, l := range strings.Split(prompt, "\n") {
+		if l = strings.Join(strings.Fields(l), " "); l != "" {
+			line = strings.TrimLeft(l, "#>*- ")
+			break
+		}
+	}
+	if utf8.RuneCountInString(line) <= maxTitleLength {
+		return line
+	}
+	runes := []rune(line)[:maxTitleLength]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, " "); i > maxTitleLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " .,;:") + "…"
+}
+
+// SessionSubject returns the subject of a session email, e.g.
+// "[claude-postman] api-server: Fix flaky test". It never changes over the
+// session's life: Gmail starts a new conversation when the subject changes,
+// even for replies. The state of each email goes in its body (StateLine).
+func SessionSubject(s *storage.Session) string {
+	return subjectPrefix + " " + SessionLabel(s)
+}
+
+// StateLine returns the Markdown line that opens a session email with its
+// state, e.g. "**done**". Being the first text of the body, it is what mail
+// clients show as the preview next to the unchanging subject.
+func StateLine(state string) string {
+	return "**" + state + "**\n\n"
+}
+
+// SessionLabel names a session the way its subjects do, e.g.
+// "api-server: Fix flaky test", falling back to the short session ID when
+// the session has no name.
+func SessionLabel(s *storage.Session) string {
+	title := s.Name
+	if title == "" {
+		title = shortID(s.ID)
+	}
+	if dir := filepath.Base(s.WorkingDir); dir != "." && dir != "/" && dir != "" {
+		title = dir + ": " + title
+	}
+	return title
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
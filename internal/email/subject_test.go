@@ -0,0 +1,39 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+func TestSessionTitle(t *testing.T) {
+	assert.Equal(t, "Fix flaky test", SessionTitle("\n  Fix   flaky test\n\nIt fails on CI."))
+	assert.Equal(t, "Add retries", SessionTitle("## Add retries\nto the client"))
+
+	long := SessionTitle(strings.Repeat("refactor the storage layer ", 5))
+	assert.True(t, strings.HasSuffix(long, "…"))
+	assert.LessOrEqual(t, utf8.RuneCountInString(long), maxTitleLength+1)
+	assert.NotContains(t, long, "laye…", "단어 중간에서 자르지 않음")
+
+	assert.Empty(t, SessionTitle("  \n "))
+}
+
+func TestSessionSubject(t *testing.T) {
+	s := &storage.Session{ID: "0123456789ab", WorkingDir: "/home/me/api-server", Name: "Fix flaky test"}
+	assert.Equal(t, "[claude-postman] api-server: Fix flaky test", SessionSubject(s))
+
+	s.Name = ""
+	s.WorkingDir = "/"
+	assert.Equal(t, "[claude-postman] 01234567", SessionSubject(s), "제목이 없으면 세션 ID 앞 8자")
+}
+
+func TestStateLine(t *testing.T) {
+	body, err := RenderHTML(StateLine("waiting for input") + "Which database?")
+	require.NoError(t, err)
+	assert.Less(t, strings.Index(body, "waiting for input"), strings.Index(body, "Which database?"),
+		"상태는 미리보기에 보이도록 본문 맨 앞")
+}
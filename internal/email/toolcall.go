@@ -0,0 +1,176 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// toolCall is a tool invocation in captured Claude Code output:
+//
+//	● Bash(go test ./...)
+//	  ⎿  ok  	example.com/app	0.4s
+type toolCall struct {
+	Name   string // Bash, Update, Read, ...
+	Args   string
+	Output string // result lines with the ⎿ gutter removed
+}
+
+var (
+	// toolStartRe matches the first line of a tool call. Long arguments
+	// wrap onto following lines, so the closing parenthesis is optional.
+	toolStartRe = regexp.MustCompile(`^[●⏺]\s+([A-Z][A-Za-z]*)\((.*)$`)
+	// toolResultRe matches the first line of a tool's result.
+	toolResultRe = regexp.MustCompile(`^\s+⎿\s?\s?(.*)$`)
+)
+
+// maxArgLines limits how far a wrapped argument list is followed.
+const maxArgLines = 5
+
+// segment is a run of Markdown text or a single tool call.
+type segment struct {
+	markdown string
+	tool     *toolCall
+}
+
+// splitToolCalls separates tool calls from the Markdown text around them.
+// Inside a fenced code block, such as a quoted transcript, a tool call line
+// is plain text: splitting there would leave the fence unclosed.
+func splitToolCalls(s string) []segment {
+	lines := strings.Split(s, "\n")
+	var segments []segment
+	var text []string
+	flush := func() {
+		if md := strings.Trim(strings.Join(text, "\n"), "\n"); md != "" {
+			segments = append(segments, segment{markdown: md})
+		}
+		text = nil
+	}
+
+	fence := ""
+	for i := 0; i < len(lines); {
+		if fence == "" {
+			if call, next, ok := parseToolCall(lines, i); ok {
+				flush()
+				segments = append(segments, segment{tool: call})
+				i = next
+				continue
+			}
+		}
+		fence = nextFence(fence, lines[i])
+		text = append(text, lines[i])
+		i++
+	}
+	flush()
+	return segments
+}
+
+// parseToolCall reads the tool call starting at lines[i] and returns the
+// index of the first line after it.
+func parseToolCall(lines []string, i int) (*toolCall, int, bool) {
+	m := toolStartRe.FindStringSubmatch(lines[i])
+	if m == nil {
+		return nil, i, false
+	}
+	call := &toolCall{Name: m[1]}
+
+	args := []string{m[2]}
+	j := i + 1
+	for !strings.HasSuffix(strings.TrimSpace(args[len(args)-1]), ")") {
+		if j >= len(lines) || len(args) > maxArgLines || !startsWithSpace(lines[j]) || toolResultRe.MatchString(lines[j]) {
+			return nil, i, false
+		}
+		args = append(args, strings.TrimSpace(lines[j]))
+		j++
+	}
+	joined := strings.TrimSpace(strings.Join(args, " "))
+	call.Args = strings.TrimSuffix(joined, ")")
+
+	if j >= len(lines) {
+		return call, j, true
+	}
+	first := toolResultRe.FindStringSubmatch(lines[j])
+	if first == nil {
+		return call, j, true
+	}
+	out := []string{first[1]}
+	j++
+	// Result lines are indented past the ⎿ gutter. A blank line ends the
+	// result unless the output continues after it.
+	for j < len(lines) {
+		l := lines[j]
+		if strings.TrimSpace(l) == "" {
+			if j+1 < len(lines) && isResultLine(lines[j+1]) {
+				out = append(out, "")
+				j++
+				continue
+			}
+			break
+		}
+		if !isResultLine(l) {
+			break
+		}
+		out = append(out, strings.TrimPrefix(l, resultIndent))
+		j++
+	}
+	call.Output = strings.TrimRight(strings.Join(out, "\n"), " \n")
+	return call, j, true
+}
+
+// resultIndent is the indentation of result lines after the first.
+const resultIndent = "     "
+
+func isResultLine(l string) bool {
+	return strings.HasPrefix(l, resultIndent)
+}
+
+// toolKinds groups tools by what they do; each kind has its own colour.
+var toolKinds = map[string]string{
+	"Bash":         "shell",
+	"BashOutput":   "shell",
+	"Edit":         "edit",
+	"MultiEdit":    "edit",
+	"Update":       "edit",
+	"Write":        "edit",
+	"NotebookEdit": "edit",
+	"Read":         "read",
+	"Grep":         "read",
+	"Glob":         "read",
+	"Search":       "read",
+	"List":         "read",
+	"WebFetch":     "read",
+	"WebSearch":    "read",
+}
+
+// collapsedLines is how long a tool's output may be before it is folded
+// into a <details> element. Clients without <details> support show it open.
+const collapsedLines = 3
+
+// renderToolCall renders a tool call as a block of its own: a header with
+// the tool name and arguments, and the output in a monospace box.
+func renderToolCall(c *toolCall) string {
+	kind := toolKinds[c.Name]
+	if kind == "" {
+		kind = "other"
+	}
+	head := fmt.Sprintf(`<strong>%s</strong>`, html.EscapeString(c.Name))
+	if c.Args != "" {
+		head += fmt.Sprintf(` <code>%s</code>`, html.EscapeString(c.Args))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<div class="pm-tool pm-tool-%s">`, kind)
+	switch {
+	case c.Output == "":
+		fmt.Fprintf(&b, `<div class="pm-tool-head">%s</div>`, head)
+	case strings.Count(c.Output, "\n")+1 > collapsedLines:
+		fmt.Fprintf(&b, `<details><summary class="pm-tool-head">%s</summary><pre class="pm-tool-out">%s</pre></details>`,
+			head, html.EscapeString(c.Output))
+	default:
+		fmt.Fprintf(&b, `<div class="pm-tool-head">%s</div><pre class="pm-tool-out">%s</pre>`,
+			head, html.EscapeString(c.Output))
+	}
+	b.WriteString(`</div>`)
+	return b.String()
+}
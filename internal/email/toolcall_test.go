@@ -0,0 +1,66 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitToolCalls(t *testing.T) {
+	t.Run("separates tool calls from text", func(t *testing.T) {
+		segs := splitToolCalls("● Checking.\n\n● Bash(ls -la)\n  ⎿  a.go\n     b.go\n\n● Done.")
+		require.Len(t, segs, 3)
+		assert.Equal(t, "● Checking.", segs[0].markdown)
+		assert.Equal(t, &toolCall{Name: "Bash", Args: "ls -la", Output: "a.go\nb.go"}, segs[1].tool)
+		assert.Equal(t, "● Done.", segs[2].markdown)
+	})
+
+	t.Run("wrapped arguments", func(t *testing.T) {
+		segs := splitToolCalls("⏺ Bash(go test ./...\n      -count=1)\n  ⎿  ok")
+		require.Len(t, segs, 1)
+		assert.Equal(t, &toolCall{Name: "Bash", Args: "go test ./... -count=1", Output: "ok"}, segs[0].tool)
+	})
+
+	t.Run("blank line inside output", func(t *testing.T) {
+		segs := splitToolCalls("● Bash(cat f)\n  ⎿  one\n\n     two\nafter")
+		require.Len(t, segs, 2)
+		assert.Equal(t, "one\n\ntwo", segs[0].tool.Output)
+		assert.Equal(t, "after", segs[1].markdown)
+	})
+
+	t.Run("tool call without output", func(t *testing.T) {
+		segs := splitToolCalls("● Read(main.go)")
+		require.Len(t, segs, 1)
+		assert.Equal(t, &toolCall{Name: "Read", Args: "main.go"}, segs[0].tool)
+	})
+
+	t.Run("unclosed call is text", func(t *testing.T) {
+		segs := splitToolCalls("● Note(this is prose\nthat continues")
+		require.Len(t, segs, 1)
+		assert.Nil(t, segs[0].tool)
+	})
+
+	t.Run("call inside a code fence is text", func(t *testing.T) {
+		md := "```\n● Bash(npm test)\n  ⎿  1 failing\n```\n\n● Read(main.go)"
+		segs := splitToolCalls(md)
+		require.Len(t, segs, 2)
+		assert.Equal(t, "```\n● Bash(npm test)\n  ⎿  1 failing\n```", segs[0].markdown, "펜스는 한 조각에 그대로")
+		assert.Equal(t, "Read", segs[1].tool.Name, "펜스가 닫힌 뒤에는 다시 인식")
+	})
+}
+
+func TestRenderToolCall(t *testing.T) {
+	t.Run("short output is shown", func(t *testing.T) {
+		html := renderToolCall(&toolCall{Name: "Read", Args: "a<b>.go", Output: "Read 3 lines"})
+		assert.Contains(t, html, `class="pm-tool pm-tool-read"`)
+		assert.Contains(t, html, "<code>a&lt;b&gt;.go</code>", "인자는 이스케이프")
+		assert.NotContains(t, html, "<details>")
+	})
+
+	t.Run("long output is collapsible", func(t *testing.T) {
+		html := renderToolCall(&toolCall{Name: "Bash", Args: "make", Output: "1\n2\n3\n4"})
+		assert.Contains(t, html, `class="pm-tool pm-tool-shell"`)
+		assert.Contains(t, html, "<details><summary")
+	})
+}
@@ -0,0 +1,151 @@
+// Package git inspects the working directory of a session when it is a git repository.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Summary describes the changes made in a repository since a base commit.
+type Summary struct {
+	Base     string // commit the turn started from (empty if the repo had no commits)
+	Status   string // git status --short
+	DiffStat string // git diff --stat <base>, untracked files included
+	Patch    string // git diff <base>, untracked files included
+}
+
+// Empty reports whether the summary contains no changes.
+func (s *Summary) Empty() bool {
+	return s.Status == "" && s.DiffStat == "" && s.Patch == ""
+}
+
+func run(dir string, args ...string) (string, error) {
+	return runEnv(dir, nil, args...)
+}
+
+// runEnv is run with extra environment variables such as GIT_INDEX_FILE.
+func runEnv(dir string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...) //nolint:gosec // args are internally controlled
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return string(out), nil
+}
+
+// IsRepo reports whether dir is inside a git work tree.
+func IsRepo(dir string) bool {
+	out, err := run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// Head returns the commit hash HEAD points to.
+// Returns an empty string for a repository without commits.
+func Head(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		if _, statErr := run(dir, "rev-parse", "--git-dir"); statErr == nil {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Summarize collects status, diffstat and the full patch of dir against base.
+// Files that are new and not yet added are part of the diff, as they would be
+// committed by /commit. An empty base diffs against the empty tree, so a fresh
+// repository shows everything that was added.
+func Summarize(dir, base string) (*Summary, error) {
+	if base == "" {
+		base = emptyTree
+	}
+
+	status, err := run(dir, "status", "--short")
+	if err != nil {
+		return nil, err
+	}
+	index, cleanup, err := stageAll(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	env := []string{"GIT_INDEX_FILE=" + index}
+	stat, err := runEnv(dir, env, "diff", "--cached", "--stat", base)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := runEnv(dir, env, "diff", "--cached", base)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Summary{
+		Status:   strings.TrimRight(status, "\n"),
+		DiffStat: strings.TrimRight(stat, "\n"),
+		Patch:    patch,
+	}
+	if base != emptyTree {
+		s.Base = base
+	}
+	return s, nil
+}
+
+// stageAll runs "git add --all" against a temporary copy of the index of dir
+// and returns its path, so that untracked files can be diffed without
+// touching the index the user works with. cleanup removes the copy.
+func stageAll(dir string) (index string, cleanup func(), err error) {
+	gitIndex, err := run(dir, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", nil, err
+	}
+	gitIndex = strings.TrimSpace(gitIndex)
+	if !filepath.IsAbs(gitIndex) {
+		gitIndex = filepath.Join(dir, gitIndex)
+	}
+
+	tmp, err := os.MkdirTemp("", "claude-postman-index-")
+	if err != nil {
+		return "", nil, fmt.Errorf("create temp index: %w", err)
+	}
+	cleanup = func() { _ = os.RemoveAll(tmp) }
+	index = filepath.Join(tmp, "index")
+
+	// Starting from the real index keeps its stat cache, so only changed
+	// files are hashed. A repository without one starts empty.
+	data, err := os.ReadFile(gitIndex) //nolint:gosec // path comes from git rev-parse
+	switch {
+	case err == nil:
+		err = os.WriteFile(index, data, 0o600)
+	case errors.Is(err, fs.ErrNotExist):
+		err = nil
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("copy index: %w", err)
+	}
+
+	if _, err := runEnv(dir, []string{"GIT_INDEX_FILE=" + index}, "add", "--all"); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return index, cleanup, nil
+}
+
+// emptyTree is the well-known hash of git's empty tree object.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates a git repository with one committed file.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o600))
+	gitCmd(t, dir, "add", ".")
+	gitCmd(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	base := []string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}
+	out, err := exec.Command("git", append(base, args...)...).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestIsRepo(t *testing.T) {
+	assert.True(t, IsRepo(initRepo(t)))
+	assert.False(t, IsRepo(t.TempDir()))
+}
+
+func TestHead(t *testing.T) {
+	t.Run("returns commit hash", func(t *testing.T) {
+		head, err := Head(initRepo(t))
+		require.NoError(t, err)
+		assert.Len(t, head, 40)
+	})
+
+	t.Run("empty for repository without commits", func(t *testing.T) {
+		dir := t.TempDir()
+		gitCmd(t, dir, "init", "-q")
+		head, err := Head(dir)
+		require.NoError(t, err)
+		assert.Empty(t, head)
+	})
+}
+
+func TestSummarize(t *testing.T) {
+	t.Run("includes uncommitted and committed changes since base", func(t *testing.T) {
+		dir := initRepo(t)
+		base, err := Head(dir)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("new\n"), 0o600))
+		gitCmd(t, dir, "add", "b.txt")
+		gitCmd(t, dir, "commit", "-q", "-m", "add b")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two\n"), 0o600))
+
+		s, err := Summarize(dir, base)
+		require.NoError(t, err)
+		assert.Equal(t, base, s.Base)
+		assert.Contains(t, s.Status, "a.txt")
+		assert.Contains(t, s.DiffStat, "a.txt")
+		assert.Contains(t, s.DiffStat, "b.txt")
+		assert.Contains(t, s.Patch, "+two")
+		assert.Contains(t, s.Patch, "+new")
+	})
+
+	t.Run("includes untracked files without staging them", func(t *testing.T) {
+		dir := initRepo(t)
+		base, err := Head(dir)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("created\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "debug.log"), []byte("noise\n"), 0o600))
+
+		s, err := Summarize(dir, base)
+		require.NoError(t, err)
+		assert.Contains(t, s.Status, "?? new.txt")
+		assert.Contains(t, s.DiffStat, "new.txt", "새 파일도 diffstat에 포함")
+		assert.Contains(t, s.Patch, "+created", "새 파일 내용도 패치에 포함")
+		assert.NotContains(t, s.Patch, "noise", "무시된 파일은 제외")
+
+		staged, err := run(dir, "diff", "--cached", "--name-only")
+		require.NoError(t, err)
+		assert.Empty(t, staged, "사용자의 인덱스는 그대로")
+	})
+
+	t.Run("includes untracked files in a repository without commits", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git not installed")
+		}
+		dir := t.TempDir()
+		gitCmd(t, dir, "init", "-q")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "first.txt"), []byte("hello\n"), 0o600))
+
+		s, err := Summarize(dir, "")
+		require.NoError(t, err)
+		assert.Empty(t, s.Base)
+		assert.Contains(t, s.Patch, "+hello")
+	})
+
+	t.Run("empty when nothing changed", func(t *testing.T) {
+		dir := initRepo(t)
+		base, err := Head(dir)
+		require.NoError(t, err)
+
+		s, err := Summarize(dir, base)
+		require.NoError(t, err)
+		assert.True(t, s.Empty())
+	})
+}
+
+func TestCheckout(t *testing.T) {
+	dir := initRepo(t)
+	current := func() string {
+		out, err := run(dir, "rev-parse", "--abbrev-ref", "HEAD")
+		require.NoError(t, err)
+		return strings.TrimSpace(out)
+	}
+	initial := current()
+
+	_, err := Checkout(dir, "fix/login")
+	require.NoError(t, err)
+	assert.Equal(t, "fix/login", current(), "없는 브랜치는 새로 생성")
+
+	_, err = Checkout(dir, initial)
+	require.NoError(t, err)
+	assert.Equal(t, initial, current(), "있는 브랜치로 전환")
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Commit stages all changes in dir and commits them with message.
+// Returns the output of git commit.
+func Commit(dir, message string) (string, error) {
+	if _, err := run(dir, "add", "--all"); err != nil {
+		return "", err
+	}
+	return run(dir, "commit", "-m", message)
+}
+
+// Checkout switches dir to branch. A branch that exists only on origin is
+// checked out tracking it; a branch that exists nowhere is created from HEAD.
+func Checkout(dir, branch string) (string, error) {
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		return run(dir, "checkout", branch)
+	}
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch); err == nil {
+		return run(dir, "checkout", "--track", "origin/"+branch)
+	}
+	return run(dir, "checkout", "-b", branch)
+}
+
+// Push pushes the current branch to origin, setting upstream if needed.
+func Push(dir string) (string, error) {
+	return run(dir, "push", "--set-upstream", "origin", "HEAD")
+}
+
+// Revert discards the changes made since base.
+// Uncommitted changes (including untracked files) are stashed rather than
+// deleted, and commits created after base are reset away (they stay
+// reachable through the reflog), so nothing is lost for good.
+func Revert(dir, base string) (string, error) {
+	var b strings.Builder
+
+	status, err := run(dir, "status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(status) != "" {
+		out, err := run(dir, "stash", "push", "--include-untracked", "-m", "claude-postman revert")
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(out)
+	}
+
+	head, err := Head(dir)
+	if err != nil {
+		return "", err
+	}
+	if base != "" && head != base {
+		out, err := run(dir, "reset", "--hard", base)
+		if err != nil {
+			return b.String(), err
+		}
+		b.WriteString(out)
+	}
+
+	if b.Len() == 0 {
+		return "Nothing to revert.\n", nil
+	}
+	return b.String(), nil
+}
+
+// CreatePR opens a pull request for the current branch using the GitHub CLI.
+// The title and body are filled from the branch's commits.
+func CreatePR(dir string) (string, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return "", fmt.Errorf("gh (GitHub CLI) not found: install it to create pull requests")
+	}
+	cmd := exec.Command("gh", "pr", "create", "--fill")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("gh pr create: %w", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,189 @@
+// Package metrics defines the Prometheus metrics of claude-postman.
+//
+// Event metrics (polls, sends, signals, turns) are package variables
+// updated where the events happen in serve, email and session. Queue and
+// session gauges are read from the database when /metrics is scraped, so
+// they stay right across restarts.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+const namespace = "claude_postman"
+
+// latencyBuckets suit network round trips to mail servers.
+var latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	// IMAPPollDuration observes how long each IMAP poll takes.
+	IMAPPollDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "imap_poll_duration_seconds",
+		Help:    "Duration of IMAP polls.",
+		Buckets: latencyBuckets,
+	})
+	// IMAPPollErrors counts failed IMAP polls.
+	IMAPPollErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Name: "imap_poll_errors_total",
+		Help: "IMAP polls that failed.",
+	})
+	// LastPoll is the time of the last successful IMAP poll; alert when it
+	// falls behind to catch a stuck relay.
+	LastPoll = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "imap_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful IMAP poll.",
+	})
+	// MessagesProcessed counts incoming emails by how serve handled them.
+	MessagesProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "messages_processed_total",
+		Help: "Incoming emails by classification: new_session, reply, command, empty_reply, unmatched.",
+	}, []string{"kind"})
+	// SMTPSendDuration observes how long each SMTP send takes.
+	SMTPSendDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "smtp_send_duration_seconds",
+		Help:    "Duration of SMTP sends, successful or not.",
+		Buckets: latencyBuckets,
+	})
+	// SMTPSendErrors counts failed SMTP sends by kind.
+	SMTPSendErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "smtp_send_errors_total",
+		Help: "SMTP sends that failed, by kind: transient, permanent, auth.",
+	}, []string{"kind"})
+	// OutboxRetries counts outbox messages scheduled for another attempt.
+	OutboxRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Name: "outbox_retries_total",
+		Help: "Outbox messages scheduled for another send attempt.",
+	})
+	// OutboxPaused is 1 while sending is paused after an SMTP auth failure.
+	OutboxPaused = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "outbox_paused",
+		Help: "1 while the outbox is paused after an SMTP authentication failure.",
+	})
+	// TurnDuration observes how long turns take, by outcome.
+	TurnDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "turn_duration_seconds",
+		Help:    "Duration of turns from prompt to result, by outcome (done, waiting, ended).",
+		Buckets: []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+	}, []string{"outcome"})
+	// FIFOSignals counts signals read from session FIFOs, by type.
+	FIFOSignals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "fifo_signals_total",
+		Help: "Signals read from session FIFOs: done, ask, shutdown, unknown.",
+	}, []string{"type"})
+)
+
+var events = []prometheus.Collector{
+	IMAPPollDuration, IMAPPollErrors, LastPoll, MessagesProcessed,
+	SMTPSendDuration, SMTPSendErrors, OutboxRetries, OutboxPaused, TurnDuration, FIFOSignals,
+}
+
+// Handler serves the metrics in the Prometheus text format, including the
+// session and outbox gauges read from store and the Go runtime metrics.
+func Handler(store *storage.Store) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(events...)
+	reg.MustRegister(
+		newStoreCollector(store),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
+
+var (
+	sessionsDesc = prometheus.NewDesc(namespace+"_sessions",
+		"Sessions by status.", []string{"status"}, nil)
+	outboxDesc = prometheus.NewDesc(namespace+"_outbox_messages",
+		"Outbox messages by status (pending, sent, failed).", []string{"status"}, nil)
+	inboxDesc = prometheus.NewDesc(namespace+"_inbox_pending_messages",
+		"Replies not yet delivered to their session.", nil, nil)
+)
+
+// sessionStatuses and outboxStatuses are always reported, at 0 when no row
+// has them, so alerts do not see a missing series.
+var (
+	sessionStatuses = []string{"creating", "active", "idle", "waiting", "paused", "ended"}
+	outboxStatuses  = []string{"pending", "sent", "failed"}
+)
+
+// storeCollector reads the session and queue gauges from the database.
+type storeCollector struct {
+	store *storage.Store
+}
+
+func newStoreCollector(store *storage.Store) *storeCollector {
+	return &storeCollector{store: store}
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsDesc
+	ch <- outboxDesc
+	ch <- inboxDesc
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	if counts, err := c.store.SessionCounts(); err != nil {
+		ch <- prometheus.NewInvalidMetric(sessionsDesc, err)
+	} else {
+		emit(ch, sessionsDesc, sessionStatuses, counts)
+	}
+	if counts, err := c.store.OutboxCounts(); err != nil {
+		ch <- prometheus.NewInvalidMetric(outboxDesc, err)
+	} else {
+		emit(ch, outboxDesc, outboxStatuses, counts)
+	}
+	if msgs, err := c.store.ListPendingInbox(); err != nil {
+		ch <- prometheus.NewInvalidMetric(inboxDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(inboxDesc, prometheus.GaugeValue, float64(len(msgs)))
+	}
+}
+
+// emit reports every known status plus any unexpected one found in counts.
+func emit(ch chan<- prometheus.Metric, desc *prometheus.Desc, known []string, counts map[string]int) {
+	seen := make(map[string]bool, len(known))
+	for _, status := range known {
+		seen[status] = true
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(counts[status]), status)
+	}
+	for status, n := range counts {
+		if !seen[status] {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n), status)
+		}
+	}
+}
+
+// Serve serves /metrics on the TCP address listen until ctx is done. Unlike
+// the dashboard it may listen on any interface so Prometheus can scrape it
+// from another host: the metrics hold counts only, never mail content.
+func Serve(ctx context.Context, store *storage.Store, listen string) error {
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler(store))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("metrics listening", "address", listen)
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
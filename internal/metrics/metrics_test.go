@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+func newTestStore(t *testing.T) *storage.Store {
+	t.Helper()
+	store, err := storage.New(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	require.NoError(t, store.Migrate())
+	return store
+}
+
+func scrape(t *testing.T, store *storage.Store) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler(store).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestHandler_StoreGauges(t *testing.T) {
+	store := newTestStore(t)
+	require.NoError(t, store.CreateSession(&storage.Session{ID: "s1", Status: "waiting"}))
+	require.NoError(t, store.CreateSession(&storage.Session{ID: "s2", Status: "waiting"}))
+	require.NoError(t, store.CreateOutbox(&storage.OutboxMessage{ID: "o1", Subject: "s", Body: "b", Status: "failed"}))
+	require.NoError(t, store.EnqueueMessage(&storage.InboxMessage{ID: "i1", SessionID: "s1", Body: "next"}))
+
+	body := scrape(t, store)
+	assert.Contains(t, body, `claude_postman_sessions{status="waiting"} 2`)
+	assert.Contains(t, body, `claude_postman_sessions{status="active"} 0`, "행이 없는 상태도 0으로 보고")
+	assert.Contains(t, body, `claude_postman_outbox_messages{status="failed"} 1`)
+	assert.Contains(t, body, `claude_postman_outbox_messages{status="pending"} 0`)
+	assert.Contains(t, body, `claude_postman_inbox_pending_messages 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestHandler_EventMetrics(t *testing.T) {
+	store := newTestStore(t)
+	MessagesProcessed.WithLabelValues("reply").Inc()
+	FIFOSignals.WithLabelValues("done").Inc()
+	IMAPPollDuration.Observe(0.2)
+	TurnDuration.WithLabelValues("done").Observe(42)
+
+	body := scrape(t, store)
+	assert.Contains(t, body, `claude_postman_messages_processed_total{kind="reply"}`)
+	assert.Contains(t, body, `claude_postman_fifo_signals_total{type="done"}`)
+	assert.Contains(t, body, "claude_postman_imap_poll_duration_seconds_bucket")
+	assert.Contains(t, body, `claude_postman_turn_duration_seconds_count{outcome="done"}`)
+	assert.Contains(t, body, "claude_postman_outbox_paused 0")
+}
+
+func TestHandler_RepeatedRegistries(t *testing.T) {
+	// The dashboard and the metrics listener each build a handler.
+	store := newTestStore(t)
+	assert.NotPanics(t, func() {
+		Handler(store)
+		Handler(store)
+	})
+}
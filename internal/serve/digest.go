@@ -0,0 +1,123 @@
+package serve
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yhzion/claude-postman/internal/config"
+	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// digestStateKey stores when the last digest was sent (RFC 3339), so a
+// restart neither repeats nor skips one.
+const digestStateKey = "digest_sent_at"
+
+// digestTimeFormat is how times are shown in the digest.
+const digestTimeFormat = "Mon Jan 2 15:04"
+
+// checkDigest queues the digest once its scheduled time has passed since
+// the last one. The first check only records the start of the period, so
+// enabling the digest does not send one right away. A period with nothing
+// to report sends nothing.
+func (s *server) checkDigest(now time.Time) error {
+	if s.cfg.Digest.Schedule == "" {
+		return nil
+	}
+	raw, err := s.store.GetState(digestStateKey)
+	if err != nil {
+		return err
+	}
+	if raw == "" {
+		return s.store.SetState(digestStateKey, now.Format(time.RFC3339))
+	}
+	last, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", digestStateKey, err)
+	}
+	if !last.Before(digestSlot(s.cfg.Digest, now)) {
+		return nil
+	}
+
+	activity, err := s.store.ActivitySince(last)
+	if err != nil {
+		return err
+	}
+	if !activity.Empty() {
+		body, err := email.RenderHTML(digestMarkdown(activity, last))
+		if err != nil {
+			return err
+		}
+		if err := s.mailer.Send("", digestSubject(s.cfg.Digest, now), body); err != nil {
+			return fmt.Errorf("queue digest: %w", err)
+		}
+	}
+	return s.store.SetState(digestStateKey, now.Format(time.RFC3339))
+}
+
+// digestSlot returns the latest scheduled digest time at or before now.
+func digestSlot(d config.DigestConfig, now time.Time) time.Time {
+	clock, _ := time.Parse("15:04", d.Time) // validated by config
+	slot := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if d.Schedule == "weekly" {
+		weekday := time.Weekday(slices.Index(config.Weekdays, d.Weekday))
+		for slot.Weekday() != weekday {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+	return slot
+}
+
+func digestSubject(d config.DigestConfig, now time.Time) string {
+	kind := "Daily"
+	if d.Schedule == "weekly" {
+		kind = "Weekly"
+	}
+	return fmt.Sprintf("[claude-postman] %s digest — %s", kind, now.Format("Mon Jan 2"))
+}
+
+// digestMarkdown lists the sessions waiting for the user first, then what
+// happened since the last digest and the outbox backlog.
+func digestMarkdown(a *storage.Activity, since time.Time) string {
+	var b strings.Builder
+	if len(a.Waiting) > 0 {
+		fmt.Fprintf(&b, "## Waiting for you (%d)\n\n", len(a.Waiting))
+		for _, sess := range a.Waiting {
+			state := "waiting for input"
+			if sess.Status == "paused" {
+				state = "paused by the budget"
+			}
+			fmt.Fprintf(&b, "- **%s** — %s since %s\n",
+				email.SessionLabel(sess), state, sess.UpdatedAt.Local().Format(digestTimeFormat))
+		}
+		b.WriteString("\nReply to the session's last email to continue it.\n\n")
+	}
+
+	fmt.Fprintf(&b, "## Since %s\n\n", since.Local().Format(digestTimeFormat))
+	fmt.Fprintf(&b, "- Started: %d\n- Finished: %d\n- Failed: %d\n- Cost: $%.2f\n",
+		len(a.Started), len(a.Finished), len(a.Failed), a.Usage.CostUSD)
+	sessionSection(&b, "Started", a.Started)
+	sessionSection(&b, "Finished", a.Finished)
+	sessionSection(&b, "Failed (ended before finishing a turn)", a.Failed)
+
+	if a.OutboxPending > 0 || a.OutboxFailed > 0 {
+		fmt.Fprintf(&b, "\n## Outbox\n\n- Not sent yet: %d\n- Gave up retrying: %d\n",
+			a.OutboxPending, a.OutboxFailed)
+	}
+	return b.String()
+}
+
+func sessionSection(b *strings.Builder, title string, sessions []*storage.Session) {
+	if len(sessions) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, sess := range sessions {
+		fmt.Fprintf(b, "- %s\n", email.SessionLabel(sess))
+	}
+}
@@ -0,0 +1,88 @@
+package serve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/config"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+func TestDigestSlot(t *testing.T) {
+	// 2026-03-04 is a Wednesday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name string
+		cfg  config.DigestConfig
+		now  time.Time
+		want time.Time
+	}{
+		{"daily after the time", config.DigestConfig{Schedule: "daily", Time: "08:00"}, at(4, 9, 0), at(4, 8, 0)},
+		{"daily before the time", config.DigestConfig{Schedule: "daily", Time: "08:00"}, at(4, 7, 59), at(3, 8, 0)},
+		{"daily at the time", config.DigestConfig{Schedule: "daily", Time: "08:00"}, at(4, 8, 0), at(4, 8, 0)},
+		{"weekly", config.DigestConfig{Schedule: "weekly", Time: "18:30", Weekday: "monday"}, at(4, 9, 0), at(2, 18, 30)},
+		{"weekly on the day before the time", config.DigestConfig{Schedule: "weekly", Time: "18:30", Weekday: "wednesday"}, at(4, 9, 0),
+			time.Date(2026, 2, 25, 18, 30, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, digestSlot(tt.cfg, tt.now))
+		})
+	}
+}
+
+func TestCheckDigest(t *testing.T) {
+	s, _, ml := newTestServer(t)
+	s.cfg.Digest = config.DigestConfig{Schedule: "daily", Time: "08:00"}
+	start := time.Date(2026, 3, 4, 9, 0, 0, 0, time.Local)
+
+	require.NoError(t, s.checkDigest(start))
+	assert.Empty(t, ml.sent, "처음에는 기간 시작만 기록")
+
+	require.NoError(t, s.store.CreateSession(&storage.Session{
+		ID: "waiting-1", WorkingDir: "/home/me/api-server", Name: "Fix flaky test", Status: "waiting",
+	}))
+
+	require.NoError(t, s.checkDigest(start.Add(12*time.Hour)))
+	assert.Empty(t, ml.sent, "다음 발송 시각 전")
+
+	require.NoError(t, s.checkDigest(start.Add(23*time.Hour)))
+	require.Len(t, ml.sent, 1)
+	assert.Equal(t, "", ml.sent[0].sessionID)
+	assert.Equal(t, "[claude-postman] Daily digest — Thu Mar 5", ml.sent[0].subject)
+	assert.Contains(t, ml.sent[0].body, "Waiting for you (1)")
+	assert.Contains(t, ml.sent[0].body, "api-server: Fix flaky test")
+
+	require.NoError(t, s.checkDigest(start.Add(24*time.Hour)))
+	assert.Len(t, ml.sent, 1, "같은 주기에 다시 보내지 않음")
+
+	// A restarted server reads the last digest time from the database.
+	restarted, _, ml2 := newTestServer(t)
+	restarted.store = s.store
+	restarted.cfg.Digest = s.cfg.Digest
+	require.NoError(t, restarted.checkDigest(start.Add(25*time.Hour)))
+	assert.Empty(t, ml2.sent, "재시작 후에도 중복 발송 없음")
+}
+
+func TestCheckDigest_NothingToReport(t *testing.T) {
+	s, _, ml := newTestServer(t)
+	s.cfg.Digest = config.DigestConfig{Schedule: "daily", Time: "08:00"}
+	start := time.Date(2026, 3, 4, 9, 0, 0, 0, time.Local)
+
+	require.NoError(t, s.checkDigest(start))
+	require.NoError(t, s.checkDigest(start.Add(24*time.Hour)))
+	assert.Empty(t, ml.sent, "보고할 내용이 없으면 보내지 않음")
+}
+
+func TestCheckDigest_Disabled(t *testing.T) {
+	s, _, ml := newTestServer(t)
+	require.NoError(t, s.checkDigest(time.Now()))
+	v, err := s.store.GetState(digestStateKey)
+	require.NoError(t, err)
+	assert.Empty(t, v)
+	assert.Empty(t, ml.sent)
+}
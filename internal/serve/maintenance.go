@@ -0,0 +1,69 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/yhzion/claude-postman/internal/config"
+	"github.com/yhzion/claude-postman/internal/session"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+const (
+	// maintenanceDelay postpones the first run so it does not compete with startup.
+	maintenanceDelay    = time.Minute
+	maintenanceInterval = 24 * time.Hour
+)
+
+// MaintenanceResult summarizes a maintenance run.
+type MaintenanceResult struct {
+	storage.PurgeStats
+	Transcripts int // Claude Code transcripts removed for purged sessions
+}
+
+// Maintain runs the data retention job once: it purges data older than
+// general.retention_days, removes the transcripts of purged sessions and
+// compacts the database. serve runs it daily; `db gc` runs it on demand.
+func Maintain(cfg *config.Config, store *storage.Store, mgr *session.Manager) (*MaintenanceResult, error) {
+	s := &server{cfg: cfg, store: store, mgr: mgr}
+	return s.maintain()
+}
+
+func (s *server) maintain() (*MaintenanceResult, error) {
+	stats, err := s.store.PurgeOldData(s.cfg.General.RetentionDays)
+	if err != nil {
+		return nil, fmt.Errorf("purge old data: %w", err)
+	}
+	result := &MaintenanceResult{PurgeStats: *stats}
+	if len(stats.SessionIDs) > 0 {
+		result.Transcripts = s.mgr.RemoveTranscripts(stats.SessionIDs)
+	}
+	if err := s.store.Compact(); err != nil {
+		return result, fmt.Errorf("compact database: %w", err)
+	}
+	return result, nil
+}
+
+func (s *server) maintenanceLoop(ctx context.Context) error {
+	timer := time.NewTimer(maintenanceDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			result, err := s.maintain()
+			if err != nil {
+				slog.Error("maintenance failed", "error", err)
+			} else {
+				slog.Info("maintenance done",
+					"outbox", result.Outbox, "inbox", result.Inbox, "templates", result.Templates,
+					"sessions", result.Sessions, "transcripts", result.Transcripts)
+			}
+			timer.Reset(maintenanceInterval)
+		}
+	}
+}
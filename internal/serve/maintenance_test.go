@@ -0,0 +1,42 @@
+package serve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+func TestMaintain_PurgesEndedSessionsAndTranscripts(t *testing.T) {
+	s, mgr, _ := newTestServer(t)
+	old := time.Now().AddDate(0, 0, -60)
+	for id, status := range map[string]string{"old-ended": "ended", "live": "idle"} {
+		require.NoError(t, s.store.CreateSession(&storage.Session{
+			ID: id, TmuxName: "session-" + id, WorkingDir: "/tmp", Model: "sonnet",
+			Status: status, CreatedAt: old, UpdatedAt: old,
+		}))
+	}
+	require.NoError(t, s.store.CreateTurn(&storage.Turn{ID: "turn-1", SessionID: "old-ended", Prompt: "old"}))
+
+	result, err := s.maintain()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), result.Sessions)
+	assert.Equal(t, 1, result.Transcripts)
+	assert.Equal(t, []string{"old-ended"}, mgr.removedIDs)
+	_, err = s.store.GetSession("live")
+	assert.NoError(t, err)
+}
+
+func TestMaintain_NothingToPurge(t *testing.T) {
+	s, mgr, _ := newTestServer(t)
+	insertSession(t, s.store, "recent", "ended")
+
+	result, err := s.maintain()
+	require.NoError(t, err)
+
+	assert.Zero(t, result.Sessions)
+	assert.Empty(t, mgr.removedIDs, "삭제된 세션이 없으면 transcript도 건드리지 않음")
+}
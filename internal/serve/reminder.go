@@ -0,0 +1,100 @@
+package serve
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// reminderTailLines is how much of the question a reminder repeats.
+const reminderTailLines = 20
+
+// checkReminders mails a reminder for sessions that have been waiting for
+// input longer than each of reminder.after_min. The count is stored on the
+// waiting turn, so a reply (which starts a new turn) or the end of the
+// session stops the reminders, and a restart does not repeat them.
+func (s *server) checkReminders(now time.Time) error {
+	after := s.cfg.Reminder.AfterMin
+	if len(after) == 0 {
+		return nil
+	}
+	sessions, err := s.mgr.ListActive()
+	if err != nil {
+		return err
+	}
+
+	for _, sess := range sessions {
+		if sess.Status != "waiting" {
+			continue
+		}
+		turn, err := s.store.GetLatestTurn(sess.ID)
+		if err != nil {
+			return err
+		}
+		if turn == nil || turn.Outcome != "waiting" || turn.FinishedAt == nil {
+			continue
+		}
+		waited := now.Sub(*turn.FinishedAt)
+		// After a restart several thresholds may have passed; only the
+		// latest one is mailed.
+		due := turn.RemindersSent
+		for due < len(after) && waited >= time.Duration(after[due])*time.Minute {
+			due++
+		}
+		if due == turn.RemindersSent {
+			continue
+		}
+		s.notify(sess.ID, email.SessionSubject(sess),
+			email.StateLine(reminderState(due, len(after), waited))+reminderMarkdown(turn, waited))
+		if err := s.store.SetRemindersSent(turn.ID, due); err != nil {
+			return err
+		}
+		slog.Info("waiting reminder queued", "session_id", sess.ID, "reminder", due)
+	}
+	return nil
+}
+
+// reminderState escalates the state line from reminder to reminder.
+func reminderState(n, total int, waited time.Duration) string {
+	state := fmt.Sprintf("still waiting for input (%s)", formatWait(waited))
+	switch {
+	case n == total && n > 1:
+		return "last reminder: " + state
+	case n > 1:
+		return fmt.Sprintf("reminder %d: %s", n, state)
+	}
+	return "reminder: " + state
+}
+
+func reminderMarkdown(turn *storage.Turn, waited time.Duration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Claude has been waiting for your answer for %s. Reply to this email to continue.\n", formatWait(waited))
+	if tail := lastLines(turn.Result, reminderTailLines); tail != "" {
+		fence := "```"
+		for strings.Contains(tail, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&b, "\nThe question ended with:\n\n%stext\n%s\n%s\n", fence, tail, fence)
+	}
+	return b.String()
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n "), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// formatWait rounds a wait to minutes, or to hours from an hour up.
+func formatWait(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh", int(d.Hours()))
+}
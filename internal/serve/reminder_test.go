@@ -0,0 +1,104 @@
+package serve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// waitingSince stores a session whose latest turn started waiting at since.
+func waitingSince(t *testing.T, s *server, mgr *mockMgr, id string, since time.Time) *storage.Session {
+	t.Helper()
+	sess := &storage.Session{ID: id, WorkingDir: "/home/me/api-server", Name: "Fix flaky test", Status: "waiting"}
+	require.NoError(t, s.store.CreateSession(sess))
+	require.NoError(t, s.store.CreateTurn(&storage.Turn{
+		ID: id + "-turn", SessionID: id, Outcome: "waiting", FinishedAt: &since,
+		Result: "Which database should I use?\n\n1. Postgres\n2. SQLite",
+	}))
+	mgr.listActiveFn = func() ([]*storage.Session, error) { return []*storage.Session{sess}, nil }
+	return sess
+}
+
+func TestCheckReminders(t *testing.T) {
+	s, mgr, ml := newTestServer(t)
+	s.cfg.Reminder.AfterMin = []int{60, 240}
+	start := time.Now().Truncate(time.Second)
+	waitingSince(t, s, mgr, "sess-1", start)
+
+	require.NoError(t, s.checkReminders(start.Add(30*time.Minute)))
+	assert.Empty(t, ml.sent, "1시간 전에는 보내지 않음")
+
+	require.NoError(t, s.checkReminders(start.Add(61*time.Minute)))
+	require.Len(t, ml.sent, 1)
+	assert.Equal(t, "sess-1", ml.sent[0].sessionID, "세션 스레드로 발송")
+	assert.Equal(t, "[claude-postman] api-server: Fix flaky test", ml.sent[0].subject, "제목은 세션 내내 같음")
+	assert.Contains(t, ml.sent[0].body, "reminder: still waiting for input (1h)")
+	assert.Contains(t, ml.sent[0].body, "2. SQLite", "질문을 다시 보여줌")
+
+	require.NoError(t, s.checkReminders(start.Add(2*time.Hour)))
+	assert.Len(t, ml.sent, 1, "같은 단계는 한 번만")
+
+	require.NoError(t, s.checkReminders(start.Add(4*time.Hour)))
+	require.Len(t, ml.sent, 2)
+	assert.Contains(t, ml.sent[1].body, "last reminder: still waiting for input (4h)")
+
+	require.NoError(t, s.checkReminders(start.Add(24*time.Hour)))
+	assert.Len(t, ml.sent, 2, "마지막 재알림 이후에는 보내지 않음")
+}
+
+func TestCheckReminders_PersistsAcrossRestart(t *testing.T) {
+	s, mgr, ml := newTestServer(t)
+	s.cfg.Reminder.AfterMin = []int{60, 240}
+	start := time.Now().Truncate(time.Second)
+	waitingSince(t, s, mgr, "sess-1", start)
+
+	require.NoError(t, s.checkReminders(start.Add(90*time.Minute)))
+	require.Len(t, ml.sent, 1)
+
+	restarted, mgr2, ml2 := newTestServer(t)
+	restarted.store = s.store
+	restarted.cfg.Reminder = s.cfg.Reminder
+	mgr2.listActiveFn = mgr.listActiveFn
+	require.NoError(t, restarted.checkReminders(start.Add(100*time.Minute)))
+	assert.Empty(t, ml2.sent, "재시작 후 중복 발송 없음")
+}
+
+func TestCheckReminders_SkipsPassedThresholds(t *testing.T) {
+	s, mgr, ml := newTestServer(t)
+	s.cfg.Reminder.AfterMin = []int{60, 240}
+	start := time.Now().Truncate(time.Second)
+	waitingSince(t, s, mgr, "sess-1", start)
+
+	// serve was down through both thresholds.
+	require.NoError(t, s.checkReminders(start.Add(5*time.Hour)))
+	require.Len(t, ml.sent, 1, "지난 단계를 몰아서 보내지 않음")
+	assert.Contains(t, ml.sent[0].body, "last reminder")
+}
+
+func TestCheckReminders_StopsAfterReply(t *testing.T) {
+	s, mgr, ml := newTestServer(t)
+	s.cfg.Reminder.AfterMin = []int{60}
+	start := time.Now().Truncate(time.Second)
+	sess := waitingSince(t, s, mgr, "sess-1", start)
+
+	// The reply was delivered: a new turn is running.
+	require.NoError(t, s.store.CreateTurn(&storage.Turn{ID: "turn-2", SessionID: "sess-1"}))
+	require.NoError(t, s.checkReminders(start.Add(2*time.Hour)))
+	assert.Empty(t, ml.sent)
+
+	sess.Status = "ended"
+	require.NoError(t, s.checkReminders(start.Add(3*time.Hour)))
+	assert.Empty(t, ml.sent)
+}
+
+func TestCheckReminders_Disabled(t *testing.T) {
+	s, mgr, ml := newTestServer(t)
+	start := time.Now().Truncate(time.Second)
+	waitingSince(t, s, mgr, "sess-1", start)
+
+	require.NoError(t, s.checkReminders(start.Add(24*time.Hour)))
+	assert.Empty(t, ml.sent)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -16,28 +17,46 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/yhzion/claude-postman/internal/config"
+	"github.com/yhzion/claude-postman/internal/dashboard"
 	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/metrics"
 	"github.com/yhzion/claude-postman/internal/session"
 	"github.com/yhzion/claude-postman/internal/storage"
 )
 
 const fifoDir = "/tmp/claude-postman"
 
+// progressInterval is how often an active session created with
+// "Notify: progress" mails a snapshot of its output.
+const progressInterval = 10 * time.Minute
+
 // sessionMgr abstracts session.Manager for testability.
 type sessionMgr interface {
-	Create(workingDir, model, prompt string) (*storage.Session, error)
+	Create(workingDir, model, prompt, sourceMessageID string, opts session.CreateOptions) (*storage.Session, error)
 	DeliverNext(sessionID string) error
 	ListActive() ([]*storage.Session, error)
 	RecoverAll() error
 	HandleAsk(sessionID string) error
 	CaptureOutput(sessionID string) (string, error)
+	RunCommand(sessionID, name, arg string) error
+	RemoveTranscripts(sessionIDs []string) int
+	EndWithReport(sessionID, reason string) error
+	SendProgress(sessionID string) error
 }
 
 // mailPoller abstracts email.Mailer for testability.
 type mailPoller interface {
 	Poll() ([]*email.IncomingMessage, error)
 	FlushOutbox() error
-	SendTemplate() (string, error)
+	QueueTemplate() (string, error)
+	Send(sessionID, subject, htmlBody string) error
+	Resend(outboxID string) (string, error)
+}
+
+// Options controls optional serve behavior.
+type Options struct {
+	// NoTemplate skips queueing a session template email on startup.
+	NoTemplate bool
 }
 
 type server struct {
@@ -45,20 +64,30 @@ type server struct {
 	store        *storage.Store
 	mgr          sessionMgr
 	mailer       mailPoller
+	opts         Options
 	pollInterval time.Duration // override for testing; 0 means use cfg
+
+	// prompts detects input prompts that Claude did not signal with ASK.
+	prompts *session.PromptWatcher
+
+	// progressSent records when each session last mailed progress.
+	// Only touched from pollLoop.
+	progressSent map[string]time.Time
 }
 
 // RunServe runs the main event loop with signal handling.
 func RunServe(ctx context.Context, cfg *config.Config,
-	store *storage.Store, mgr *session.Manager, mailer *email.Mailer) error {
+	store *storage.Store, mgr *session.Manager, mailer *email.Mailer, opts Options) error {
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
 	s := &server{
-		cfg:    cfg,
-		store:  store,
-		mgr:    mgr,
-		mailer: mailer,
+		cfg:     cfg,
+		store:   store,
+		mgr:     mgr,
+		mailer:  mailer,
+		opts:    opts,
+		prompts: session.NewPromptWatcher(session.DefaultDetector, session.DefaultStableCaptures),
 	}
 	return s.run(ctx)
 }
@@ -68,13 +97,9 @@ func (s *server) run(ctx context.Context) error {
 		return fmt.Errorf("create FIFO dir: %w", err)
 	}
 
-	// Send template email to verify SMTP and ensure user has a fresh template.
-	// Serve will not start if this fails.
-	msgID, err := s.mailer.SendTemplate()
-	if err != nil {
-		return fmt.Errorf("send template email: %w", err)
+	if !s.opts.NoTemplate {
+		s.ensureTemplate()
 	}
-	slog.Info("template email sent", "message_id", msgID)
 
 	if err := s.mgr.RecoverAll(); err != nil {
 		return fmt.Errorf("recover sessions: %w", err)
@@ -97,9 +122,54 @@ func (s *server) run(ctx context.Context) error {
 		return s.flushLoop(ctx, interval)
 	})
 
+	g.Go(func() error {
+		return s.maintenanceLoop(ctx)
+	})
+
+	if listen := s.cfg.HTTP.Listen; listen != "" {
+		g.Go(func() error {
+			// The dashboard is optional: a listener that cannot start must
+			// not take the relay down.
+			if err := dashboard.New(s.store, s.mgr, s.mailer).Serve(ctx, listen); err != nil {
+				slog.Error("dashboard failed", "address", listen, "error", err)
+			}
+			return nil
+		})
+	}
+	if listen := s.cfg.HTTP.MetricsListen; listen != "" {
+		g.Go(func() error {
+			if err := metrics.Serve(ctx, s.store, listen); err != nil {
+				slog.Error("metrics endpoint failed", "address", listen, "error", err)
+			}
+			return nil
+		})
+	}
+
 	return g.Wait()
 }
 
+// ensureTemplate queues a fresh template email unless the latest valid one is
+// younger than email.template_refresh_days. The template goes through the
+// outbox, so an SMTP outage does not keep serve from starting.
+func (s *server) ensureTemplate() {
+	latest, err := s.store.LatestValidTemplate("", time.Now())
+	if err != nil {
+		slog.Error("failed to look up templates", "error", err)
+		return
+	}
+	refresh := time.Duration(s.cfg.Email.TemplateRefreshDays) * 24 * time.Hour
+	if latest != nil && time.Since(latest.CreatedAt) < refresh {
+		slog.Info("recent template still valid", "message_id", latest.MessageID)
+		return
+	}
+	msgID, err := s.mailer.QueueTemplate()
+	if err != nil {
+		slog.Error("failed to queue template email", "error", err)
+		return
+	}
+	slog.Info("template email queued", "message_id", msgID)
+}
+
 func (s *server) pollLoop(ctx context.Context, interval time.Duration) error {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -123,6 +193,15 @@ func (s *server) pollLoop(ctx context.Context, interval time.Duration) error {
 			if err := s.checkWaitingPrompts(); err != nil {
 				slog.Error("check waiting prompts failed", "error", err)
 			}
+			if err := s.checkSessionLimits(time.Now()); err != nil {
+				slog.Error("check session limits failed", "error", err)
+			}
+			if err := s.checkReminders(time.Now()); err != nil {
+				slog.Error("check reminders failed", "error", err)
+			}
+			if err := s.checkDigest(time.Now()); err != nil {
+				slog.Error("digest failed", "error", err)
+			}
 		}
 	}
 }
@@ -145,28 +224,89 @@ func (s *server) flushLoop(ctx context.Context, interval time.Duration) error {
 
 func (s *server) processMessages(msgs []*email.IncomingMessage) error {
 	for _, msg := range msgs {
-		if msg.IsNewSession {
+		metrics.MessagesProcessed.WithLabelValues(messageKind(msg)).Inc()
+		switch {
+		case msg.IsNewSession:
 			if err := s.handleNewSession(msg); err != nil {
 				slog.Error("failed to create session", "error", err)
 			}
-		} else if msg.SessionID != "" {
+		case msg.SessionID != "" && msg.Command != "":
+			if err := s.mgr.RunCommand(msg.SessionID, msg.Command, msg.CommandArg); err != nil {
+				slog.Error("failed to run command", "session_id", msg.SessionID, "command", msg.Command, "error", err)
+			}
+		case msg.SessionID != "":
 			if err := s.handleExistingSession(msg); err != nil {
 				slog.Error("failed to enqueue message", "session_id", msg.SessionID, "error", err)
 			}
-		} else {
+		default:
 			slog.Warn("ignoring unmatched email", "from", msg.From, "subject", msg.Subject)
 		}
 	}
 	return nil
 }
 
+// messageKind classifies an incoming email for the messages_processed metric,
+// following the dispatch in processMessages.
+func messageKind(msg *email.IncomingMessage) string {
+	switch {
+	case msg.IsNewSession:
+		return "new_session"
+	case msg.SessionID != "" && msg.Command != "":
+		return "command"
+	case msg.SessionID != "" && msg.Body == "":
+		return "empty_reply"
+	case msg.SessionID != "":
+		return "reply"
+	default:
+		return "unmatched"
+	}
+}
+
+// handleNewSession creates a session from a template reply. Directory and
+// model written in the reply win; otherwise the replied template's preset
+// decides, then the global defaults. Invalid directives or a failed start are
+// reported back by email instead of creating the session.
 func (s *server) handleNewSession(msg *email.IncomingMessage) error {
-	model := msg.Model
+	// A directive that could not be applied, such as a mistyped "Mdoe: plan",
+	// might have restricted the session, so it must not start without it.
+	if problems := directiveProblems(msg); len(problems) > 0 {
+		s.notify("", "[claude-postman] Session not started",
+			"The session was not started because of invalid directives:\n\n"+bulletList(problems))
+		return fmt.Errorf("invalid directives: %s", strings.Join(problems, "; "))
+	}
+
+	d := msg.Directives
+	opts := session.CreateOptions{
+		Name:      d.Name,
+		Branch:    d.Branch,
+		Timeout:   d.Timeout,
+		Effort:    d.Effort,
+		Notify:    d.Notify,
+		CC:        d.CC,
+		BudgetUSD: d.Budget,
+	}
+	workingDir, model := msg.WorkingDir, msg.Model
+	if msg.Template != "" {
+		if preset := s.cfg.Template(msg.Template); preset != nil {
+			if workingDir == "" {
+				workingDir = preset.WorkingDir
+			}
+			if model == "" {
+				model = preset.Model
+			}
+			opts.SystemPrompt = preset.SystemPrompt
+			opts.PermissionMode = preset.PermissionMode
+		} else {
+			slog.Warn("template preset no longer in config, using defaults", "template", msg.Template)
+		}
+	}
 	if model == "" {
 		model = s.cfg.General.DefaultModel
 	}
+	if d.Mode != "" {
+		opts.PermissionMode = d.Mode
+	}
 
-	workingDir := msg.WorkingDir
 	if workingDir == "" {
 		home, err := os.UserHomeDir()
 		if err != nil {
@@ -187,17 +327,54 @@ func (s *server) handleNewSession(msg *email.IncomingMessage) error {
 		workingDir = home
 	}
 
-	if _, err := s.mgr.Create(workingDir, model, msg.Body); err != nil {
+	if _, err := s.mgr.Create(workingDir, model, msg.Body, msg.MessageID, opts); err != nil {
+		s.notify("", "[claude-postman] Session not started",
+			fmt.Sprintf("The session could not be started:\n\n    %v", err))
 		return fmt.Errorf("create session: %w", err)
 	}
 
 	return nil
 }
 
+// directiveProblems lists the invalid and unknown directives of msg.
+func directiveProblems(msg *email.IncomingMessage) []string {
+	problems := slices.Clone(msg.DirectiveErrors)
+	for _, key := range msg.UnknownDirectives {
+		problems = append(problems, fmt.Sprintf("%s: not a known directive", key))
+	}
+	return problems
+}
+
+// notify renders a markdown notice and queues it through the outbox.
+// sessionID may be empty for notices that belong to no session.
+func (s *server) notify(sessionID, subject, markdown string) {
+	body, err := email.RenderHTML(markdown)
+	if err != nil {
+		body = markdown
+	}
+	if err := s.mailer.Send(sessionID, subject, body); err != nil {
+		slog.Error("failed to queue notice", "session_id", sessionID, "subject", subject, "error", err)
+	}
+}
+
+func bulletList(items []string) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString("- " + item + "\n")
+	}
+	return b.String()
+}
+
 func (s *server) handleExistingSession(msg *email.IncomingMessage) error {
+	if msg.Body == "" {
+		// Nothing was left after removing the quoted email and signature.
+		slog.Warn("ignoring empty reply", "session_id", msg.SessionID, "message_id", msg.MessageID)
+		return nil
+	}
 	return s.store.EnqueueMessage(&storage.InboxMessage{
 		ID:        uuid.New().String(),
 		SessionID: msg.SessionID,
+		MessageID: msg.MessageID,
 		Body:      msg.Body,
 	})
 }
@@ -219,27 +396,88 @@ func (s *server) checkIdleSessions() error {
 	return nil
 }
 
+// checkWaitingPrompts is the safety net for sessions where Claude waits for
+// input without sending ASK: a prompt that stays on screen across captures
+// is handled as if ASK had been received.
 func (s *server) checkWaitingPrompts() error {
 	sessions, err := s.mgr.ListActive()
 	if err != nil {
 		return err
 	}
 
+	active := make(map[string]bool, len(sessions))
 	for _, sess := range sessions {
 		if sess.Status != "active" {
 			continue
 		}
+		active[sess.ID] = true
 		output, err := s.mgr.CaptureOutput(sess.ID)
 		if err != nil {
 			slog.Warn("failed to capture output", "session_id", sess.ID, "error", err)
 			continue
 		}
-		if session.HasInputPrompt(output) {
+		if prompt, ok := s.prompts.Observe(sess.ID, output); ok {
+			slog.Info("input prompt detected", "session_id", sess.ID, "kind", prompt.Kind)
 			if err := s.mgr.HandleAsk(sess.ID); err != nil {
 				slog.Warn("fallback HandleAsk failed", "session_id", sess.ID, "error", err)
 			}
 		}
 	}
+	s.prompts.Forget(active)
 
 	return nil
 }
+
+// checkSessionLimits ends sessions that outlived their Timeout directive and
+// mails progress for active sessions created with "Notify: progress".
+func (s *server) checkSessionLimits(now time.Time) error {
+	sessions, err := s.mgr.ListActive()
+	if err != nil {
+		return err
+	}
+
+	active := make(map[string]bool, len(sessions))
+	for _, sess := range sessions {
+		active[sess.ID] = true
+		if sess.TimeoutMin > 0 {
+			timeout := time.Duration(sess.TimeoutMin) * time.Minute
+			if now.Sub(sess.CreatedAt) >= timeout {
+				reason := fmt.Sprintf("Session timed out after %s.", timeout)
+				if err := s.mgr.EndWithReport(sess.ID, reason); err != nil {
+					slog.Warn("failed to end timed-out session", "session_id", sess.ID, "error", err)
+				}
+				continue
+			}
+		}
+		if sess.Notify == "progress" && sess.Status == "active" {
+			s.checkProgress(sess, now)
+		}
+	}
+
+	for id := range s.progressSent {
+		if !active[id] {
+			delete(s.progressSent, id)
+		}
+	}
+	return nil
+}
+
+// checkProgress mails a progress snapshot once the session has been active
+// for progressInterval since its turn started or since the last snapshot.
+func (s *server) checkProgress(sess *storage.Session, now time.Time) {
+	since := sess.UpdatedAt
+	if last, ok := s.progressSent[sess.ID]; ok && last.After(since) {
+		since = last
+	}
+	if now.Sub(since) < progressInterval {
+		return
+	}
+	if err := s.mgr.SendProgress(sess.ID); err != nil {
+		slog.Warn("failed to send progress", "session_id", sess.ID, "error", err)
+		return
+	}
+	if s.progressSent == nil {
+		s.progressSent = make(map[string]time.Time)
+	}
+	s.progressSent[sess.ID] = now
+}
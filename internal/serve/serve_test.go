@@ -3,6 +3,7 @@ package serve
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync/atomic"
@@ -13,34 +14,44 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/yhzion/claude-postman/internal/config"
 	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/session"
 	"github.com/yhzion/claude-postman/internal/storage"
 )
 
 // --- Mocks ---
 
 type mockMgr struct {
-	createFn        func(string, string, string) (*storage.Session, error)
+	createFn        func(string, string, string, string, session.CreateOptions) (*storage.Session, error)
 	deliverFn       func(string) error
 	listActiveFn    func() ([]*storage.Session, error)
 	recoverAllFn    func() error
 	handleAskFn     func(string) error
 	captureOutputFn func(string) (string, error)
 	createCalls     []createCall
+	commandCalls    []commandCall
+	removedIDs      []string
 	deliverCalls    []string
 	handleAskCalls  []string
+	endCalls        []string
+	progressCalls   []string
 	recoverCalled   atomic.Bool
 }
 
+type commandCall struct {
+	sessionID, name, arg string
+}
+
 type createCall struct {
 	workingDir string
 	model      string
 	prompt     string
+	opts       session.CreateOptions
 }
 
-func (m *mockMgr) Create(workingDir, model, prompt string) (*storage.Session, error) {
-	m.createCalls = append(m.createCalls, createCall{workingDir, model, prompt})
+func (m *mockMgr) Create(workingDir, model, prompt, sourceMessageID string, opts session.CreateOptions) (*storage.Session, error) {
+	m.createCalls = append(m.createCalls, createCall{workingDir, model, prompt, opts})
 	if m.createFn != nil {
-		return m.createFn(workingDir, model, prompt)
+		return m.createFn(workingDir, model, prompt, sourceMessageID, opts)
 	}
 	return &storage.Session{ID: "test-id", Status: "active"}, nil
 }
@@ -83,12 +94,38 @@ func (m *mockMgr) CaptureOutput(sessionID string) (string, error) {
 	return "", nil
 }
 
+func (m *mockMgr) RunCommand(sessionID, name, arg string) error {
+	m.commandCalls = append(m.commandCalls, commandCall{sessionID, name, arg})
+	return nil
+}
+
+func (m *mockMgr) RemoveTranscripts(sessionIDs []string) int {
+	m.removedIDs = append(m.removedIDs, sessionIDs...)
+	return len(sessionIDs)
+}
+
+func (m *mockMgr) EndWithReport(sessionID, _ string) error {
+	m.endCalls = append(m.endCalls, sessionID)
+	return nil
+}
+
+func (m *mockMgr) SendProgress(sessionID string) error {
+	m.progressCalls = append(m.progressCalls, sessionID)
+	return nil
+}
+
+type sentNotice struct {
+	sessionID, subject, body string
+}
+
 type mockMail struct {
-	pollFn         func() ([]*email.IncomingMessage, error)
-	flushFn        func() error
-	sendTemplateFn func() (string, error)
-	pollCount      atomic.Int32
-	flushCount     atomic.Int32
+	pollFn          func() ([]*email.IncomingMessage, error)
+	flushFn         func() error
+	queueTemplateFn func() (string, error)
+	templateCount   atomic.Int32
+	pollCount       atomic.Int32
+	flushCount      atomic.Int32
+	sent            []sentNotice
 }
 
 func (m *mockMail) Poll() ([]*email.IncomingMessage, error) {
@@ -107,13 +144,23 @@ func (m *mockMail) FlushOutbox() error {
 	return nil
 }
 
-func (m *mockMail) SendTemplate() (string, error) {
-	if m.sendTemplateFn != nil {
-		return m.sendTemplateFn()
+func (m *mockMail) QueueTemplate() (string, error) {
+	m.templateCount.Add(1)
+	if m.queueTemplateFn != nil {
+		return m.queueTemplateFn()
 	}
 	return "<test-template@claude-postman>", nil
 }
 
+func (m *mockMail) Send(sessionID, subject, htmlBody string) error {
+	m.sent = append(m.sent, sentNotice{sessionID, subject, htmlBody})
+	return nil
+}
+
+func (m *mockMail) Resend(string) (string, error) {
+	return "", nil
+}
+
 // --- Helpers ---
 
 func newTestStore(t *testing.T) *storage.Store {
@@ -134,6 +181,11 @@ func newTestServer(t *testing.T) (*server, *mockMgr, *mockMail) {
 		General: config.GeneralConfig{
 			DefaultModel:    "sonnet",
 			PollIntervalSec: 30,
+			RetentionDays:   30,
+		},
+		Email: config.EmailConfig{
+			TemplateLifetimeDays: 30,
+			TemplateRefreshDays:  7,
 		},
 	}
 	s := &server{
@@ -142,6 +194,7 @@ func newTestServer(t *testing.T) (*server, *mockMgr, *mockMail) {
 		mgr:          mgr,
 		mailer:       ml,
 		pollInterval: 50 * time.Millisecond,
+		prompts:      session.NewPromptWatcher(session.DefaultDetector, session.DefaultStableCaptures),
 	}
 	return s, mgr, ml
 }
@@ -177,6 +230,53 @@ func TestRunServe_RecoversOnStart(t *testing.T) {
 	assert.True(t, mgr.recoverCalled.Load(), "RecoverAll should be called on startup")
 }
 
+func TestRunServe_StartsWhenTemplateFails(t *testing.T) {
+	s, _, ml := newTestServer(t)
+	ml.queueTemplateFn = func() (string, error) { return "", errors.New("disk full") }
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.NoError(t, s.run(ctx), "템플릿 실패로 serve가 중단되면 안 됨")
+	assert.Equal(t, int32(1), ml.templateCount.Load())
+}
+
+func TestRunServe_NoTemplate(t *testing.T) {
+	s, _, ml := newTestServer(t)
+	s.opts.NoTemplate = true
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, s.run(ctx))
+	assert.Zero(t, ml.templateCount.Load(), "--no-template이면 템플릿을 보내지 않음")
+}
+
+func TestEnsureTemplate_Threshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		age       time.Duration
+		revoked   bool
+		wantQueue bool
+	}{
+		{"recent valid template", 24 * time.Hour, false, false},
+		{"old valid template", 8 * 24 * time.Hour, false, true},
+		{"recent but revoked", time.Hour, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, ml := newTestServer(t)
+			expires := time.Now().Add(20 * 24 * time.Hour)
+			require.NoError(t, s.store.SaveTemplate(&storage.Template{
+				ID: "t1", MessageID: "<t1@claude-postman>",
+				CreatedAt: time.Now().Add(-tt.age), ExpiresAt: &expires, Revoked: tt.revoked,
+			}))
+
+			s.ensureTemplate()
+
+			assert.Equal(t, tt.wantQueue, ml.templateCount.Load() == 1)
+		})
+	}
+}
+
 func TestProcessMessages_NewSession(t *testing.T) {
 	t.Run("creates session with prompt as CLI argument", func(t *testing.T) {
 		s, mgr, _ := newTestServer(t)
@@ -228,6 +328,123 @@ func TestProcessMessages_NewSession(t *testing.T) {
 	})
 }
 
+func TestProcessMessages_NewSession_Preset(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	newPresetServer := func(t *testing.T) (*server, *mockMgr) {
+		s, mgr, _ := newTestServer(t)
+		s.cfg.Templates = []config.TemplatePreset{{
+			Name: "infra", WorkingDir: "~/src/infra", Model: "opus",
+			SystemPrompt: "Only plan.", PermissionMode: "plan",
+		}}
+		return s, mgr
+	}
+
+	t.Run("preset supplies defaults", func(t *testing.T) {
+		s, mgr := newPresetServer(t)
+		msgs := []*email.IncomingMessage{{IsNewSession: true, Template: "infra", Body: "task"}}
+		require.NoError(t, s.processMessages(msgs))
+
+		require.Len(t, mgr.createCalls, 1)
+		call := mgr.createCalls[0]
+		assert.Equal(t, filepath.Join(home, "src/infra"), call.workingDir)
+		assert.Equal(t, "opus", call.model)
+		assert.Equal(t, session.CreateOptions{SystemPrompt: "Only plan.", PermissionMode: "plan"}, call.opts)
+	})
+
+	t.Run("reply values win over preset", func(t *testing.T) {
+		s, mgr := newPresetServer(t)
+		msgs := []*email.IncomingMessage{{IsNewSession: true, Template: "infra", WorkingDir: "/tmp", Model: "haiku", Body: "task"}}
+		require.NoError(t, s.processMessages(msgs))
+
+		require.Len(t, mgr.createCalls, 1)
+		assert.Equal(t, "/tmp", mgr.createCalls[0].workingDir)
+		assert.Equal(t, "haiku", mgr.createCalls[0].model)
+		assert.Equal(t, "plan", mgr.createCalls[0].opts.PermissionMode, "권한 모드는 항상 프리셋을 따름")
+	})
+
+	t.Run("unknown preset falls back to defaults", func(t *testing.T) {
+		s, mgr := newPresetServer(t)
+		msgs := []*email.IncomingMessage{{IsNewSession: true, Template: "removed", Body: "task"}}
+		require.NoError(t, s.processMessages(msgs))
+
+		require.Len(t, mgr.createCalls, 1)
+		assert.Equal(t, home, mgr.createCalls[0].workingDir)
+		assert.Equal(t, "sonnet", mgr.createCalls[0].model)
+		assert.Empty(t, mgr.createCalls[0].opts)
+	})
+}
+
+func TestProcessMessages_NewSession_Directives(t *testing.T) {
+	t.Run("directives become create options", func(t *testing.T) {
+		s, mgr, ml := newTestServer(t)
+		s.cfg.Templates = []config.TemplatePreset{{Name: "infra", PermissionMode: "plan"}}
+		msgs := []*email.IncomingMessage{{
+			IsNewSession: true, Template: "infra", WorkingDir: "/tmp", Body: "task",
+			Directives: email.Directives{
+				Name: "Login bug", Branch: "fix/login", Timeout: time.Hour, Effort: "high",
+				Mode: "acceptEdits", Notify: "progress", CC: []string{"a@example.com"}, Budget: 3,
+			},
+		}}
+		require.NoError(t, s.processMessages(msgs))
+
+		require.Len(t, mgr.createCalls, 1)
+		assert.Equal(t, session.CreateOptions{
+			PermissionMode: "acceptEdits",
+			Name:           "Login bug",
+			Branch:         "fix/login",
+			Timeout:        time.Hour,
+			Effort:         "high",
+			Notify:         "progress",
+			CC:             []string{"a@example.com"},
+			BudgetUSD:      3,
+		}, mgr.createCalls[0].opts, "Mode 지시어가 프리셋의 권한 모드보다 우선")
+		assert.Empty(t, ml.sent)
+	})
+
+	t.Run("invalid directives are reported, not started", func(t *testing.T) {
+		s, mgr, ml := newTestServer(t)
+		msgs := []*email.IncomingMessage{{
+			IsNewSession: true, WorkingDir: "/tmp", Body: "task",
+			DirectiveErrors: []string{"Effort: must be one of low, medium, high"},
+		}}
+		require.NoError(t, s.processMessages(msgs))
+
+		assert.Empty(t, mgr.createCalls)
+		require.Len(t, ml.sent, 1)
+		assert.Empty(t, ml.sent[0].sessionID)
+		assert.Contains(t, ml.sent[0].body, "Effort: must be one of")
+	})
+
+	t.Run("create failure is reported", func(t *testing.T) {
+		s, mgr, ml := newTestServer(t)
+		mgr.createFn = func(string, string, string, string, session.CreateOptions) (*storage.Session, error) {
+			return nil, errors.New("branch fix/login: /tmp is not a git repository")
+		}
+		msgs := []*email.IncomingMessage{{IsNewSession: true, WorkingDir: "/tmp", Body: "task"}}
+		require.NoError(t, s.processMessages(msgs))
+
+		require.Len(t, ml.sent, 1)
+		assert.Contains(t, ml.sent[0].body, "not a git repository")
+	})
+
+	t.Run("unknown directives are reported, not started", func(t *testing.T) {
+		s, mgr, ml := newTestServer(t)
+		msgs := []*email.IncomingMessage{{
+			IsNewSession: true, WorkingDir: "/tmp", Body: "task",
+			Directives:        email.Directives{Branch: "x"},
+			UnknownDirectives: []string{"Mdoe"},
+		}}
+		require.NoError(t, s.processMessages(msgs))
+
+		assert.Empty(t, mgr.createCalls, "적용하지 못한 지시어가 있으면 권한 제한 없이 시작하지 않음")
+		require.Len(t, ml.sent, 1)
+		assert.Empty(t, ml.sent[0].sessionID)
+		assert.Contains(t, ml.sent[0].body, "Mdoe: not a known directive")
+	})
+}
+
 func TestProcessMessages_NewSession_TildeExpansion(t *testing.T) {
 	home, err := os.UserHomeDir()
 	require.NoError(t, err)
@@ -286,6 +503,40 @@ func TestProcessMessages_ExistingSession(t *testing.T) {
 	assert.Equal(t, "Continue working", msg.Body)
 }
 
+func TestProcessMessages_EmptyReply(t *testing.T) {
+	s, _, _ := newTestServer(t)
+
+	sessionID := "existing-session-003"
+	insertSession(t, s.store, sessionID, "active")
+
+	msgs := []*email.IncomingMessage{{SessionID: sessionID, Body: ""}}
+	require.NoError(t, s.processMessages(msgs))
+
+	msg, err := s.store.DequeueMessage(sessionID)
+	require.NoError(t, err)
+	assert.Nil(t, msg, "인용문만 있는 답장은 전달하지 않음")
+}
+
+func TestProcessMessages_Command(t *testing.T) {
+	s, mgr, _ := newTestServer(t)
+
+	sessionID := "existing-session-002"
+	insertSession(t, s.store, sessionID, "idle")
+
+	msgs := []*email.IncomingMessage{
+		{SessionID: sessionID, Body: "/commit \"fix\"", Command: "commit", CommandArg: "fix"},
+	}
+	require.NoError(t, s.processMessages(msgs))
+
+	require.Len(t, mgr.commandCalls, 1)
+	assert.Equal(t, commandCall{sessionID, "commit", "fix"}, mgr.commandCalls[0])
+
+	// Commands are not forwarded to Claude
+	msg, err := s.store.DequeueMessage(sessionID)
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+}
+
 func TestPollLoop_ContinuesOnError(t *testing.T) {
 	s, _, ml := newTestServer(t)
 
@@ -360,14 +611,36 @@ func TestCheckWaitingPrompts_DetectsPrompt(t *testing.T) {
 		}, nil
 	}
 	mgr.captureOutputFn = func(_ string) (string, error) {
-		return "Choose:\n1. A\n2. B\n❯ ", nil
+		return "Which database?\n❯ 1. Postgres\n  2. SQLite\n", nil
 	}
 
-	err := s.checkWaitingPrompts()
-	require.NoError(t, err)
+	require.NoError(t, s.checkWaitingPrompts())
+	assert.Empty(t, mgr.handleAskCalls, "한 번의 캡처로는 판단하지 않음")
 
-	assert.Len(t, mgr.handleAskCalls, 1)
+	require.NoError(t, s.checkWaitingPrompts())
+	require.Len(t, mgr.handleAskCalls, 1)
 	assert.Equal(t, "active-1", mgr.handleAskCalls[0])
+
+	require.NoError(t, s.checkWaitingPrompts())
+	assert.Len(t, mgr.handleAskCalls, 1, "같은 화면은 한 번만 처리")
+}
+
+func TestCheckWaitingPrompts_ChangingScreen(t *testing.T) {
+	s, mgr, _ := newTestServer(t)
+
+	mgr.listActiveFn = func() ([]*storage.Session, error) {
+		return []*storage.Session{{ID: "active-1", Status: "active"}}, nil
+	}
+	n := 0
+	mgr.captureOutputFn = func(_ string) (string, error) {
+		n++
+		return fmt.Sprintf("Continue? [y/N]\nprocessing item %d", n), nil
+	}
+
+	for range 3 {
+		require.NoError(t, s.checkWaitingPrompts())
+	}
+	assert.Empty(t, mgr.handleAskCalls, "출력이 계속 바뀌면 대기 상태가 아님")
 }
 
 func TestCheckWaitingPrompts_SkipsNonActive(t *testing.T) {
@@ -403,3 +676,62 @@ func TestCheckWaitingPrompts_NoPromptNoAction(t *testing.T) {
 
 	assert.Empty(t, mgr.handleAskCalls)
 }
+
+func TestCheckSessionLimits_Timeout(t *testing.T) {
+	s, mgr, _ := newTestServer(t)
+	now := time.Now()
+
+	mgr.listActiveFn = func() ([]*storage.Session, error) {
+		return []*storage.Session{
+			{ID: "expired", Status: "active", TimeoutMin: 60, CreatedAt: now.Add(-61 * time.Minute)},
+			{ID: "fresh", Status: "idle", TimeoutMin: 60, CreatedAt: now.Add(-10 * time.Minute)},
+			{ID: "unlimited", Status: "idle", CreatedAt: now.Add(-48 * time.Hour)},
+		}, nil
+	}
+
+	require.NoError(t, s.checkSessionLimits(now))
+	assert.Equal(t, []string{"expired"}, mgr.endCalls)
+}
+
+func TestCheckSessionLimits_Progress(t *testing.T) {
+	s, mgr, _ := newTestServer(t)
+	start := time.Now()
+
+	sessions := []*storage.Session{
+		{ID: "progress-1", Status: "active", Notify: "progress", UpdatedAt: start},
+		{ID: "quiet", Status: "active", UpdatedAt: start},
+	}
+	mgr.listActiveFn = func() ([]*storage.Session, error) { return sessions, nil }
+
+	require.NoError(t, s.checkSessionLimits(start.Add(5*time.Minute)))
+	assert.Empty(t, mgr.progressCalls, "10분이 지나기 전에는 보내지 않음")
+
+	require.NoError(t, s.checkSessionLimits(start.Add(11*time.Minute)))
+	assert.Equal(t, []string{"progress-1"}, mgr.progressCalls)
+
+	require.NoError(t, s.checkSessionLimits(start.Add(15*time.Minute)))
+	assert.Len(t, mgr.progressCalls, 1, "마지막 전송 이후 10분 간격")
+
+	require.NoError(t, s.checkSessionLimits(start.Add(22*time.Minute)))
+	assert.Len(t, mgr.progressCalls, 2)
+
+	sessions = nil
+	require.NoError(t, s.checkSessionLimits(start.Add(23*time.Minute)))
+	assert.Empty(t, s.progressSent, "종료된 세션 기록은 정리")
+}
+
+func TestMessageKind(t *testing.T) {
+	tests := []struct {
+		msg  *email.IncomingMessage
+		want string
+	}{
+		{&email.IncomingMessage{IsNewSession: true, Body: "hi"}, "new_session"},
+		{&email.IncomingMessage{SessionID: "s", Command: "commit"}, "command"},
+		{&email.IncomingMessage{SessionID: "s"}, "empty_reply"},
+		{&email.IncomingMessage{SessionID: "s", Body: "next"}, "reply"},
+		{&email.IncomingMessage{Body: "who?"}, "unmatched"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, messageKind(tt.msg))
+	}
+}
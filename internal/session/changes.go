@@ -0,0 +1,133 @@
+package session
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/git"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// maxInlinePatchLines limits how much of the patch is embedded in the email body.
+// The full patch is always attached.
+const maxInlinePatchLines = 300
+
+func (m *Manager) baseFilePath(sessionID string) string {
+	return filepath.Join(m.fifoDir, sessionID+".base")
+}
+
+func (m *Manager) removeBaseFile(sessionID string) {
+	_ = os.Remove(m.baseFilePath(sessionID))
+}
+
+// markTurnStart records the commit HEAD points to when a turn starts,
+// so the result email can show what changed during that turn.
+// Working directories that are not git repositories are ignored.
+func (m *Manager) markTurnStart(session *storage.Session) {
+	if !git.IsRepo(session.WorkingDir) {
+		return
+	}
+	head, err := git.Head(session.WorkingDir)
+	if err != nil {
+		slog.Warn("failed to read HEAD", "session_id", session.ID, "error", err)
+		return
+	}
+	if err := os.WriteFile(m.baseFilePath(session.ID), []byte(head), 0o600); err != nil {
+		slog.Warn("failed to record turn base commit", "session_id", session.ID, "error", err)
+	}
+}
+
+// collectChanges summarizes the working directory changes since the turn started.
+// Returns nil if the working directory is not a git repository or nothing changed.
+// Falls back to the current HEAD when no base commit was recorded (e.g. after recovery).
+func (m *Manager) collectChanges(session *storage.Session) *git.Summary {
+	if !git.IsRepo(session.WorkingDir) {
+		return nil
+	}
+
+	var base string
+	if b, err := os.ReadFile(m.baseFilePath(session.ID)); err == nil {
+		base = strings.TrimSpace(string(b))
+	} else {
+		head, headErr := git.Head(session.WorkingDir)
+		if headErr != nil {
+			slog.Warn("failed to read HEAD", "session_id", session.ID, "error", headErr)
+			return nil
+		}
+		base = head
+	}
+
+	summary, err := git.Summarize(session.WorkingDir, base)
+	if err != nil {
+		slog.Warn("failed to summarize changes", "session_id", session.ID, "error", err)
+		return nil
+	}
+	if summary.Empty() {
+		return nil
+	}
+	return summary
+}
+
+// changesMarkdown renders a change summary as Markdown appended to the result email.
+// Diff blocks are fenced as "diff" so the highlighter colors them.
+func changesMarkdown(s *git.Summary) string {
+	var b strings.Builder
+	b.WriteString("\n\n---\n\n## Changes\n\n")
+	if s.Base != "" {
+		fmt.Fprintf(&b, "Compared to commit `%s`.\n\n", shortHash(s.Base))
+	}
+	if s.Status != "" {
+		b.WriteString("**git status**\n\n")
+		writeFenced(&b, "text", s.Status)
+	}
+	if s.DiffStat != "" {
+		b.WriteString("**diffstat**\n\n")
+		writeFenced(&b, "text", s.DiffStat)
+	}
+	if s.Patch != "" {
+		lines := strings.Split(strings.TrimRight(s.Patch, "\n"), "\n")
+		truncated := len(lines) > maxInlinePatchLines
+		if truncated {
+			lines = lines[:maxInlinePatchLines]
+		}
+		writeFenced(&b, "diff", strings.Join(lines, "\n"))
+		if truncated {
+			fmt.Fprintf(&b, "_Patch truncated to %d lines — the full patch is attached._\n\n", maxInlinePatchLines)
+		}
+	}
+	b.WriteString(replyCommandsHelp)
+	return b.String()
+}
+
+// changesAttachments returns the full patch as an email attachment.
+func changesAttachments(sessionID string, s *git.Summary) []email.Attachment {
+	if s == nil || s.Patch == "" {
+		return nil
+	}
+	return []email.Attachment{{
+		Filename:    shortHash(sessionID) + ".patch",
+		ContentType: "text/x-diff",
+		Data:        []byte(s.Patch),
+	}}
+}
+
+// writeFenced writes content as a fenced code block, using a fence longer
+// than any backtick run inside the content.
+func writeFenced(b *strings.Builder, lang, content string) {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, lang, content, fence)
+}
+
+func shortHash(s string) string {
+	if len(s) > 8 {
+		return s[:8]
+	}
+	return s
+}
@@ -0,0 +1,171 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Option is one numbered choice of a menu prompt.
+type Option struct {
+	Number      int
+	Label       string
+	Description string // indented help text below the option, if any
+	Selected    bool   // highlighted with ❯
+}
+
+// optionPartsRe splits a menu option line into selector, number and label.
+var optionPartsRe = regexp.MustCompile(`^(❯\s*)?(\d+)\.\s+(.+)$`)
+
+// Options returns the numbered choices of p and the question shown above
+// them. Options is empty for prompts that are not menus, such as [y/N]
+// confirmations.
+func (p Prompt) Options() (question string, options []Option) {
+	first, last := -1, -1
+	for i, l := range p.Lines {
+		if optionRe.MatchString(l) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return "", nil
+	}
+	if first > 0 {
+		question = p.Lines[first-1]
+	}
+
+	for _, l := range p.Lines[first : last+1] {
+		m := optionPartsRe.FindStringSubmatch(l)
+		if m == nil {
+			if n := len(options); n > 0 {
+				options[n-1].Description = strings.TrimSpace(options[n-1].Description + " " + l)
+			}
+			continue
+		}
+		number, _ := strconv.Atoi(m[2])
+		options = append(options, Option{Number: number, Label: m[3], Selected: m[1] != ""})
+	}
+	return question, options
+}
+
+// choicesMarkdown describes how to answer p by email, or returns "" when
+// the prompt has no fixed answers.
+func choicesMarkdown(p Prompt) string {
+	if p.Kind == PromptConfirm {
+		return "\n\n---\n\n**Reply `yes` or `no` to answer:** " + p.Lines[len(p.Lines)-1] + "\n"
+	}
+	question, options := p.Options()
+	if len(options) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\n---\n\n")
+	if question != "" {
+		fmt.Fprintf(&b, "**%s**\n\n", question)
+	}
+	for _, o := range options {
+		fmt.Fprintf(&b, "%d. %s", o.Number, o.Label)
+		if o.Description != "" {
+			fmt.Fprintf(&b, " — %s", o.Description)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nReply with the number of your choice")
+	if p.Kind == PromptPermission || p.Kind == PromptPlan {
+		b.WriteString(", `yes` or `no`")
+	}
+	b.WriteString(".\n")
+	return b.String()
+}
+
+var (
+	yesReplies = []string{"yes", "y", "approve", "approved", "ok", "accept", "proceed"}
+	noReplies  = []string{"no", "n", "reject", "deny", "cancel"}
+)
+
+// choiceKeys maps an email reply to the keys that answer p: the number of
+// an option, an option's label, or yes/no style words. Only the first line
+// of the reply counts and it must match exactly (ignoring case and trailing
+// punctuation); anything else is not a choice and is typed as text.
+func choiceKeys(p Prompt, reply string) ([]Key, bool) {
+	answer := firstLine(reply)
+	yes, no := slices.Contains(yesReplies, answer), slices.Contains(noReplies, answer)
+
+	if p.Kind == PromptConfirm {
+		switch {
+		case yes:
+			return []Key{Text("y"), KeyEnter}, true
+		case no:
+			return []Key{Text("n"), KeyEnter}, true
+		}
+		return nil, false
+	}
+
+	_, options := p.Options()
+	if len(options) == 0 {
+		return nil, false
+	}
+	target := -1
+	if n, err := strconv.Atoi(answer); err == nil {
+		target = optionIndex(options, func(o Option) bool { return o.Number == n })
+	} else {
+		target = optionIndex(options, func(o Option) bool { return strings.EqualFold(o.Label, answer) })
+		if target < 0 && yes {
+			target = optionIndex(options, func(o Option) bool { return hasWordPrefix(o.Label, "yes") })
+		}
+		if target < 0 && no {
+			target = optionIndex(options, func(o Option) bool { return hasWordPrefix(o.Label, "no") })
+		}
+	}
+	if target < 0 {
+		return nil, false
+	}
+
+	current := optionIndex(options, func(o Option) bool { return o.Selected })
+	if current < 0 {
+		current = 0
+	}
+	var keys []Key
+	for ; current < target; current++ {
+		keys = append(keys, KeyDown)
+	}
+	for ; current > target; current-- {
+		keys = append(keys, KeyUp)
+	}
+	return append(keys, KeyEnter), true
+}
+
+func firstLine(s string) string {
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			return strings.ToLower(strings.TrimRight(l, ".!"))
+		}
+	}
+	return ""
+}
+
+func optionIndex(options []Option, match func(Option) bool) int {
+	for i, o := range options {
+		if match(o) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasWordPrefix reports whether label starts with word followed by a
+// non-letter, so "No, keep planning" has the prefix "no" but "None" does not.
+func hasWordPrefix(label, word string) bool {
+	if len(label) < len(word) || !strings.EqualFold(label[:len(word)], word) {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(label[len(word):])
+	return next == utf8.RuneError || !unicode.IsLetter(next)
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func detectFixture(t *testing.T, name string) Prompt {
+	t.Helper()
+	screen, err := os.ReadFile(filepath.Join("testdata", "panes", name+".txt"))
+	require.NoError(t, err)
+	p, ok := DefaultDetector.Detect(string(screen))
+	require.True(t, ok)
+	return p
+}
+
+func TestPromptOptions(t *testing.T) {
+	t.Run("permission dialog", func(t *testing.T) {
+		question, options := detectFixture(t, "permission_bash").Options()
+		assert.Equal(t, "Do you want to proceed?", question)
+		require.Len(t, options, 3)
+		assert.Equal(t, Option{Number: 1, Label: "Yes", Selected: true}, options[0])
+		assert.Equal(t, "No, and tell Claude what to do differently (esc)", options[2].Label)
+	})
+
+	t.Run("descriptions belong to the option above", func(t *testing.T) {
+		question, options := detectFixture(t, "menu_ask_user_question").Options()
+		assert.Equal(t, "Which backend should the session store use?", question)
+		require.Len(t, options, 3)
+		assert.Equal(t, "SQLite", options[0].Label)
+		assert.Equal(t, "Single file, already a dependency", options[0].Description)
+	})
+
+	t.Run("confirmations have no options", func(t *testing.T) {
+		_, options := detectFixture(t, "confirm_apt").Options()
+		assert.Empty(t, options)
+	})
+}
+
+func TestChoiceKeys(t *testing.T) {
+	tests := []struct {
+		fixture, reply string
+		want           []Key
+	}{
+		{"permission_bash", "1", []Key{KeyEnter}},
+		{"permission_bash", "2", []Key{KeyDown, KeyEnter}},
+		{"permission_bash", "Yes!", []Key{KeyEnter}},
+		{"permission_bash", "no", []Key{KeyDown, KeyDown, KeyEnter}},
+		{"plan_approval", "approve", []Key{KeyEnter}},
+		{"plan_approval", "reject", []Key{KeyDown, KeyDown, KeyEnter}},
+		{"menu_model", "1", []Key{KeyUp, KeyEnter}},
+		{"menu_ask_user_question", "postgres", []Key{KeyDown, KeyEnter}},
+		{"confirm_apt", "y", []Key{Text("y"), KeyEnter}},
+		{"confirm_shell", "No.", []Key{Text("n"), KeyEnter}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture+"/"+tt.reply, func(t *testing.T) {
+			keys, ok := choiceKeys(detectFixture(t, tt.fixture), tt.reply)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, keys)
+		})
+	}
+
+	t.Run("other replies are typed as text", func(t *testing.T) {
+		for _, reply := range []string{"7", "yes, but only in the build dir", "Use Redis instead"} {
+			_, ok := choiceKeys(detectFixture(t, "permission_bash"), reply)
+			assert.False(t, ok, reply)
+		}
+		_, ok := choiceKeys(detectFixture(t, "confirm_apt"), "2")
+		assert.False(t, ok)
+	})
+}
+
+func TestHasWordPrefix(t *testing.T) {
+	assert.True(t, hasWordPrefix("No, keep planning", "no"))
+	assert.True(t, hasWordPrefix("No", "no"))
+	assert.False(t, hasWordPrefix("None of these", "no"))
+}
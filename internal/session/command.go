@@ -0,0 +1,114 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yhzion/claude-postman/internal/git"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+var (
+	ErrNotGitRepo     = errors.New("working directory is not a git repository")
+	ErrSessionBusy    = errors.New("session is busy; wait for the result email before running commands")
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrNotPaused      = errors.New("session is not paused")
+)
+
+// replyCommandsHelp is appended to result emails with changes.
+const replyCommandsHelp = "Reply with one of these commands to review the changes:\n\n" +
+	"- `/commit \"message\"` — commit all changes\n" +
+	"- `/push` — push the current branch to origin\n" +
+	"- `/revert` — discard the changes of this turn (uncommitted work is stashed)\n" +
+	"- `/pr` — open a pull request with the GitHub CLI\n"
+
+// RunCommand executes a reply command and queues an email reporting the outcome.
+// /commit, /push, /revert and /pr act on the session's working directory;
+// /continue resumes a session paused by the budget; /search looks up past
+// sessions and turns.
+// Commands run in the relay itself, never through Claude, and git commands
+// only while the session is idle or waiting so they cannot race with a running turn.
+func (m *Manager) RunCommand(sessionID, name, arg string) error {
+	session, err := m.store.GetSession(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	var report string
+	switch name {
+	case "continue":
+		output, cmdErr := m.resume(session)
+		report = commandReport(name, output, cmdErr)
+	case "search":
+		report = m.searchReport(arg)
+	default:
+		output, cmdErr := m.runGitCommand(session, name, arg)
+		report = commandReport(name, output, cmdErr)
+	}
+	return m.store.CreateOutbox(sessionOutbox(session, "/"+name, report))
+}
+
+func (m *Manager) runGitCommand(session *storage.Session, name, arg string) (string, error) {
+	if session.Status != "idle" && session.Status != "waiting" {
+		return "", ErrSessionBusy
+	}
+	if !git.IsRepo(session.WorkingDir) {
+		return "", ErrNotGitRepo
+	}
+
+	switch name {
+	case "commit":
+		if arg == "" {
+			arg = "claude-postman: session " + shortHash(session.ID)
+		}
+		return git.Commit(session.WorkingDir, arg)
+	case "push":
+		return git.Push(session.WorkingDir)
+	case "revert":
+		base := ""
+		if b, err := os.ReadFile(m.baseFilePath(session.ID)); err == nil {
+			base = strings.TrimSpace(string(b))
+		}
+		return git.Revert(session.WorkingDir, base)
+	case "pr":
+		return git.CreatePR(session.WorkingDir)
+	default:
+		return "", fmt.Errorf("%w: /%s", ErrUnknownCommand, name)
+	}
+}
+
+// resume confirms spending past the budget and makes a paused session idle
+// again, so queued messages are delivered on the next poll.
+func (m *Manager) resume(session *storage.Session) (string, error) {
+	if session.Status != "paused" {
+		return "", ErrNotPaused
+	}
+	usage, err := m.store.SessionUsage(session.ID)
+	if err != nil {
+		return "", err
+	}
+	session.BudgetAckUSD = usage.CostUSD
+	session.Status = "idle"
+	if err := m.store.UpdateSession(session); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Session resumed at %s spent. Queued messages will be delivered shortly.",
+		formatUSD(usage.CostUSD)), nil
+}
+
+// commandReport renders the outcome of a reply command as Markdown.
+func commandReport(name, output string, err error) string {
+	var b strings.Builder
+	if err != nil {
+		fmt.Fprintf(&b, "## `/%s` failed\n\n", name)
+		writeFenced(&b, "text", err.Error())
+	} else {
+		fmt.Fprintf(&b, "## `/%s` succeeded\n\n", name)
+	}
+	if out := strings.TrimSpace(output); out != "" {
+		writeFenced(&b, "text", out)
+	}
+	return b.String()
+}
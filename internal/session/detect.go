@@ -1,23 +1,192 @@
 package session
 
-import "strings"
-
-// HasInputPrompt checks the last 5 lines of tmux output for Claude Code's
-// input prompt indicator (❯). Returns true if Claude Code is waiting for input.
-func HasInputPrompt(output string) bool {
-	output = strings.TrimRight(output, " \t\n")
-	if output == "" {
-		return false
-	}
-	lines := strings.Split(output, "\n")
-	start := len(lines) - 5
-	if start < 0 {
-		start = 0
-	}
-	for i := start; i < len(lines); i++ {
-		if strings.Contains(lines[i], "❯") {
+import (
+	"regexp"
+	"strings"
+)
+
+// Prompt kinds reported by the default detector.
+const (
+	PromptPermission = "permission" // tool permission dialog ("Do you want to proceed?")
+	PromptPlan       = "plan"       // plan mode approval ("Would you like to proceed?")
+	PromptMenu       = "menu"       // numbered selection menu (AskUserQuestion and others)
+	PromptConfirm    = "confirm"    // shell-style [y/N] question
+)
+
+// Prompt is an input request found on a session's screen.
+type Prompt struct {
+	Kind string
+	// Lines are the cleaned bottom lines of the screen the prompt was found in.
+	Lines []string
+}
+
+// Detector finds input prompts in a captured tmux pane.
+type Detector interface {
+	Detect(screen string) (Prompt, bool)
+}
+
+// Rule recognizes one kind of prompt in the bottom lines of a screen,
+// as returned by screenTail.
+type Rule struct {
+	Kind  string
+	Match func(tail []string) bool
+}
+
+// RuleDetector reports the first rule that matches.
+type RuleDetector []Rule
+
+// DefaultDetector recognizes Claude Code's dialogs and shell confirmations.
+// Plan approval comes before the generic rules that would also match it.
+var DefaultDetector = RuleDetector{
+	{Kind: PromptPlan, Match: isPlanApproval},
+	{Kind: PromptPermission, Match: isPermissionDialog},
+	{Kind: PromptMenu, Match: isMenu},
+	{Kind: PromptConfirm, Match: isConfirm},
+}
+
+// Detect implements Detector.
+func (d RuleDetector) Detect(screen string) (Prompt, bool) {
+	tail := screenTail(screen, tailLines)
+	if len(tail) == 0 {
+		return Prompt{}, false
+	}
+	for _, r := range d {
+		if r.Match(tail) {
+			return Prompt{Kind: r.Kind, Lines: tail}, true
+		}
+	}
+	return Prompt{}, false
+}
+
+// tailLines is how many non-empty lines at the bottom of the screen rules
+// look at. Claude Code draws its dialogs at the bottom of the pane.
+const tailLines = 20
+
+var (
+	// selectorRe matches the highlighted option of a menu: "❯ 1. Yes".
+	selectorRe = regexp.MustCompile(`^❯\s*\d+\.\s+\S`)
+	// optionRe matches any option of a menu, highlighted or not.
+	optionRe     = regexp.MustCompile(`^(❯\s*)?\d+\.\s+\S`)
+	permissionRe = regexp.MustCompile(`^Do you want to .+\?$`)
+	confirmRe    = regexp.MustCompile(`(?i)(\[y/n\]|\(y/n\)|\[yes/no\]|\(yes/no\))\s*[:?]?$`)
+)
+
+// screenTail returns the last n non-empty lines of screen with dialog
+// borders and surrounding whitespace removed.
+func screenTail(screen string, n int) []string {
+	var lines []string
+	for _, l := range strings.Split(screen, "\n") {
+		l = strings.TrimSpace(l)
+		l = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(l, "│"), "│"))
+		if l == "" || strings.Trim(l, "╭╮╰╯─") == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// menuOptions reports whether tail ends with a numbered menu: a highlighted
+// option, at least one other option, and no more than footerLines lines of
+// help text after the last option.
+func menuOptions(tail []string) bool {
+	const footerLines = 3
+	selected, options, last := false, 0, -1
+	for i, l := range tail {
+		if optionRe.MatchString(l) {
+			options++
+			last = i
+			if selectorRe.MatchString(l) {
+				selected = true
+			}
+		}
+	}
+	return selected && options >= 2 && len(tail)-1-last <= footerLines
+}
+
+func containsLine(tail []string, match func(string) bool) bool {
+	for _, l := range tail {
+		if match(l) {
 			return true
 		}
 	}
 	return false
 }
+
+func isPlanApproval(tail []string) bool {
+	return menuOptions(tail) &&
+		containsLine(tail, func(l string) bool { return strings.HasPrefix(l, "Would you like to proceed?") }) &&
+		containsLine(tail, func(l string) bool { return strings.Contains(l, "keep planning") })
+}
+
+func isPermissionDialog(tail []string) bool {
+	return menuOptions(tail) && containsLine(tail, permissionRe.MatchString)
+}
+
+func isMenu(tail []string) bool {
+	return menuOptions(tail)
+}
+
+func isConfirm(tail []string) bool {
+	return confirmRe.MatchString(tail[len(tail)-1])
+}
+
+// DefaultStableCaptures is how many consecutive identical captures showing
+// a prompt are required before it is reported.
+const DefaultStableCaptures = 2
+
+// PromptWatcher tracks captures of each session and reports a prompt only
+// once the screen showing it has stayed the same for Stable captures, so
+// output that scrolls past a prompt-like line does not count. Each stable
+// screen is reported once. Not safe for concurrent use.
+type PromptWatcher struct {
+	Detector Detector
+	Stable   int
+
+	seen map[string]*observation
+}
+
+type observation struct {
+	screen   string
+	count    int
+	reported bool
+}
+
+// NewPromptWatcher returns a watcher using d that requires stable identical
+// captures before reporting.
+func NewPromptWatcher(d Detector, stable int) *PromptWatcher {
+	return &PromptWatcher{Detector: d, Stable: stable, seen: make(map[string]*observation)}
+}
+
+// Observe records a capture of sessionID's pane and returns the prompt on
+// it once it has been stable long enough.
+func (w *PromptWatcher) Observe(sessionID, screen string) (Prompt, bool) {
+	screen = strings.TrimRight(screen, " \t\n")
+	obs := w.seen[sessionID]
+	if obs == nil || obs.screen != screen {
+		obs = &observation{screen: screen}
+		w.seen[sessionID] = obs
+	}
+	obs.count++
+
+	if obs.reported || obs.count < w.Stable {
+		return Prompt{}, false
+	}
+	p, ok := w.Detector.Detect(screen)
+	if ok {
+		obs.reported = true
+	}
+	return p, ok
+}
+
+// Forget drops the state of sessions not in keep, e.g. ended ones.
+func (w *PromptWatcher) Forget(keep map[string]bool) {
+	for id := range w.seen {
+		if !keep[id] {
+			delete(w.seen, id)
+		}
+	}
+}
@@ -1,42 +1,88 @@
 package session
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestHasInputPrompt(t *testing.T) {
-	t.Run("detects prompt on last line", func(t *testing.T) {
-		output := "Some output\n❯ "
-		assert.True(t, HasInputPrompt(output))
-	})
+// TestDefaultDetector_Corpus runs the detector over captured panes in
+// testdata/panes. The file name prefix is the expected prompt kind;
+// "none" means no prompt must be detected.
+func TestDefaultDetector_Corpus(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "panes", "*.txt"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
 
-	t.Run("detects prompt within last 5 lines", func(t *testing.T) {
-		output := "line1\nline2\nline3\n❯ \n\n"
-		assert.True(t, HasInputPrompt(output))
-	})
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(name, func(t *testing.T) {
+			screen, err := os.ReadFile(file)
+			require.NoError(t, err)
 
-	t.Run("no prompt when thinking", func(t *testing.T) {
-		output := "● Thinking about the problem...\n  Still working..."
-		assert.False(t, HasInputPrompt(output))
-	})
+			want, _, _ := strings.Cut(name, "_")
+			prompt, ok := DefaultDetector.Detect(string(screen))
+			if want == "none" {
+				assert.False(t, ok, "오탐: %s", prompt.Kind)
+				return
+			}
+			require.True(t, ok, "프롬프트를 감지해야 함")
+			assert.Equal(t, want, prompt.Kind)
+		})
+	}
+}
+
+func TestDefaultDetector_Empty(t *testing.T) {
+	_, ok := DefaultDetector.Detect("")
+	assert.False(t, ok)
+	_, ok = DefaultDetector.Detect("   \n  \n  ")
+	assert.False(t, ok)
+}
+
+func TestPromptWatcher(t *testing.T) {
+	const menu = "Pick one\n❯ 1. A\n  2. B\n"
+
+	t.Run("requires stable captures", func(t *testing.T) {
+		w := NewPromptWatcher(DefaultDetector, 3)
+		for i := range 2 {
+			_, ok := w.Observe("s1", menu)
+			assert.False(t, ok, "capture %d", i+1)
+		}
+		prompt, ok := w.Observe("s1", menu)
+		require.True(t, ok)
+		assert.Equal(t, PromptMenu, prompt.Kind)
 
-	t.Run("no prompt in middle of output", func(t *testing.T) {
-		output := "❯ old prompt\nNow doing work\nMore output\nStill going\nAlmost done\nFinishing up"
-		assert.False(t, HasInputPrompt(output))
+		_, ok = w.Observe("s1", menu)
+		assert.False(t, ok, "같은 화면은 한 번만 보고")
 	})
 
-	t.Run("empty output returns false", func(t *testing.T) {
-		assert.False(t, HasInputPrompt(""))
+	t.Run("changed screen restarts the count", func(t *testing.T) {
+		w := NewPromptWatcher(DefaultDetector, 2)
+		w.Observe("s1", menu)
+		_, ok := w.Observe("s1", "working…")
+		assert.False(t, ok)
+		_, ok = w.Observe("s1", menu)
+		assert.False(t, ok)
+		_, ok = w.Observe("s1", menu)
+		assert.True(t, ok)
 	})
 
-	t.Run("only whitespace returns false", func(t *testing.T) {
-		assert.False(t, HasInputPrompt("   \n  \n  "))
+	t.Run("sessions are tracked separately", func(t *testing.T) {
+		w := NewPromptWatcher(DefaultDetector, 2)
+		w.Observe("s1", menu)
+		_, ok := w.Observe("s2", menu)
+		assert.False(t, ok)
 	})
 
-	t.Run("detects prompt with text after it", func(t *testing.T) {
-		output := "Choose a project:\n1. A\n2. B\n❯ waiting for input"
-		assert.True(t, HasInputPrompt(output))
+	t.Run("forget drops state", func(t *testing.T) {
+		w := NewPromptWatcher(DefaultDetector, 2)
+		w.Observe("s1", menu)
+		w.Forget(map[string]bool{})
+		_, ok := w.Observe("s1", menu)
+		assert.False(t, ok)
 	})
 }
@@ -12,7 +12,9 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/git"
+	"github.com/yhzion/claude-postman/internal/metrics"
 	"github.com/yhzion/claude-postman/internal/storage"
 )
 
@@ -48,24 +50,42 @@ func (m *Manager) writeSentinel(sessionID string) {
 }
 
 // handleDoneTx runs the transactional part of HandleDone:
-// saves result, creates outbox, and checks for the next queued inbox message.
-func (m *Manager) handleDoneTx(session *storage.Session, output string) (*storage.InboxMessage, error) {
+// records the turn's usage, saves result, creates outbox, and checks for the
+// next queued inbox message. When changes is non-nil, the git summary is
+// appended to the email and the full patch is attached. If a budget is
+// exceeded the session is paused and no queued message is dequeued.
+func (m *Manager) handleDoneTx(session *storage.Session, output string,
+	changes *git.Summary, snap transcriptSnapshot) (*storage.InboxMessage, error) {
+	body := email.StripANSI(output)
+	if changes != nil {
+		body += changesMarkdown(changes)
+	}
+
 	var nextMsg *storage.InboxMessage
+	var turn *storage.Turn
 	err := m.store.Tx(context.Background(), func(tx *storage.Store) error {
 		session.LastResult = &output
 
-		outbox := &storage.OutboxMessage{
-			ID:        uuid.New().String(),
-			SessionID: session.ID,
-			Subject:   "Claude Code result",
-			Body:      renderOutput(output),
-			Status:    "pending",
+		finished, usage, paused, txErr := m.recordTurn(tx, session, snap, output, "done")
+		if txErr != nil {
+			return txErr
+		}
+		turn = finished
+
+		state := "done"
+		if paused {
+			state = "paused"
 		}
+		outbox := sessionOutbox(session, state, body+usage, changesAttachments(session.ID, changes)...)
 		if txErr := tx.CreateOutbox(outbox); txErr != nil {
 			return txErr
 		}
 
-		var txErr error
+		if paused {
+			session.Status = "paused"
+			return tx.UpdateSession(session)
+		}
+
 		nextMsg, txErr = tx.DequeueMessage(session.ID)
 		if txErr != nil {
 			return txErr
@@ -75,6 +95,9 @@ func (m *Manager) handleDoneTx(session *storage.Session, output string) (*storag
 			if txErr = tx.MarkProcessed(nextMsg.ID); txErr != nil {
 				return txErr
 			}
+			if txErr = startTurn(tx, session.ID, nextMsg.Body, nextMsg.MessageID); txErr != nil {
+				return txErr
+			}
 			session.LastPrompt = &nextMsg.Body
 		} else {
 			session.Status = "idle"
@@ -82,29 +105,50 @@ func (m *Manager) handleDoneTx(session *storage.Session, output string) (*storag
 
 		return tx.UpdateSession(session)
 	})
+	if err == nil {
+		observeTurn(turn)
+	}
 	return nextMsg, err
 }
 
 // handleAskTx runs the transactional part of HandleAsk:
-// saves result, creates outbox, and sets status to "waiting".
-func (m *Manager) handleAskTx(session *storage.Session, output string) error {
-	return m.store.Tx(context.Background(), func(tx *storage.Store) error {
+// records the turn's usage, saves result, creates outbox, and sets status to
+// "waiting" (or "paused" if a budget was exceeded). When the screen shows a
+// menu or [y/N] question, the email lists the answers that can be replied.
+func (m *Manager) handleAskTx(session *storage.Session, output string, snap transcriptSnapshot) error {
+	choices := ""
+	if prompt, ok := m.detector.Detect(output); ok {
+		choices = choicesMarkdown(prompt)
+	}
+	var turn *storage.Turn
+	err := m.store.Tx(context.Background(), func(tx *storage.Store) error {
 		session.LastResult = &output
 
-		outbox := &storage.OutboxMessage{
-			ID:        uuid.New().String(),
-			SessionID: session.ID,
-			Subject:   "Claude Code is waiting for your input",
-			Body:      renderOutput(output),
-			Status:    "pending",
+		finished, usage, paused, txErr := m.recordTurn(tx, session, snap, output, "waiting")
+		if txErr != nil {
+			return txErr
+		}
+		turn = finished
+
+		state := "waiting for input"
+		if paused {
+			state = "paused"
 		}
-		if txErr := tx.CreateOutbox(outbox); txErr != nil {
+		if txErr := tx.CreateOutbox(sessionOutbox(session, state, output+choices+usage)); txErr != nil {
 			return txErr
 		}
 
 		session.Status = "waiting"
+		if paused {
+			session.Status = "paused"
+		}
 		return tx.UpdateSession(session)
 	})
+	if err != nil {
+		return err
+	}
+	observeTurn(turn)
+	return nil
 }
 
 // HandleAsk processes an ASK signal from a session's FIFO.
@@ -122,7 +166,7 @@ func (m *Manager) HandleAsk(sessionID string) error {
 		return fmt.Errorf("capture-pane: %w", err)
 	}
 
-	return m.handleAskTx(session, output)
+	return m.handleAskTx(session, output, m.snapshotTranscript(sessionID))
 }
 
 // dispatchSignal processes a single FIFO signal line.
@@ -130,16 +174,20 @@ func (m *Manager) HandleAsk(sessionID string) error {
 func (m *Manager) dispatchSignal(sessionID, line string) bool {
 	switch {
 	case strings.HasPrefix(line, "DONE:"):
+		metrics.FIFOSignals.WithLabelValues("done").Inc()
 		if err := m.HandleDone(sessionID); err != nil {
 			slog.Error("HandleDone failed", "session_id", sessionID, "error", err)
 		}
 	case strings.HasPrefix(line, "ASK:"):
+		metrics.FIFOSignals.WithLabelValues("ask").Inc()
 		if err := m.HandleAsk(sessionID); err != nil {
 			slog.Error("HandleAsk failed", "session_id", sessionID, "error", err)
 		}
 	case line == "SHUTDOWN":
+		metrics.FIFOSignals.WithLabelValues("shutdown").Inc()
 		return true
 	default:
+		metrics.FIFOSignals.WithLabelValues("unknown").Inc()
 		slog.Warn("unknown FIFO signal", "session_id", sessionID, "line", line)
 	}
 	return false
@@ -169,10 +217,13 @@ func (m *Manager) listenFIFO(sessionID string) {
 }
 
 // HandleDone processes a DONE signal from a session's FIFO.
-// 1. Waits captureDelay for rendering to complete.
-// 2. Captures tmux pane output.
-// 3. In a transaction: saves result, creates outbox, checks for next inbox message.
-// 4. If a queued message exists, sends it to tmux outside the transaction.
+//  1. Waits captureDelay for rendering to complete.
+//  2. Captures tmux pane output.
+//  3. Summarizes git changes since the turn started (if the working dir is a repo)
+//     and reads token usage from Claude's transcript.
+//  4. In a transaction: records the turn, saves result, creates outbox, checks
+//     the budget and the next inbox message.
+//  5. If a queued message exists, sends it to tmux outside the transaction.
 func (m *Manager) HandleDone(sessionID string) error {
 	time.Sleep(m.captureDelay)
 
@@ -186,13 +237,14 @@ func (m *Manager) HandleDone(sessionID string) error {
 		return fmt.Errorf("capture-pane: %w", err)
 	}
 
-	nextMsg, err := m.handleDoneTx(session, output)
+	nextMsg, err := m.handleDoneTx(session, output, m.collectChanges(session), m.snapshotTranscript(sessionID))
 	if err != nil {
 		return err
 	}
 
 	if nextMsg != nil {
-		return m.tmux.SendKeys(session.TmuxName, nextMsg.Body)
+		m.markTurnStart(session)
+		return m.sendLine(session.TmuxName, nextMsg.Body)
 	}
 	return nil
 }
@@ -0,0 +1,43 @@
+package session
+
+import "fmt"
+
+// EndWithReport captures the session's last output, queues it as a
+// "session ended" email with reason, and then ends the session.
+func (m *Manager) EndWithReport(sessionID, reason string) error {
+	session, err := m.store.GetSession(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	if session.Status == "ended" {
+		return ErrSessionEnded
+	}
+
+	output, err := m.tmux.CapturePane(session.TmuxName, capturePaneLines)
+	if err != nil {
+		output = fmt.Sprintf("(could not capture output: %v)", err)
+	}
+	if err := m.store.CreateOutbox(sessionOutbox(session, "ended", reason+"\n\n"+output)); err != nil {
+		return err
+	}
+	return m.End(sessionID)
+}
+
+// SendProgress queues a snapshot of an active session's output, for
+// sessions created with "Notify: progress". Sessions that are not active
+// have nothing to report and are skipped.
+func (m *Manager) SendProgress(sessionID string) error {
+	session, err := m.store.GetSession(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	if session.Status != "active" {
+		return nil
+	}
+
+	output, err := m.tmux.CapturePane(session.TmuxName, capturePaneLines)
+	if err != nil {
+		return fmt.Errorf("capture-pane: %w", err)
+	}
+	return m.store.CreateOutbox(sessionOutbox(session, "in progress", "Still working. Current output:\n\n"+output))
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// maxSearchResults limits the turns listed in a /search reply.
+const maxSearchResults = 20
+
+// searchReport runs a /search reply command and renders the matches as Markdown.
+func (m *Manager) searchReport(query string) string {
+	if strings.TrimSpace(query) == "" {
+		return commandReport("search", "", fmt.Errorf("usage: /search <words>"))
+	}
+	results, err := m.store.Search(query, maxSearchResults)
+	if err != nil {
+		return commandReport("search", "", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Search: `%s`\n\n", strings.ReplaceAll(query, "`", ""))
+	groups := storage.GroupBySession(results)
+	if len(groups) == 0 {
+		b.WriteString("No matching sessions.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d matching turns in %d sessions.\n\n", len(results), len(groups))
+	for _, group := range groups {
+		first := group[0]
+		fmt.Fprintf(&b, "### `%s` — %s\n\nSession `%s`\n\n", first.WorkingDir, first.Status, first.SessionID)
+		for _, r := range group {
+			fmt.Fprintf(&b, "- **Turn %d** · %s — %s\n", r.Seq, r.StartedAt.Local().Format("2006-01-02 15:04"), r.Snippet)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -5,12 +5,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/yhzion/claude-postman/internal/email"
+	"github.com/yhzion/claude-postman/internal/git"
 	"github.com/yhzion/claude-postman/internal/storage"
 )
 
@@ -42,14 +45,40 @@ var (
 
 // renderOutput converts raw tmux output to HTML for email delivery.
 // ANSI escape codes are stripped, then Markdown is rendered to HTML.
-// Falls back to cleaned plain text if HTML conversion fails.
-func renderOutput(raw string) string {
+// Output too large for the email body is cut and returned in full as an
+// attachment named after name. Falls back to cleaned plain text if HTML
+// conversion fails.
+func renderOutput(name, raw string) (string, []email.Attachment) {
 	cleaned := email.StripANSI(raw)
-	html, err := email.RenderHTML(cleaned)
+	html, attachments, err := email.RenderReport(cleaned, name)
 	if err != nil {
-		return cleaned
+		return cleaned, nil
+	}
+	return html, attachments
+}
+
+// sessionOutbox builds a pending outbox email for a session from a Markdown
+// report, with attachments added to any the rendering produces. state opens
+// the body ("done", "waiting for input", ...) while the subject stays the
+// same for the whole session; the Message-ID lets replies be matched back to
+// the session.
+func sessionOutbox(session *storage.Session, state, markdown string, attachments ...email.Attachment) *storage.OutboxMessage {
+	body, output := renderOutput(shortHash(session.ID)+"-output", email.StateLine(state)+markdown)
+	encoded, err := email.EncodeAttachments(append(attachments, output...))
+	if err != nil {
+		slog.Warn("dropping attachments", "session_id", session.ID, "error", err)
+	}
+
+	msgID := email.NewMessageID()
+	return &storage.OutboxMessage{
+		ID:          uuid.New().String(),
+		SessionID:   session.ID,
+		MessageID:   &msgID,
+		Subject:     email.SessionSubject(session),
+		Body:        body,
+		Attachments: encoded,
+		Status:      "pending",
 	}
-	return html
 }
 
 // Manager manages tmux session lifecycles.
@@ -57,16 +86,22 @@ type Manager struct {
 	store        *storage.Store
 	tmux         TmuxRunner
 	fifoDir      string
+	claudeDir    string // ~/.claude, where Claude Code keeps session transcripts
 	captureDelay time.Duration
+	budget       Budget
+	detector     Detector // finds menus to list in waiting emails and answer by number
 }
 
 // New creates a new session Manager.
 func New(store *storage.Store, tmux TmuxRunner) *Manager {
+	home, _ := os.UserHomeDir()
 	return &Manager{
 		store:        store,
 		tmux:         tmux,
 		fifoDir:      defaultFIFODir,
+		claudeDir:    filepath.Join(home, ".claude"),
 		captureDelay: 500 * time.Millisecond,
+		detector:     DefaultDetector,
 	}
 }
 
@@ -74,14 +109,63 @@ func tmuxName(sessionID string) string {
 	return "session-" + sessionID
 }
 
-func (m *Manager) claudeCommand(sessionID, model, promptFile string) string {
+// CreateOptions are optional settings for a new session, taken from a
+// template preset and the directives of the request email.
+type CreateOptions struct {
+	// SystemPrompt is appended to the relay's system prompt.
+	SystemPrompt string
+	// PermissionMode is passed to claude --permission-mode; "" skips
+	// permission checks.
+	PermissionMode string
+
+	Name      string        // human-readable session title
+	Branch    string        // git branch checked out before Claude starts
+	Timeout   time.Duration // end the session this long after it started; 0 disables
+	Effort    string        // thinking effort: low, medium or high
+	Notify    string        // "progress" also mails periodic progress
+	CC        []string      // extra recipients of the session's emails
+	BudgetUSD float64       // session budget overriding the configured one
+}
+
+// thinkingTokens maps an effort level to Claude Code's thinking budget
+// (MAX_THINKING_TOKENS), matching its "think", "think hard" and "ultrathink" budgets.
+var thinkingTokens = map[string]int{
+	"low":    4000,
+	"medium": 10000,
+	"high":   31999,
+}
+
+func (m *Manager) claudeCommand(sessionID, model, promptFile string, opts CreateOptions) string {
 	sysPrompt := fmt.Sprintf(systemPromptTemplate, sessionID, sessionID, sessionID, sessionID)
-	return fmt.Sprintf("claude --dangerously-skip-permissions --session-id %s --system-prompt '%s' --model %s \"$(cat %s)\"",
-		sessionID, sysPrompt, model, promptFile)
+	if opts.SystemPrompt != "" {
+		sysPrompt += "\n\n" + opts.SystemPrompt
+	}
+	return fmt.Sprintf("%sclaude %s --session-id %s --system-prompt %s --model %s \"$(cat %s)\"",
+		effortEnv(opts.Effort), permissionFlag(opts.PermissionMode), sessionID, shellQuote(sysPrompt), model, promptFile)
+}
+
+func (m *Manager) claudeResumeCommand(session *storage.Session) string {
+	return fmt.Sprintf("%sclaude %s --resume %s --model %s",
+		effortEnv(session.Effort), permissionFlag(session.PermissionMode), session.ID, session.Model)
+}
+
+func effortEnv(effort string) string {
+	if tokens, ok := thinkingTokens[effort]; ok {
+		return fmt.Sprintf("MAX_THINKING_TOKENS=%d ", tokens)
+	}
+	return ""
+}
+
+func permissionFlag(mode string) string {
+	if mode == "" {
+		return "--dangerously-skip-permissions"
+	}
+	return "--permission-mode " + mode
 }
 
-func (m *Manager) claudeResumeCommand(sessionID, model string) string {
-	return fmt.Sprintf("claude --dangerously-skip-permissions --resume %s --model %s", sessionID, model)
+// shellQuote quotes s as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 func (m *Manager) promptFilePath(sessionID string) string {
@@ -96,23 +180,60 @@ func (m *Manager) removePromptFile(sessionID string) {
 	_ = os.Remove(m.promptFilePath(sessionID))
 }
 
+// startTurn records the start of a turn for prompt.
+// sourceMessageID is the Message-ID of the email the prompt came from.
+func startTurn(store *storage.Store, sessionID, prompt, sourceMessageID string) error {
+	return store.CreateTurn(&storage.Turn{
+		ID:              uuid.New().String(),
+		SessionID:       sessionID,
+		Prompt:          prompt,
+		SourceMessageID: sourceMessageID,
+	})
+}
+
 // Create creates a new tmux session with Claude Code and sends the initial prompt
 // as a CLI argument. This avoids timing issues with SendKeys-based prompt delivery.
-func (m *Manager) Create(workingDir, model, prompt string) (*storage.Session, error) {
+// sourceMessageID is the Message-ID of the email that requested the session.
+// When opts.Branch is set, the branch is checked out (or created) first and
+// the session is not created if that fails.
+func (m *Manager) Create(workingDir, model, prompt, sourceMessageID string, opts CreateOptions) (*storage.Session, error) {
+	if opts.Branch != "" {
+		if !git.IsRepo(workingDir) {
+			return nil, fmt.Errorf("branch %s: %s is not a git repository", opts.Branch, workingDir)
+		}
+		if _, err := git.Checkout(workingDir, opts.Branch); err != nil {
+			return nil, fmt.Errorf("branch %s: %w", opts.Branch, err)
+		}
+	}
+
 	id := uuid.New().String()
 	name := tmuxName(id)
+	title := opts.Name
+	if title == "" {
+		title = email.SessionTitle(prompt)
+	}
 
 	session := &storage.Session{
-		ID:         id,
-		TmuxName:   name,
-		WorkingDir: workingDir,
-		Model:      model,
-		Status:     "creating",
-		LastPrompt: &prompt,
+		ID:             id,
+		TmuxName:       name,
+		WorkingDir:     workingDir,
+		Model:          model,
+		Status:         "creating",
+		LastPrompt:     &prompt,
+		PermissionMode: opts.PermissionMode,
+		Name:           title,
+		TimeoutMin:     int(opts.Timeout / time.Minute),
+		Effort:         opts.Effort,
+		Notify:         opts.Notify,
+		CC:             opts.CC,
+		BudgetUSD:      opts.BudgetUSD,
 	}
 	if err := m.store.CreateSession(session); err != nil {
 		return nil, fmt.Errorf("create session record: %w", err)
 	}
+	if err := startTurn(m.store, id, prompt, sourceMessageID); err != nil {
+		return nil, fmt.Errorf("create turn record: %w", err)
+	}
 
 	if err := m.createFIFO(id); err != nil {
 		return nil, fmt.Errorf("create FIFO: %w", err)
@@ -122,12 +243,14 @@ func (m *Manager) Create(workingDir, model, prompt string) (*storage.Session, er
 		return nil, fmt.Errorf("write prompt file: %w", err)
 	}
 
+	m.markTurnStart(session)
+
 	if err := m.tmux.NewSession(name, workingDir); err != nil {
 		return nil, fmt.Errorf("tmux new-session: %w", err)
 	}
 
-	cmd := m.claudeCommand(id, model, m.promptFilePath(id))
-	if err := m.tmux.SendKeys(name, cmd); err != nil {
+	cmd := m.claudeCommand(id, model, m.promptFilePath(id), opts)
+	if err := m.sendLine(name, cmd); err != nil {
 		return nil, fmt.Errorf("tmux send-keys: %w", err)
 	}
 
@@ -151,12 +274,20 @@ func (m *Manager) End(sessionID string) error {
 		return ErrSessionEnded
 	}
 
-	_ = m.tmux.SendKeys(session.TmuxName, "/exit")
+	_ = m.sendLine(session.TmuxName, "/exit")
 	_ = m.tmux.KillSession(session.TmuxName)
 
 	m.writeSentinel(sessionID)
 	_ = m.removeFIFO(sessionID)
 	m.removePromptFile(sessionID)
+	m.removeBaseFile(sessionID)
+
+	if turn, err := m.store.GetOpenTurn(sessionID); err == nil && turn != nil {
+		turn.Outcome = "ended"
+		if m.store.FinishTurn(turn) == nil {
+			observeTurn(turn)
+		}
+	}
 
 	session.Status = "ended"
 	return m.store.UpdateSession(session)
@@ -173,6 +304,16 @@ func (m *Manager) DeliverNext(sessionID string) error {
 		return ErrSessionNotIdle
 	}
 
+	// A waiting session may show a menu; a reply like "2" or "yes"
+	// then selects an option instead of being typed.
+	var prompt Prompt
+	hasPrompt := false
+	if session.Status == "waiting" {
+		if screen, err := m.tmux.CapturePane(session.TmuxName, capturePaneLines); err == nil {
+			prompt, hasPrompt = m.detector.Detect(screen)
+		}
+	}
+
 	var msg *storage.InboxMessage
 	err = m.store.Tx(context.Background(), func(tx *storage.Store) error {
 		var txErr error
@@ -186,6 +327,9 @@ func (m *Manager) DeliverNext(sessionID string) error {
 		if txErr = tx.MarkProcessed(msg.ID); txErr != nil {
 			return txErr
 		}
+		if txErr = startTurn(tx, sessionID, msg.Body, msg.MessageID); txErr != nil {
+			return txErr
+		}
 		session.Status = "active"
 		session.LastPrompt = &msg.Body
 		return tx.UpdateSession(session)
@@ -195,7 +339,13 @@ func (m *Manager) DeliverNext(sessionID string) error {
 	}
 
 	if msg != nil {
-		return m.tmux.SendKeys(session.TmuxName, msg.Body)
+		m.markTurnStart(session)
+		if hasPrompt {
+			if keys, ok := choiceKeys(prompt, msg.Body); ok {
+				return m.tmux.SendKeys(session.TmuxName, keys...)
+			}
+		}
+		return m.sendLine(session.TmuxName, msg.Body)
 	}
 	return nil
 }
@@ -205,9 +355,9 @@ func (m *Manager) Get(sessionID string) (*storage.Session, error) {
 	return m.store.GetSession(sessionID)
 }
 
-// ListActive returns all non-ended sessions (creating, active, idle, waiting).
+// ListActive returns all non-ended sessions (creating, active, idle, waiting, paused).
 func (m *Manager) ListActive() ([]*storage.Session, error) {
-	return m.store.ListSessionsByStatus("creating", "active", "idle", "waiting")
+	return m.store.ListSessionsByStatus("creating", "active", "idle", "waiting", "paused")
 }
 
 // CaptureOutput captures the current tmux pane output for a session.
@@ -223,7 +373,7 @@ func (m *Manager) CaptureOutput(sessionID string) (string, error) {
 // For each session missing its tmux session, it recreates the tmux session with --resume.
 // If recovery fails, the session is marked as ended.
 func (m *Manager) RecoverAll() error {
-	sessions, err := m.store.ListSessionsByStatus("active", "idle", "waiting")
+	sessions, err := m.store.ListSessionsByStatus("active", "idle", "waiting", "paused")
 	if err != nil {
 		return err
 	}
@@ -245,8 +395,8 @@ func (m *Manager) RecoverAll() error {
 			continue
 		}
 
-		cmd := m.claudeResumeCommand(session.ID, session.Model)
-		if sendErr := m.tmux.SendKeys(session.TmuxName, cmd); sendErr != nil {
+		cmd := m.claudeResumeCommand(session)
+		if sendErr := m.sendLine(session.TmuxName, cmd); sendErr != nil {
 			session.Status = "ended"
 			_ = m.store.UpdateSession(session)
 			continue
@@ -2,12 +2,15 @@ package session
 
 import (
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/email"
 	"github.com/yhzion/claude-postman/internal/storage"
 )
 
@@ -24,7 +27,8 @@ type mockTmux struct {
 
 type sentKey struct {
 	session string
-	text    string
+	text    string // literal text of the call
+	keys    []Key
 }
 
 func newMockTmux() *mockTmux {
@@ -41,11 +45,15 @@ func (m *mockTmux) NewSession(name, _ string) error {
 	return nil
 }
 
-func (m *mockTmux) SendKeys(sessionName, text string) error {
+func (m *mockTmux) SendKeys(sessionName string, keys ...Key) error {
 	if m.sendKeysErr != nil {
 		return m.sendKeysErr
 	}
-	m.sentKeys = append(m.sentKeys, sentKey{session: sessionName, text: text})
+	var text strings.Builder
+	for _, k := range keys {
+		text.WriteString(k.Text)
+	}
+	m.sentKeys = append(m.sentKeys, sentKey{session: sessionName, text: text.String(), keys: keys})
 	return nil
 }
 
@@ -83,6 +91,7 @@ func newTestManager(t *testing.T) (*Manager, *mockTmux) {
 	mock := newMockTmux()
 	mgr := New(store, mock)
 	mgr.fifoDir = t.TempDir()
+	mgr.claudeDir = t.TempDir()
 	mgr.captureDelay = 0
 	return mgr, mock
 }
@@ -104,7 +113,7 @@ func createTestSession(t *testing.T, mgr *Manager, id, status string) *storage.S
 func TestCreate_DBRecordAndTmuxSession(t *testing.T) {
 	mgr, mock := newTestManager(t)
 
-	session, err := mgr.Create("/tmp/work", "sonnet", "Do something cool")
+	session, err := mgr.Create("/tmp/work", "sonnet", "Do something cool", "<req@mail>", CreateOptions{})
 	require.NoError(t, err)
 
 	// UUID 형식 확인
@@ -130,18 +139,87 @@ func TestCreate_DBRecordAndTmuxSession(t *testing.T) {
 	assert.Contains(t, mock.sentKeys[0].text, "--session-id "+session.ID)
 	assert.Contains(t, mock.sentKeys[0].text, "--model sonnet")
 	assert.Contains(t, mock.sentKeys[0].text, "$(cat ")
+
+	// 제목은 첫 프롬프트 요약
+	assert.Equal(t, "Do something cool", session.Name)
+}
+
+func TestCreate_WithPresetOptions(t *testing.T) {
+	mgr, mock := newTestManager(t)
+
+	session, err := mgr.Create("/tmp/infra", "sonnet", "Plan the upgrade", "", CreateOptions{
+		SystemPrompt:   "Don't apply changes.",
+		PermissionMode: "plan",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "plan", session.PermissionMode, "resume 시 재사용하도록 저장")
+
+	require.Len(t, mock.sentKeys, 1)
+	cmd := mock.sentKeys[0].text
+	assert.Contains(t, cmd, "claude --permission-mode plan")
+	assert.NotContains(t, cmd, "--dangerously-skip-permissions")
+	assert.Contains(t, cmd, `Don'\''t apply changes.`, "추가 지시는 시스템 프롬프트 뒤에 셸 인용되어 붙음")
 }
 
 func TestCreate_TMuxNameFormat(t *testing.T) {
 	mgr, _ := newTestManager(t)
 
-	session, err := mgr.Create("/tmp/work", "opus", "task")
+	session, err := mgr.Create("/tmp/work", "opus", "task", "", CreateOptions{})
 	require.NoError(t, err)
 
 	assert.True(t, strings.HasPrefix(session.TmuxName, "session-"))
 	assert.Equal(t, "session-"+session.ID, session.TmuxName)
 }
 
+func TestCreate_WithDirectives(t *testing.T) {
+	mgr, mock := newTestManager(t)
+
+	session, err := mgr.Create("/tmp/work", "sonnet", "task", "", CreateOptions{
+		Name:      "Login bug",
+		Timeout:   90 * time.Minute,
+		Effort:    "high",
+		Notify:    "progress",
+		CC:        []string{"alice@example.com"},
+		BudgetUSD: 2.5,
+	})
+	require.NoError(t, err)
+
+	got, err := mgr.Get(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Login bug", got.Name)
+	assert.Equal(t, 90, got.TimeoutMin)
+	assert.Equal(t, "high", got.Effort)
+	assert.Equal(t, "progress", got.Notify)
+	assert.Equal(t, []string{"alice@example.com"}, got.CC)
+	assert.InDelta(t, 2.5, got.BudgetUSD, 0.001)
+
+	require.Len(t, mock.sentKeys, 1)
+	assert.True(t, strings.HasPrefix(mock.sentKeys[0].text, "MAX_THINKING_TOKENS=31999 claude "),
+		"effort는 thinking 토큰 예산으로 전달")
+}
+
+func TestCreate_BranchChecksOut(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	_, dir := createGitSession(t, mgr, "branch-base", "ended")
+
+	_, err := mgr.Create(dir, "sonnet", "task", "", CreateOptions{Branch: "fix/login"})
+	require.NoError(t, err)
+	assert.Equal(t, "fix/login\n", runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+}
+
+func TestCreate_BranchRequiresGitRepo(t *testing.T) {
+	mgr, mock := newTestManager(t)
+
+	_, err := mgr.Create(t.TempDir(), "sonnet", "task", "", CreateOptions{Branch: "fix/login"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a git repository")
+	assert.Empty(t, mock.sentKeys, "체크아웃 실패 시 세션을 만들지 않음")
+
+	sessions, err := mgr.ListActive()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
 func TestEnd_KillsSessionAndUpdatesDB(t *testing.T) {
 	mgr, mock := newTestManager(t)
 
@@ -176,6 +254,43 @@ func TestEnd_NotFound(t *testing.T) {
 	assert.ErrorIs(t, err, ErrSessionNotFound)
 }
 
+func TestEndWithReport(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	session := createTestSession(t, mgr, "timeout-1", "active")
+	mock.sessions[session.TmuxName] = true
+	mock.captured = "half-done output"
+
+	require.NoError(t, mgr.EndWithReport("timeout-1", "Session timed out after 1h0m0s."))
+
+	got, err := mgr.Get("timeout-1")
+	require.NoError(t, err)
+	assert.Equal(t, "ended", got.Status)
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, "[claude-postman] test: timeout-", outbox[0].Subject)
+	assert.Contains(t, outbox[0].Body, "<strong>ended</strong>")
+	assert.Contains(t, outbox[0].Body, "timed out")
+	assert.Contains(t, outbox[0].Body, "half-done output", "종료 직전 출력을 포함")
+}
+
+func TestSendProgress(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	createTestSession(t, mgr, "progress-1", "active")
+	createTestSession(t, mgr, "progress-2", "idle")
+	mock.captured = "step 3 of 5"
+
+	require.NoError(t, mgr.SendProgress("progress-1"))
+	require.NoError(t, mgr.SendProgress("progress-2"), "active가 아니면 건너뜀")
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, "progress-1", outbox[0].SessionID)
+	assert.Contains(t, outbox[0].Body, "step 3 of 5")
+}
+
 func TestDeliverNext_IdleWithMessage(t *testing.T) {
 	mgr, mock := newTestManager(t)
 
@@ -231,6 +346,20 @@ func TestDeliverNext_WaitingWithMessage(t *testing.T) {
 	assert.Equal(t, "3번 선택", mock.sentKeys[0].text)
 }
 
+func TestDeliverNext_WaitingMenuChoice(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	createTestSession(t, mgr, "menu-1", "waiting")
+	mock.captured = "Do you want to proceed?\n❯ 1. Yes\n  2. Yes, and don't ask again\n  3. No, and tell Claude what to do differently (esc)\n"
+
+	require.NoError(t, mgr.store.EnqueueMessage(&storage.InboxMessage{
+		ID: "reply-menu", SessionID: "menu-1", Body: "3\n\nOn Mon, you wrote:\n> ...",
+	}))
+	require.NoError(t, mgr.DeliverNext("menu-1"))
+
+	require.Len(t, mock.sentKeys, 1)
+	assert.Equal(t, []Key{KeyDown, KeyDown, KeyEnter}, mock.sentKeys[0].keys, "숫자 답장은 방향키 + Enter로 선택")
+}
+
 func TestDeliverNext_ActiveSession(t *testing.T) {
 	mgr, _ := newTestManager(t)
 	createTestSession(t, mgr, "active-1", "active")
@@ -278,6 +407,234 @@ func TestHandleDone_TransitionsToIdle(t *testing.T) {
 	assert.Contains(t, outbox[0].Body, "<html>")
 	assert.Contains(t, outbox[0].Body, "작업 완료 결과입니다")
 	assert.Equal(t, "pending", outbox[0].Status)
+	assert.Equal(t, "[claude-postman] test: done-1", outbox[0].Subject, "상태는 제목이 아닌 본문에")
+	assert.Contains(t, outbox[0].Body, "<strong>done</strong>")
+	require.NotNil(t, outbox[0].MessageID, "답장을 세션에 매칭하려면 Message-ID 필요")
+
+	sid, err := mgr.store.GetSessionIDByOutboxMessageID(*outbox[0].MessageID)
+	require.NoError(t, err)
+	assert.Equal(t, "done-1", sid)
+}
+
+// runGit runs a git command in dir with a fixed test identity.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	base := []string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}
+	out, err := exec.Command("git", append(base, args...)...).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+// createGitSession creates a session whose working dir is a fresh git
+// repository with one committed main.go, and records the turn start.
+func createGitSession(t *testing.T, mgr *Manager, id, status string) (*storage.Session, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.name", "test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o600))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+
+	session := &storage.Session{
+		ID: id, TmuxName: tmuxName(id), WorkingDir: dir, Model: "sonnet", Status: status,
+	}
+	require.NoError(t, mgr.store.CreateSession(session))
+	mgr.markTurnStart(session)
+	return session, dir
+}
+
+func TestHandleDone_GitRepoAttachesChanges(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	_, dir := createGitSession(t, mgr, "git-1", "active")
+
+	// Claude가 작업 중 파일을 수정
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o600))
+	mock.captured = "done"
+
+	require.NoError(t, mgr.HandleDone("git-1"))
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Contains(t, outbox[0].Body, "Changes")
+	assert.Contains(t, outbox[0].Body, "main.go")
+
+	atts, err := email.DecodeAttachments(outbox[0].Attachments)
+	require.NoError(t, err)
+	require.Len(t, atts, 1)
+	assert.Equal(t, "git-1.patch", atts[0].Filename)
+	assert.Contains(t, string(atts[0].Data), "+func main() {}")
+}
+
+func TestHandleDone_NonRepoHasNoAttachments(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	createTestSession(t, mgr, "plain-1", "active")
+	mock.captured = "done"
+
+	require.NoError(t, mgr.HandleDone("plain-1"))
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Nil(t, outbox[0].Attachments)
+}
+
+func TestHandleDone_LargeOutputAttached(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	createTestSession(t, mgr, "big-1", "active")
+	mock.captured = "START\n" + strings.Repeat("log line with some text\n", 20000) + "END"
+
+	require.NoError(t, mgr.HandleDone("big-1"))
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.LessOrEqual(t, len(outbox[0].Body), email.MaxBodyBytes, "본문은 크기 예산 이내")
+	assert.Contains(t, outbox[0].Body, "START")
+	assert.Contains(t, outbox[0].Body, "END")
+
+	atts, err := email.DecodeAttachments(outbox[0].Attachments)
+	require.NoError(t, err)
+	require.Len(t, atts, 1)
+	assert.Equal(t, "big-1-output.md", atts[0].Filename)
+	assert.Contains(t, string(atts[0].Data), "START\nlog line")
+}
+
+func TestRunCommand_Commit(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	_, dir := createGitSession(t, mgr, "cmd-1", "idle")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0o600))
+
+	require.NoError(t, mgr.RunCommand("cmd-1", "commit", "Add new.go"))
+
+	assert.Contains(t, runGit(t, dir, "log", "-1", "--format=%s"), "Add new.go")
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.NotContains(t, outbox[0].Subject, "/commit", "명령 결과도 같은 제목")
+	assert.Contains(t, outbox[0].Body, "<strong>/commit</strong>")
+	assert.Contains(t, outbox[0].Body, "succeeded")
+}
+
+func TestRunCommand_Revert(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	_, dir := createGitSession(t, mgr, "cmd-2", "idle")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("broken"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "junk.txt"), []byte("junk"), 0o600))
+
+	require.NoError(t, mgr.RunCommand("cmd-2", "revert", ""))
+
+	content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+	assert.NoFileExists(t, filepath.Join(dir, "junk.txt"))
+	assert.Contains(t, runGit(t, dir, "stash", "list"), "claude-postman revert")
+}
+
+func TestRunCommand_BusySession(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	_, dir := createGitSession(t, mgr, "cmd-3", "active")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0o600))
+
+	require.NoError(t, mgr.RunCommand("cmd-3", "commit", "too early"))
+
+	assert.Contains(t, runGit(t, dir, "log", "-1", "--format=%s"), "initial")
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Contains(t, outbox[0].Body, "failed")
+}
+
+func TestRunCommand_Search(t *testing.T) {
+	mgr, mock := newTestManager(t)
+
+	session, err := mgr.Create("/tmp/api", "sonnet", "Fix the migration bug", "", CreateOptions{})
+	require.NoError(t, err)
+	mock.captured = "Fixed it"
+	require.NoError(t, mgr.HandleDone(session.ID))
+
+	require.NoError(t, mgr.RunCommand(session.ID, "search", "migration"))
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 2)
+	reply := outbox[1]
+	assert.Equal(t, outbox[0].Subject, reply.Subject, "세션 이메일은 모두 같은 제목")
+	assert.Equal(t, "[claude-postman] api: Fix the migration bug", reply.Subject)
+	assert.Contains(t, reply.Body, "/tmp/api")
+	assert.Contains(t, reply.Body, session.ID)
+	assert.Contains(t, reply.Body, "<strong>migration</strong>", "매칭 부분이 강조되어야 함")
+	assert.NotContains(t, reply.Body, "Session-ID:", "답장이 다른 세션으로 라우팅되면 안 됨")
+}
+
+func TestHandleDone_RecordsUsage(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	createTestSession(t, mgr, "usage-1", "active")
+	mock.captured = "done"
+
+	writeTranscript(t, mgr.claudeDir, "usage-1", assistantLine("msg_1", "claude-sonnet-4-5", 1000, 100))
+	require.NoError(t, mgr.HandleDone("usage-1"))
+
+	// 두 번째 턴: transcript 누적치에서 이전 턴을 뺀 값만 기록
+	writeTranscript(t, mgr.claudeDir, "usage-1",
+		assistantLine("msg_1", "claude-sonnet-4-5", 1000, 100),
+		assistantLine("msg_2", "claude-sonnet-4-5", 500, 50))
+	s, err := mgr.Get("usage-1")
+	require.NoError(t, err)
+	s.Status = "active"
+	require.NoError(t, mgr.store.UpdateSession(s))
+	require.NoError(t, mgr.HandleDone("usage-1"))
+
+	turns, err := mgr.store.ListTurns("usage-1")
+	require.NoError(t, err)
+	require.Len(t, turns, 2)
+	assert.Equal(t, int64(1000), turns[0].InputTokens)
+	assert.Equal(t, int64(500), turns[1].InputTokens)
+	assert.Equal(t, "claude-sonnet-4-5", turns[1].Model)
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 2)
+	assert.Contains(t, outbox[1].Body, "Usage")
+}
+
+func TestHandleDone_BudgetExceededPauses(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	mgr.SetBudget(Budget{SessionUSD: 0.001})
+	createTestSession(t, mgr, "budget-1", "active")
+	mock.captured = "done"
+	writeTranscript(t, mgr.claudeDir, "budget-1", assistantLine("msg_1", "claude-sonnet-4-5", 1000, 100))
+
+	require.NoError(t, mgr.store.EnqueueMessage(&storage.InboxMessage{
+		ID: "queued-1", SessionID: "budget-1", Body: "queued work",
+	}))
+	require.NoError(t, mgr.HandleDone("budget-1"))
+
+	got, err := mgr.Get("budget-1")
+	require.NoError(t, err)
+	assert.Equal(t, "paused", got.Status)
+	assert.Empty(t, mock.sentKeys, "일시정지된 세션에는 대기 메시지를 전달하지 않음")
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Contains(t, outbox[0].Body, "Budget exceeded")
+
+	// /continue로 재개
+	require.NoError(t, mgr.RunCommand("budget-1", "continue", ""))
+	got, err = mgr.Get("budget-1")
+	require.NoError(t, err)
+	assert.Equal(t, "idle", got.Status)
+	assert.Greater(t, got.BudgetAckUSD, 0.0)
+
+	require.NoError(t, mgr.DeliverNext("budget-1"))
+	require.Len(t, mock.sentKeys, 1)
+	assert.Equal(t, "queued work", mock.sentKeys[0].text)
 }
 
 func TestHandleDone_WithPendingInbox(t *testing.T) {
@@ -318,6 +675,64 @@ func TestHandleDone_WithPendingInbox(t *testing.T) {
 	assert.Nil(t, dequeued, "메시지가 이미 처리되어야 함")
 }
 
+func TestTurnHistory(t *testing.T) {
+	mgr, mock := newTestManager(t)
+
+	session, err := mgr.Create("/tmp/work", "sonnet", "첫 번째 작업", "<first@mail>", CreateOptions{})
+	require.NoError(t, err)
+
+	// 첫 턴: 질문으로 끝남
+	mock.captured = "어느 파일을 고칠까요?"
+	require.NoError(t, mgr.HandleAsk(session.ID))
+
+	// 답장 → 두 번째 턴 시작
+	require.NoError(t, mgr.store.EnqueueMessage(&storage.InboxMessage{
+		ID:        "reply-1",
+		SessionID: session.ID,
+		MessageID: "<reply@mail>",
+		Body:      "main.go",
+	}))
+	require.NoError(t, mgr.DeliverNext(session.ID))
+
+	// 두 번째 턴: 완료
+	mock.captured = "main.go 수정 완료"
+	require.NoError(t, mgr.HandleDone(session.ID))
+
+	turns, err := mgr.store.ListTurns(session.ID)
+	require.NoError(t, err)
+	require.Len(t, turns, 2, "이전 턴 기록이 덮어써지지 않아야 함")
+
+	assert.Equal(t, 1, turns[0].Seq)
+	assert.Equal(t, "첫 번째 작업", turns[0].Prompt)
+	assert.Equal(t, "<first@mail>", turns[0].SourceMessageID)
+	assert.Equal(t, "waiting", turns[0].Outcome)
+	assert.Contains(t, turns[0].Result, "어느 파일을 고칠까요?")
+	assert.NotNil(t, turns[0].FinishedAt)
+
+	assert.Equal(t, 2, turns[1].Seq)
+	assert.Equal(t, "main.go", turns[1].Prompt)
+	assert.Equal(t, "<reply@mail>", turns[1].SourceMessageID)
+	assert.Equal(t, "done", turns[1].Outcome)
+	assert.Contains(t, turns[1].Result, "main.go 수정 완료")
+}
+
+func TestEnd_FinishesOpenTurn(t *testing.T) {
+	mgr, _ := newTestManager(t)
+
+	session, err := mgr.Create("/tmp/work", "sonnet", "긴 작업", "", CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, mgr.End(session.ID))
+
+	open, err := mgr.store.GetOpenTurn(session.ID)
+	require.NoError(t, err)
+	assert.Nil(t, open, "종료 후 실행 중인 턴이 없어야 함")
+
+	turns, err := mgr.store.ListTurns(session.ID)
+	require.NoError(t, err)
+	require.Len(t, turns, 1)
+	assert.Equal(t, "ended", turns[0].Outcome)
+}
+
 func TestHandleAsk_TransitionsToWaiting(t *testing.T) {
 	mgr, mock := newTestManager(t)
 	createTestSession(t, mgr, "ask-1", "active")
@@ -341,6 +756,24 @@ func TestHandleAsk_TransitionsToWaiting(t *testing.T) {
 	assert.Contains(t, outbox[0].Body, "어느 프로젝트를 분석할까요?")
 }
 
+func TestHandleAsk_ListsOptions(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	createTestSession(t, mgr, "ask-menu", "active")
+	screen, err := os.ReadFile(filepath.Join("testdata", "panes", "plan_approval.txt"))
+	require.NoError(t, err)
+	mock.captured = string(screen)
+
+	require.NoError(t, mgr.HandleAsk("ask-menu"))
+
+	outbox, err := mgr.store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	body := outbox[0].Body
+	assert.Contains(t, body, "<strong>Would you like to proceed?</strong>")
+	assert.Contains(t, body, `<li style="margin:4px 0;">Yes, and manually approve edits</li>`, "선택지는 HTML 목록으로")
+	assert.Contains(t, body, "Reply with the number of your choice")
+}
+
 func TestHandleAsk_NotFound(t *testing.T) {
 	mgr, _ := newTestManager(t)
 
@@ -350,7 +783,7 @@ func TestHandleAsk_NotFound(t *testing.T) {
 
 func TestClaudeCommand_ContainsAskInstruction(t *testing.T) {
 	mgr, _ := newTestManager(t)
-	cmd := mgr.claudeCommand("test-id", "sonnet", "/tmp/prompt")
+	cmd := mgr.claudeCommand("test-id", "sonnet", "/tmp/prompt", CreateOptions{})
 	assert.Contains(t, cmd, "ASK:test-id")
 	assert.Contains(t, cmd, "DONE:test-id")
 }
@@ -450,6 +883,7 @@ func TestRecoverAll_MissingSession(t *testing.T) {
 	require.Len(t, mock.sentKeys, 1)
 	assert.Contains(t, mock.sentKeys[0].text, "--resume recover-missing")
 	assert.Contains(t, mock.sentKeys[0].text, "--model sonnet")
+	assert.Contains(t, mock.sentKeys[0].text, "--dangerously-skip-permissions")
 
 	// DB 상태 유지
 	got, err := mgr.Get("recover-missing")
@@ -457,6 +891,19 @@ func TestRecoverAll_MissingSession(t *testing.T) {
 	assert.Equal(t, "active", got.Status)
 }
 
+func TestRecoverAll_KeepsPermissionMode(t *testing.T) {
+	mgr, mock := newTestManager(t)
+	require.NoError(t, mgr.store.CreateSession(&storage.Session{
+		ID: "recover-plan", TmuxName: tmuxName("recover-plan"), WorkingDir: "/tmp/test",
+		Model: "sonnet", Status: "idle", PermissionMode: "plan",
+	}))
+
+	require.NoError(t, mgr.RecoverAll())
+
+	require.Len(t, mock.sentKeys, 1)
+	assert.Contains(t, mock.sentKeys[0].text, "claude --permission-mode plan --resume recover-plan")
+}
+
 func TestRecoverAll_RecoveryFailure(t *testing.T) {
 	mgr, mock := newTestManager(t)
 	createTestSession(t, mgr, "recover-fail", "idle")
@@ -2,13 +2,36 @@ package session
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
+)
+
+// Key is one input sent to a tmux pane: literal text, or the special key
+// named by Name (a tmux key name such as "Enter" or "Down").
+type Key struct {
+	Text string
+	Name string
+}
+
+// Text returns a Key that types s.
+func Text(s string) Key {
+	return Key{Text: s}
+}
+
+// Special keys used to answer Claude Code's menus.
+var (
+	KeyEnter  = Key{Name: "Enter"}
+	KeyUp     = Key{Name: "Up"}
+	KeyDown   = Key{Name: "Down"}
+	KeyEscape = Key{Name: "Escape"}
 )
 
 // TmuxRunner abstracts tmux command execution for testability.
 type TmuxRunner interface {
 	NewSession(name, workingDir string) error
-	SendKeys(sessionName, text string) error
+	// SendKeys sends keys to the session's pane in order.
+	SendKeys(sessionName string, keys ...Key) error
 	CapturePane(sessionName string, lines int) (string, error)
 	KillSession(sessionName string) error
 	HasSession(sessionName string) bool
@@ -25,8 +48,98 @@ func (t *tmuxCmd) NewSession(name, workingDir string) error {
 	return exec.Command("tmux", "new-session", "-d", "-s", name, "-c", workingDir).Run()
 }
 
-func (t *tmuxCmd) SendKeys(sessionName, text string) error {
-	return exec.Command("tmux", "send-keys", "-t", sessionName, text, "Enter").Run()
+// maxLiteralLen is the longest single-line text typed with send-keys -l.
+// Longer or multi-line text is pasted from a buffer.
+const maxLiteralLen = 200
+
+// SendKeys types short single-line text with send-keys -l, so it is never
+// read as a key name or flag, and pastes other text through a tmux buffer
+// with bracketed paste, so newlines do not submit it early and its length
+// is not limited by the command line. Consecutive special keys are sent
+// with one send-keys.
+func (t *tmuxCmd) SendKeys(sessionName string, keys ...Key) error {
+	for _, step := range sendKeysSteps(keys) {
+		var err error
+		switch {
+		case step.paste != "":
+			err = pasteText(sessionName, step.paste)
+		case step.literal != "":
+			err = runTmux("send-keys", "-t", sessionName, "-l", "--", step.literal)
+		default:
+			err = runTmux(append([]string{"send-keys", "-t", sessionName}, step.keys...)...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendStep is one tmux invocation of SendKeys. Exactly one field is set.
+type sendStep struct {
+	keys    []string // special key names
+	literal string   // typed with send-keys -l
+	paste   string   // pasted from a buffer
+}
+
+func sendKeysSteps(keys []Key) []sendStep {
+	var steps []sendStep
+	for _, k := range keys {
+		switch {
+		case k.Name != "":
+			if n := len(steps); n > 0 && steps[n-1].keys != nil {
+				steps[n-1].keys = append(steps[n-1].keys, k.Name)
+				continue
+			}
+			steps = append(steps, sendStep{keys: []string{k.Name}})
+		case k.Text == "":
+		case strings.Contains(k.Text, "\n") || len(k.Text) > maxLiteralLen:
+			steps = append(steps, sendStep{paste: k.Text})
+		default:
+			steps = append(steps, sendStep{literal: k.Text})
+		}
+	}
+	return steps
+}
+
+// pasteText loads text into a tmux buffer from a temp file and pastes it
+// with bracketed paste (-p), deleting the buffer afterwards (-d).
+func pasteText(sessionName, text string) error {
+	f, err := os.CreateTemp("", "claude-postman-paste-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	buffer := "claude-postman-" + sessionName
+	if err := runTmux("load-buffer", "-b", buffer, f.Name()); err != nil {
+		return fmt.Errorf("load-buffer: %w", err)
+	}
+	if err := runTmux("paste-buffer", "-p", "-d", "-b", buffer, "-t", sessionName); err != nil {
+		return fmt.Errorf("paste-buffer: %w", err)
+	}
+	return nil
+}
+
+// runTmux runs a tmux command and includes its stderr in the error.
+func runTmux(args ...string) error {
+	out, err := exec.Command("tmux", args...).CombinedOutput() //nolint:gosec // args are internally controlled
+	if err != nil {
+		return fmt.Errorf("tmux %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// sendLine types text into the session's pane and presses Enter.
+func (m *Manager) sendLine(sessionName, text string) error {
+	return m.tmux.SendKeys(sessionName, Text(text), KeyEnter)
 }
 
 func (t *tmuxCmd) CapturePane(sessionName string, lines int) (string, error) {
@@ -0,0 +1,66 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendKeysSteps(t *testing.T) {
+	t.Run("short text is literal, keys are grouped", func(t *testing.T) {
+		steps := sendKeysSteps([]Key{Text("C-c"), KeyDown, KeyDown, KeyEnter})
+		assert.Equal(t, []sendStep{
+			{literal: "C-c"},
+			{keys: []string{"Down", "Down", "Enter"}},
+		}, steps, "키 이름처럼 보이는 텍스트도 -l로 전송")
+	})
+
+	t.Run("multi-line text is pasted", func(t *testing.T) {
+		steps := sendKeysSteps([]Key{Text("first\nsecond"), KeyEnter})
+		assert.Equal(t, []sendStep{
+			{paste: "first\nsecond"},
+			{keys: []string{"Enter"}},
+		}, steps)
+	})
+
+	t.Run("long text is pasted", func(t *testing.T) {
+		long := strings.Repeat("x", maxLiteralLen+1)
+		steps := sendKeysSteps([]Key{Text(long)})
+		assert.Equal(t, []sendStep{{paste: long}}, steps)
+	})
+
+	t.Run("empty text is skipped", func(t *testing.T) {
+		assert.Empty(t, sendKeysSteps([]Key{Text("")}))
+	})
+}
+
+// TestTmuxSendKeys_Real delivers text to `cat` running in a real tmux
+// session and checks that it arrives unchanged.
+func TestTmuxSendKeys_Real(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	name := fmt.Sprintf("claude-postman-test-%d", os.Getpid())
+	if err := exec.Command("tmux", "new-session", "-d", "-s", name, "cat").Run(); err != nil {
+		t.Skipf("cannot start tmux session: %v", err)
+	}
+	t.Cleanup(func() { _ = exec.Command("tmux", "kill-session", "-t", name).Run() })
+
+	tmux := NewTmuxRunner()
+	require.NoError(t, tmux.SendKeys(name, Text("-x C-c"), KeyEnter))
+	require.NoError(t, tmux.SendKeys(name, Text("line one\nline two"), KeyEnter))
+
+	var out string
+	require.Eventually(t, func() bool {
+		out, _ = tmux.CapturePane(name, 50)
+		return strings.Count(out, "line two") >= 2
+	}, 3*time.Second, 50*time.Millisecond)
+	assert.Contains(t, out, "-x C-c", "플래그나 키 이름이 아니라 문자 그대로 입력")
+	assert.Contains(t, out, "line one\nline two", "줄바꿈에서 끊기지 않고 한 번에 전달")
+}
@@ -0,0 +1,305 @@
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yhzion/claude-postman/internal/metrics"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// Budget limits spending per session and per day. Zero disables a limit.
+type Budget struct {
+	SessionUSD float64
+	DailyUSD   float64
+}
+
+// SetBudget configures the spending limits checked after every turn.
+func (m *Manager) SetBudget(b Budget) {
+	m.budget = b
+}
+
+// modelPrice is the price in USD per million tokens.
+// Cache writes cost 1.25x and cache reads 0.1x the input price.
+type modelPrice struct {
+	match  string
+	input  float64
+	output float64
+}
+
+// modelPrices is matched in order against the model name reported in the
+// transcript; the first entry whose match is a substring wins.
+var modelPrices = []modelPrice{
+	{"opus-4-0", 15, 75},
+	{"opus-4-1", 15, 75},
+	{"opus-4-2025", 15, 75},
+	{"opus", 5, 25},
+	{"sonnet", 3, 15},
+	{"haiku-3", 0.8, 4},
+	{"haiku", 1, 5},
+}
+
+// estimateCost returns the estimated cost of u for model.
+// Unknown models are priced like Sonnet.
+func estimateCost(model string, u storage.Usage) float64 {
+	price := modelPrice{input: 3, output: 15}
+	for _, p := range modelPrices {
+		if strings.Contains(model, p.match) {
+			price = p
+			break
+		}
+	}
+	perToken := func(usd float64) float64 { return usd / 1_000_000 }
+	return float64(u.InputTokens)*perToken(price.input) +
+		float64(u.OutputTokens)*perToken(price.output) +
+		float64(u.CacheCreationTokens)*perToken(price.input*1.25) +
+		float64(u.CacheReadTokens)*perToken(price.input*0.1)
+}
+
+// transcriptEntry is the subset of a Claude Code transcript line we need.
+type transcriptEntry struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// findTranscript locates the Claude Code transcript for a session.
+// Claude stores it as <claudeDir>/projects/<encoded cwd>/<session-id>.jsonl.
+func (m *Manager) findTranscript(sessionID string) string {
+	matches, err := filepath.Glob(filepath.Join(m.claudeDir, "projects", "*", sessionID+".jsonl"))
+	if err != nil || len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+// RemoveTranscripts deletes the Claude Code transcripts of the given sessions,
+// e.g. after their records were purged. Returns the number of files removed.
+func (m *Manager) RemoveTranscripts(sessionIDs []string) int {
+	removed := 0
+	for _, id := range sessionIDs {
+		path := m.findTranscript(id)
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			slog.Warn("failed to remove transcript", "session_id", id, "error", err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+// readTranscriptUsage sums the usage of all assistant messages in a transcript.
+// Claude writes one line per content block, repeating the usage of the
+// message, so entries are de-duplicated by message ID (the last one wins).
+// Returns the cumulative usage and the most recently used model.
+func readTranscriptUsage(path string) (storage.Usage, string, error) {
+	f, err := os.Open(path) //nolint:gosec // path is derived from the session ID
+	if err != nil {
+		return storage.Usage{}, "", err
+	}
+	defer f.Close()
+
+	type message struct {
+		model string
+		usage storage.Usage
+	}
+	byID := make(map[string]message)
+	var order []string
+	var lastModel string
+
+	r := bufio.NewReader(f)
+	for {
+		line, readErr := r.ReadBytes('\n')
+		var e transcriptEntry
+		if len(line) > 0 && json.Unmarshal(line, &e) == nil && e.Type == "assistant" && e.Message.ID != "" {
+			if _, seen := byID[e.Message.ID]; !seen {
+				order = append(order, e.Message.ID)
+			}
+			byID[e.Message.ID] = message{
+				model: e.Message.Model,
+				usage: storage.Usage{
+					InputTokens:         e.Message.Usage.InputTokens,
+					OutputTokens:        e.Message.Usage.OutputTokens,
+					CacheCreationTokens: e.Message.Usage.CacheCreationInputTokens,
+					CacheReadTokens:     e.Message.Usage.CacheReadInputTokens,
+				},
+			}
+			lastModel = e.Message.Model
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return storage.Usage{}, "", readErr
+		}
+	}
+
+	var total storage.Usage
+	for _, id := range order {
+		msg := byID[id]
+		msg.usage.CostUSD = estimateCost(msg.model, msg.usage)
+		total = addUsage(total, msg.usage)
+	}
+	return total, lastModel, nil
+}
+
+func addUsage(a, b storage.Usage) storage.Usage {
+	return storage.Usage{
+		InputTokens:         a.InputTokens + b.InputTokens,
+		OutputTokens:        a.OutputTokens + b.OutputTokens,
+		CacheCreationTokens: a.CacheCreationTokens + b.CacheCreationTokens,
+		CacheReadTokens:     a.CacheReadTokens + b.CacheReadTokens,
+		CostUSD:             a.CostUSD + b.CostUSD,
+	}
+}
+
+// subUsage returns a-b, clamping at zero (e.g. after a transcript was rotated).
+func subUsage(a, b storage.Usage) storage.Usage {
+	clamp := func(v int64) int64 { return max(v, 0) }
+	return storage.Usage{
+		InputTokens:         clamp(a.InputTokens - b.InputTokens),
+		OutputTokens:        clamp(a.OutputTokens - b.OutputTokens),
+		CacheCreationTokens: clamp(a.CacheCreationTokens - b.CacheCreationTokens),
+		CacheReadTokens:     clamp(a.CacheReadTokens - b.CacheReadTokens),
+		CostUSD:             max(a.CostUSD-b.CostUSD, 0),
+	}
+}
+
+// transcriptSnapshot is the cumulative usage read from a transcript.
+// found is false when no transcript exists for the session.
+type transcriptSnapshot struct {
+	usage storage.Usage
+	model string
+	found bool
+}
+
+// snapshotTranscript reads the session's transcript outside any transaction.
+func (m *Manager) snapshotTranscript(sessionID string) transcriptSnapshot {
+	path := m.findTranscript(sessionID)
+	if path == "" {
+		return transcriptSnapshot{}
+	}
+	usage, model, err := readTranscriptUsage(path)
+	if err != nil {
+		return transcriptSnapshot{}
+	}
+	return transcriptSnapshot{usage: usage, model: model, found: true}
+}
+
+// recordTurn finishes the session's running turn with its result, outcome and
+// usage, then checks the budget. The turn's usage is the transcript total
+// minus what earlier turns recorded. If no turn is running (e.g. after
+// recovery), a turn is recorded for the last prompt.
+// Returns the finished turn, a Markdown usage summary for the email and
+// whether the session must be paused because a budget was exceeded. The
+// caller observes the turn's metrics once the transaction has committed.
+func (m *Manager) recordTurn(tx *storage.Store, session *storage.Session,
+	snap transcriptSnapshot, output, outcome string) (*storage.Turn, string, bool, error) {
+	prev, err := tx.SessionUsage(session.ID)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	turn, err := tx.GetOpenTurn(session.ID)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if turn == nil {
+		turn = &storage.Turn{ID: uuid.New().String(), SessionID: session.ID}
+		if session.LastPrompt != nil {
+			turn.Prompt = *session.LastPrompt
+		}
+		if err := tx.CreateTurn(turn); err != nil {
+			return nil, "", false, err
+		}
+	}
+	turn.Result = output
+	turn.Outcome = outcome
+	turn.Model = snap.model
+	if snap.found {
+		turn.Usage = subUsage(snap.usage, prev)
+	}
+	if err := tx.FinishTurn(turn); err != nil {
+		return nil, "", false, err
+	}
+
+	sessionTotal := addUsage(prev, turn.Usage)
+	daily, err := tx.UsageSince(startOfDay(time.Now()))
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	var b strings.Builder
+	if snap.found {
+		fmt.Fprintf(&b, "\n\n---\n\n**Usage** — this turn: %s (in %d · out %d · cache write %d · cache read %d) · session: %s · today: %s\n",
+			formatUSD(turn.CostUSD), turn.InputTokens, turn.OutputTokens,
+			turn.CacheCreationTokens, turn.CacheReadTokens,
+			formatUSD(sessionTotal.CostUSD), formatUSD(daily.CostUSD))
+	}
+
+	budget := m.budget
+	if session.BudgetUSD > 0 {
+		budget.SessionUSD = session.BudgetUSD
+	}
+	reason := budget.exceeded(sessionTotal.CostUSD, session.BudgetAckUSD, daily.CostUSD)
+	if reason == "" {
+		return turn, b.String(), false, nil
+	}
+	fmt.Fprintf(&b, "\n\n**Budget exceeded** — %s. The session is paused and queued messages "+
+		"will wait. Reply `/continue` to resume.\n", reason)
+	return turn, b.String(), true, nil
+}
+
+// exceeded returns why the budget is exceeded, or an empty string.
+// ack is the session cost at which the user last confirmed with /continue;
+// each confirmation grants another full session budget, and the daily budget
+// asks again once the session has spent more since the confirmation.
+func (b Budget) exceeded(sessionCost, ack, dailyCost float64) string {
+	if b.SessionUSD > 0 && sessionCost-ack >= b.SessionUSD {
+		return fmt.Sprintf("session budget of %s reached (session total %s)", formatUSD(b.SessionUSD), formatUSD(sessionCost))
+	}
+	if b.DailyUSD > 0 && dailyCost >= b.DailyUSD && sessionCost > ack {
+		return fmt.Sprintf("daily budget of %s reached (today %s)", formatUSD(b.DailyUSD), formatUSD(dailyCost))
+	}
+	return ""
+}
+
+func formatUSD(v float64) string {
+	if v > 0 && v < 0.01 {
+		return fmt.Sprintf("$%.4f", v)
+	}
+	return fmt.Sprintf("$%.2f", v)
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, t.Location())
+}
+
+// observeTurn records a finished turn's duration in the turn_duration metric.
+func observeTurn(turn *storage.Turn) {
+	if turn.FinishedAt == nil {
+		return
+	}
+	metrics.TurnDuration.WithLabelValues(turn.Outcome).Observe(turn.FinishedAt.Sub(turn.StartedAt).Seconds())
+}
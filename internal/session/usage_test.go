@@ -0,0 +1,81 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/storage"
+)
+
+// writeTranscript writes a Claude Code transcript for sessionID under claudeDir.
+func writeTranscript(t *testing.T, claudeDir, sessionID string, lines ...string) {
+	t.Helper()
+	dir := filepath.Join(claudeDir, "projects", "-tmp-test")
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	content := strings.Join(lines, "\n") + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, sessionID+".jsonl"), []byte(content), 0o600))
+}
+
+func assistantLine(id, model string, in, out int) string {
+	return fmt.Sprintf(`{"type":"assistant","message":{"id":%q,"model":%q,"usage":`+
+		`{"input_tokens":%d,"output_tokens":%d,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}`,
+		id, model, in, out)
+}
+
+func TestReadTranscriptUsage_DeduplicatesMessages(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeTranscript(t, claudeDir, "s1",
+		`{"type":"user","message":{"role":"user","content":"hi"}}`,
+		assistantLine("msg_1", "claude-sonnet-4-5-20250929", 1000, 100),
+		assistantLine("msg_1", "claude-sonnet-4-5-20250929", 1000, 100), // 같은 메시지의 다른 content block
+		`not json`,
+		assistantLine("msg_2", "claude-sonnet-4-5-20250929", 2000, 200),
+	)
+
+	u, model, err := readTranscriptUsage(filepath.Join(claudeDir, "projects", "-tmp-test", "s1.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-5-20250929", model)
+	assert.Equal(t, int64(3000), u.InputTokens)
+	assert.Equal(t, int64(300), u.OutputTokens)
+	// 3000 * $3/MTok + 300 * $15/MTok
+	assert.InDelta(t, 0.009+0.0045, u.CostUSD, 1e-9)
+}
+
+func TestEstimateCost_ModelPricing(t *testing.T) {
+	u := storage.Usage{InputTokens: 1_000_000}
+	assert.InDelta(t, 15.0, estimateCost("claude-opus-4-1-20250805", u), 1e-9)
+	assert.InDelta(t, 5.0, estimateCost("claude-opus-4-5-20251101", u), 1e-9)
+	assert.InDelta(t, 3.0, estimateCost("claude-sonnet-4-5", u), 1e-9)
+	assert.InDelta(t, 1.0, estimateCost("claude-haiku-4-5", u), 1e-9)
+	assert.InDelta(t, 3.0, estimateCost("unknown", u), 1e-9, "알 수 없는 모델은 Sonnet 가격")
+
+	cache := storage.Usage{CacheCreationTokens: 1_000_000, CacheReadTokens: 1_000_000}
+	assert.InDelta(t, 3.75+0.3, estimateCost("claude-sonnet-4-5", cache), 1e-9)
+}
+
+func TestBudget_Exceeded(t *testing.T) {
+	b := Budget{SessionUSD: 5, DailyUSD: 20}
+	assert.Empty(t, b.exceeded(4.99, 0, 10))
+	assert.Contains(t, b.exceeded(5, 0, 10), "session budget")
+	assert.Empty(t, b.exceeded(9, 5, 10), "/continue 후 세션 예산이 다시 부여됨")
+	assert.Contains(t, b.exceeded(3, 0, 20), "daily budget")
+	assert.Empty(t, b.exceeded(3, 3, 25), "확인 이후 지출이 없으면 다시 묻지 않음")
+	assert.Empty(t, Budget{}.exceeded(100, 0, 100), "0이면 비활성화")
+}
+
+func TestRemoveTranscripts(t *testing.T) {
+	mgr, _ := newTestManager(t)
+	writeTranscript(t, mgr.claudeDir, "purged-1", assistantLine("m1", "claude-sonnet-4-5", 1, 1))
+	writeTranscript(t, mgr.claudeDir, "kept-1", assistantLine("m1", "claude-sonnet-4-5", 1, 1))
+
+	removed := mgr.RemoveTranscripts([]string{"purged-1", "missing"})
+
+	assert.Equal(t, 1, removed)
+	assert.Empty(t, mgr.findTranscript("purged-1"))
+	assert.NotEmpty(t, mgr.findTranscript("kept-1"), "다른 세션의 transcript는 보존되어야 함")
+}
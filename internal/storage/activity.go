@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Activity summarizes what happened since a point in time, for the digest
+// email.
+type Activity struct {
+	Started  []*Session // sessions created since
+	Finished []*Session // sessions with a turn that finished with a result since
+	Failed   []*Session // sessions ended in the middle of a turn since
+	Waiting  []*Session // sessions waiting for input or paused by a budget now
+	Usage    Usage      // usage of the turns recorded since
+	// OutboxPending and OutboxFailed count the emails not sent yet and the
+	// emails that gave up retrying, regardless of since.
+	OutboxPending int
+	OutboxFailed  int
+}
+
+// Empty reports whether there is nothing to tell.
+func (a *Activity) Empty() bool {
+	return len(a.Started) == 0 && len(a.Finished) == 0 && len(a.Failed) == 0 &&
+		len(a.Waiting) == 0 && a.Usage.CostUSD == 0 && a.OutboxPending == 0 && a.OutboxFailed == 0
+}
+
+// ActivitySince collects the activity recorded at or after since.
+func (s *Store) ActivitySince(since time.Time) (*Activity, error) {
+	from := formatTime(since)
+	a := &Activity{}
+	var err error
+	if a.Started, err = s.listSessions(`WHERE created_at >= ? ORDER BY created_at, rowid`, from); err != nil {
+		return nil, err
+	}
+	if a.Finished, err = s.sessionsWithTurns("done", from); err != nil {
+		return nil, err
+	}
+	if a.Failed, err = s.sessionsWithTurns("ended", from); err != nil {
+		return nil, err
+	}
+	if a.Waiting, err = s.listSessions(`WHERE status IN ('waiting', 'paused') ORDER BY updated_at, rowid`); err != nil {
+		return nil, err
+	}
+	if a.Usage, err = s.UsageSince(since); err != nil {
+		return nil, err
+	}
+	err = s.q().QueryRowContext(context.Background(),
+		`SELECT COALESCE(SUM(status = 'pending'), 0), COALESCE(SUM(status = 'failed'), 0) FROM outbox`,
+	).Scan(&a.OutboxPending, &a.OutboxFailed)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// sessionsWithTurns returns the sessions with a turn that finished with
+// outcome at or after from.
+func (s *Store) sessionsWithTurns(outcome, from string) ([]*Session, error) {
+	return s.listSessions(
+		`WHERE id IN (SELECT session_id FROM turns WHERE outcome = ? AND finished_at >= ?)
+		 ORDER BY updated_at, rowid`, outcome, from)
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sessionIDs(sessions []*Session) []string {
+	ids := make([]string, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+func TestActivitySince(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	since := now.Add(-24 * time.Hour)
+	old := now.Add(-48 * time.Hour)
+
+	require.NoError(t, store.CreateSession(&Session{ID: "old", Status: "waiting", CreatedAt: old}))
+	createTestSession(t, store, "done")
+	createTestSession(t, store, "timed-out")
+
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "t-old", SessionID: "old", Outcome: "done", CreatedAt: old, FinishedAt: &old,
+		Usage: Usage{CostUSD: 5},
+	}))
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "t-done", SessionID: "done", Outcome: "done", FinishedAt: &now, Usage: Usage{CostUSD: 0.5},
+	}))
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "t-ended", SessionID: "timed-out", Outcome: "ended", FinishedAt: &now, Usage: Usage{CostUSD: 0.25},
+	}))
+
+	for id, status := range map[string]string{"o1": "pending", "o2": "failed", "o3": "sent"} {
+		require.NoError(t, store.CreateOutbox(&OutboxMessage{ID: id, Subject: "s", Body: "b", Status: status}))
+	}
+
+	a, err := store.ActivitySince(since)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"done", "timed-out"}, sessionIDs(a.Started))
+	assert.Equal(t, []string{"done"}, sessionIDs(a.Finished))
+	assert.Equal(t, []string{"timed-out"}, sessionIDs(a.Failed))
+	assert.Equal(t, []string{"old"}, sessionIDs(a.Waiting), "대기 중인 세션은 기간과 무관")
+	assert.InDelta(t, 0.75, a.Usage.CostUSD, 1e-9)
+	assert.Equal(t, 1, a.OutboxPending)
+	assert.Equal(t, 1, a.OutboxFailed)
+	assert.False(t, a.Empty())
+}
+
+func TestActivitySince_Empty(t *testing.T) {
+	store := newTestStore(t)
+	a, err := store.ActivitySince(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.True(t, a.Empty())
+}
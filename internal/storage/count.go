@@ -0,0 +1,32 @@
+package storage
+
+import "context"
+
+// SessionCounts counts sessions by status.
+func (s *Store) SessionCounts() (map[string]int, error) {
+	return s.countBy(`SELECT status, COUNT(*) FROM sessions GROUP BY status`)
+}
+
+// OutboxCounts counts outbox messages by status.
+func (s *Store) OutboxCounts() (map[string]int, error) {
+	return s.countBy(`SELECT status, COUNT(*) FROM outbox GROUP BY status`)
+}
+
+func (s *Store) countBy(query string) (map[string]int, error) {
+	rows, err := s.q().QueryContext(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, err
+		}
+		counts[key] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionCounts_OutboxCounts(t *testing.T) {
+	store := newTestStore(t)
+
+	counts, err := store.SessionCounts()
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+
+	createTestSession(t, store, "a")
+	createTestSession(t, store, "b")
+	require.NoError(t, store.CreateSession(&Session{ID: "c", Status: "waiting"}))
+	require.NoError(t, store.CreateOutbox(&OutboxMessage{ID: "o1", Subject: "s", Body: "b", Status: "pending"}))
+	require.NoError(t, store.CreateOutbox(&OutboxMessage{ID: "o2", Subject: "s", Body: "b", Status: "failed"}))
+	require.NoError(t, store.CreateOutbox(&OutboxMessage{ID: "o3", Subject: "s", Body: "b", Status: "failed"}))
+
+	counts, err = store.SessionCounts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"active": 2, "waiting": 1}, counts)
+
+	counts, err = store.OutboxCounts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"pending": 1, "failed": 2}, counts)
+}
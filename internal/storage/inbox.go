@@ -12,9 +12,9 @@ func (s *Store) EnqueueMessage(msg *InboxMessage) error {
 		msg.CreatedAt = time.Now()
 	}
 	_, err := s.q().ExecContext(context.Background(),
-		`INSERT INTO inbox (id, session_id, body, created_at, processed)
-		 VALUES (?, ?, ?, ?, ?)`,
-		msg.ID, msg.SessionID, msg.Body, formatTime(msg.CreatedAt), boolToInt(msg.Processed),
+		`INSERT INTO inbox (id, session_id, message_id, body, created_at, processed)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		msg.ID, msg.SessionID, nullIfEmpty(msg.MessageID), msg.Body, formatTime(msg.CreatedAt), boolToInt(msg.Processed),
 	)
 	return err
 }
@@ -22,15 +22,16 @@ func (s *Store) EnqueueMessage(msg *InboxMessage) error {
 // DequeueMessage retrieves the oldest unprocessed message for a session.
 func (s *Store) DequeueMessage(sessionID string) (*InboxMessage, error) {
 	row := s.q().QueryRowContext(context.Background(),
-		`SELECT id, session_id, body, created_at, processed
+		`SELECT id, session_id, message_id, body, created_at, processed
 		 FROM inbox WHERE session_id = ? AND processed = 0 ORDER BY created_at ASC LIMIT 1`,
 		sessionID,
 	)
 
 	var msg InboxMessage
+	var messageID sql.NullString
 	var processed int
 
-	err := row.Scan(&msg.ID, &msg.SessionID, &msg.Body, &msg.CreatedAt, &processed)
+	err := row.Scan(&msg.ID, &msg.SessionID, &messageID, &msg.Body, &msg.CreatedAt, &processed)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -38,10 +39,38 @@ func (s *Store) DequeueMessage(sessionID string) (*InboxMessage, error) {
 		return nil, err
 	}
 
+	msg.MessageID = messageID.String
 	msg.Processed = processed != 0
 	return &msg, nil
 }
 
+// ListPendingInbox retrieves the unprocessed messages of every session,
+// oldest first.
+func (s *Store) ListPendingInbox() ([]*InboxMessage, error) {
+	rows, err := s.q().QueryContext(context.Background(),
+		`SELECT id, session_id, message_id, body, created_at, processed
+		 FROM inbox WHERE processed = 0 ORDER BY created_at ASC, rowid ASC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []*InboxMessage
+	for rows.Next() {
+		var msg InboxMessage
+		var messageID sql.NullString
+		var processed int
+		if err := rows.Scan(&msg.ID, &msg.SessionID, &messageID, &msg.Body, &msg.CreatedAt, &processed); err != nil {
+			return nil, err
+		}
+		msg.MessageID = messageID.String
+		msg.Processed = processed != 0
+		msgs = append(msgs, &msg)
+	}
+	return msgs, rows.Err()
+}
+
 // MarkProcessed marks an inbox message as processed.
 func (s *Store) MarkProcessed(id string) error {
 	_, err := s.q().ExecContext(context.Background(),
@@ -56,3 +85,10 @@ func boolToInt(b bool) int {
 	}
 	return 0
 }
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -29,6 +29,20 @@ func TestEnqueueMessage_DequeueMessage(t *testing.T) {
 	assert.False(t, got.Processed)
 }
 
+func TestEnqueueMessage_MessageID(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+
+	require.NoError(t, store.EnqueueMessage(&InboxMessage{
+		ID: "inbox-1", SessionID: "sess-1", MessageID: "<reply@mail>", Body: "답장",
+	}))
+
+	got, err := store.DequeueMessage("sess-1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "<reply@mail>", got.MessageID)
+}
+
 func TestDequeueMessage_FIFO(t *testing.T) {
 	store := newTestStore(t)
 	createTestSession(t, store, "sess-1")
@@ -100,3 +114,19 @@ func TestMarkProcessed(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, got, "처리 완료된 메시지는 dequeue되면 안 됨")
 }
+
+func TestListPendingInbox(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+	createTestSession(t, store, "sess-2")
+
+	require.NoError(t, store.EnqueueMessage(&InboxMessage{ID: "in-1", SessionID: "sess-1", Body: "first"}))
+	require.NoError(t, store.EnqueueMessage(&InboxMessage{ID: "in-2", SessionID: "sess-2", Body: "second"}))
+	require.NoError(t, store.EnqueueMessage(&InboxMessage{ID: "in-3", SessionID: "sess-1", Body: "done", Processed: true}))
+
+	msgs, err := store.ListPendingInbox()
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "in-1", msgs[0].ID)
+	assert.Equal(t, "in-2", msgs[1].ID)
+}
@@ -0,0 +1,20 @@
+CREATE TABLE turns (
+    id                    TEXT PRIMARY KEY,
+    session_id            TEXT NOT NULL,
+    seq                   INTEGER NOT NULL,
+    model                 TEXT NOT NULL DEFAULT '',
+    input_tokens          INTEGER NOT NULL DEFAULT 0,
+    output_tokens         INTEGER NOT NULL DEFAULT 0,
+    cache_creation_tokens INTEGER NOT NULL DEFAULT 0,
+    cache_read_tokens     INTEGER NOT NULL DEFAULT 0,
+    cost_usd              REAL NOT NULL DEFAULT 0,
+    created_at            DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES sessions(id),
+    UNIQUE (session_id, seq)
+);
+
+CREATE INDEX idx_turns_created_at ON turns(created_at);
+
+ALTER TABLE sessions ADD COLUMN budget_ack_usd REAL NOT NULL DEFAULT 0;
+
+INSERT INTO schema_version (version) VALUES (2);
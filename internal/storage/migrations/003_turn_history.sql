@@ -0,0 +1,15 @@
+ALTER TABLE turns ADD COLUMN prompt TEXT;
+ALTER TABLE turns ADD COLUMN result TEXT;
+ALTER TABLE turns ADD COLUMN started_at DATETIME;
+ALTER TABLE turns ADD COLUMN finished_at DATETIME;
+ALTER TABLE turns ADD COLUMN outcome TEXT NOT NULL DEFAULT 'running';
+ALTER TABLE turns ADD COLUMN source_message_id TEXT;
+
+-- Turns recorded before history existed were written when they finished.
+UPDATE turns SET started_at = created_at, finished_at = created_at, outcome = 'done';
+
+CREATE INDEX idx_turns_session_outcome ON turns(session_id, outcome);
+
+ALTER TABLE inbox ADD COLUMN message_id TEXT;
+
+INSERT INTO schema_version (version) VALUES (3);
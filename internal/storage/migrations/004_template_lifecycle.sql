@@ -0,0 +1,10 @@
+ALTER TABLE template ADD COLUMN expires_at DATETIME;
+ALTER TABLE template ADD COLUMN revoked INTEGER NOT NULL DEFAULT 0;
+
+-- Templates sent before expiry existed get the default lifetime of 30 days,
+-- and all but the most recent one are revoked.
+UPDATE template SET expires_at = datetime(created_at, '+30 days');
+UPDATE template SET revoked = 1
+ WHERE id <> (SELECT id FROM template ORDER BY created_at DESC LIMIT 1);
+
+INSERT INTO schema_version (version) VALUES (4);
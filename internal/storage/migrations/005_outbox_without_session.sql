@@ -0,0 +1,28 @@
+-- Template emails are queued in the outbox too, so session_id becomes optional.
+-- SQLite cannot drop NOT NULL in place; rebuild the table.
+CREATE TABLE outbox_new (
+    id              TEXT PRIMARY KEY,
+    session_id      TEXT,
+    message_id      TEXT,
+    subject         TEXT NOT NULL,
+    body            TEXT NOT NULL,
+    attachments     TEXT,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    retry_count     INTEGER NOT NULL DEFAULT 0,
+    next_retry_at   DATETIME,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at         DATETIME,
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+);
+
+INSERT INTO outbox_new (id, session_id, message_id, subject, body, attachments, status,
+                        retry_count, next_retry_at, created_at, sent_at)
+SELECT id, session_id, message_id, subject, body, attachments, status,
+       retry_count, next_retry_at, created_at, sent_at
+FROM outbox;
+
+DROP TABLE outbox;
+ALTER TABLE outbox_new RENAME TO outbox;
+CREATE INDEX idx_outbox_status ON outbox(status);
+
+INSERT INTO schema_version (version) VALUES (5);
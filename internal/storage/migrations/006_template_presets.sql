@@ -0,0 +1,9 @@
+-- Named templates carry the preset (directory, model, instructions,
+-- permission mode) from config; '' is the default template.
+ALTER TABLE template ADD COLUMN name TEXT NOT NULL DEFAULT '';
+
+-- Permission mode the session was started with, reused on resume.
+-- '' skips permission checks.
+ALTER TABLE sessions ADD COLUMN permission_mode TEXT NOT NULL DEFAULT '';
+
+INSERT INTO schema_version (version) VALUES (6);
@@ -0,0 +1,10 @@
+-- Settings given as directives (Name:, Timeout:, Effort:, Notify:, CC:,
+-- Budget:) in the email that started the session.
+ALTER TABLE sessions ADD COLUMN name TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN timeout_min INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN effort TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN notify TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN cc TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN budget_usd REAL NOT NULL DEFAULT 0;
+
+INSERT INTO schema_version (version) VALUES (7);
@@ -0,0 +1,9 @@
+-- Small runtime values that must survive a restart, such as when the last
+-- digest was sent.
+CREATE TABLE state (
+    key        TEXT PRIMARY KEY,
+    value      TEXT NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO schema_version (version) VALUES (8);
@@ -0,0 +1,5 @@
+-- Reminder emails sent while a turn waited for input, so a restart neither
+-- repeats nor resets them.
+ALTER TABLE turns ADD COLUMN reminders_sent INTEGER NOT NULL DEFAULT 0;
+
+INSERT INTO schema_version (version) VALUES (9);
@@ -0,0 +1,5 @@
+-- Failed messages already listed in a dead letter summary, so each one is
+-- reported once. Requeueing a message clears the flag.
+ALTER TABLE outbox ADD COLUMN reported INTEGER NOT NULL DEFAULT 0;
+
+INSERT INTO schema_version (version) VALUES (10);
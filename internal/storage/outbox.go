@@ -3,19 +3,27 @@ package storage
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"time"
 )
 
+// ErrAmbiguousID is returned by FindOutbox when an ID prefix matches more
+// than one message.
+var ErrAmbiguousID = errors.New("ID prefix matches more than one message")
+
+const outboxColumns = `id, session_id, message_id, subject, body, attachments, status, retry_count,
+	next_retry_at, created_at, sent_at`
+
 // CreateOutbox inserts a new outbox message.
+// An empty SessionID stores a message that belongs to no session (e.g. a template).
 func (s *Store) CreateOutbox(msg *OutboxMessage) error {
 	if msg.CreatedAt.IsZero() {
 		msg.CreatedAt = time.Now()
 	}
 	_, err := s.q().ExecContext(context.Background(),
-		`INSERT INTO outbox (id, session_id, message_id, subject, body, attachments, status, retry_count, next_retry_at, created_at, sent_at)
+		`INSERT INTO outbox (`+outboxColumns+`)
 		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		msg.ID, msg.SessionID, msg.MessageID, msg.Subject, msg.Body, msg.Attachments,
+		msg.ID, nullIfEmpty(msg.SessionID), msg.MessageID, msg.Subject, msg.Body, msg.Attachments,
 		msg.Status, msg.RetryCount, formatNullableTime(msg.NextRetryAt),
 		formatTime(msg.CreatedAt), formatNullableTime(msg.SentAt),
 	)
@@ -25,9 +33,98 @@ func (s *Store) CreateOutbox(msg *OutboxMessage) error {
 // GetPendingOutbox retrieves outbox messages ready to be sent.
 // Conditions: status=pending AND (next_retry_at IS NULL OR next_retry_at <= now).
 func (s *Store) GetPendingOutbox() ([]*OutboxMessage, error) {
+	return s.listOutbox(`WHERE status = 'pending' AND (next_retry_at IS NULL OR next_retry_at <= datetime('now'))`)
+}
+
+// ListOutbox retrieves the most recent outbox messages, newest first.
+// An empty status lists messages of every status; limit <= 0 lists all.
+func (s *Store) ListOutbox(status string, limit int) ([]*OutboxMessage, error) {
+	where, args := `WHERE 1 = 1`, []any{}
+	if status != "" {
+		where, args = `WHERE status = ?`, append(args, status)
+	}
+	if limit > 0 {
+		return s.listOutbox(where+` ORDER BY created_at DESC, rowid DESC LIMIT ?`, append(args, limit)...)
+	}
+	return s.listOutbox(where+` ORDER BY created_at DESC, rowid DESC`, args...)
+}
+
+// GetOutbox retrieves an outbox message by ID.
+func (s *Store) GetOutbox(id string) (*OutboxMessage, error) {
+	row := s.q().QueryRowContext(context.Background(),
+		`SELECT `+outboxColumns+` FROM outbox WHERE id = ?`, id,
+	)
+	return scanOutbox(row)
+}
+
+// FindOutbox retrieves an outbox message by ID or unique ID prefix.
+// Returns sql.ErrNoRows if none matches and ErrAmbiguousID if several do.
+func (s *Store) FindOutbox(ref string) (*OutboxMessage, error) {
+	msgs, err := s.listOutbox(`WHERE substr(id, 1, length(?)) = ? ORDER BY id = ? DESC LIMIT 2`, ref, ref, ref)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(msgs) == 0:
+		return nil, sql.ErrNoRows
+	case msgs[0].ID == ref || len(msgs) == 1:
+		return msgs[0], nil
+	default:
+		return nil, ErrAmbiguousID
+	}
+}
+
+// RequeueOutbox puts a message back in the queue with a fresh retry count,
+// so the next flush sends it.
+func (s *Store) RequeueOutbox(id string) error {
+	res, err := s.q().ExecContext(context.Background(),
+		`UPDATE outbox SET status = 'pending', retry_count = 0, next_retry_at = NULL, reported = 0
+		 WHERE id = ?`, id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RequeueFailedOutbox puts every failed message back in the queue.
+// Returns the number of messages requeued.
+func (s *Store) RequeueFailedOutbox() (int64, error) {
+	return s.exec(`UPDATE outbox SET status = 'pending', retry_count = 0, next_retry_at = NULL, reported = 0
+		WHERE status = 'failed'`)
+}
+
+// DropFailedOutbox deletes the failed message with the given ID, or every
+// failed message when id is empty. Returns the number of messages deleted.
+func (s *Store) DropFailedOutbox(id string) (int64, error) {
+	if id == "" {
+		return s.exec(`DELETE FROM outbox WHERE status = 'failed'`)
+	}
+	return s.exec(`DELETE FROM outbox WHERE status = 'failed' AND id = ?`, id)
+}
+
+// ListUnreportedFailed retrieves failed messages not yet listed in a dead
+// letter summary, oldest first.
+func (s *Store) ListUnreportedFailed() ([]*OutboxMessage, error) {
+	return s.listOutbox(`WHERE status = 'failed' AND reported = 0 ORDER BY created_at, rowid`)
+}
+
+// MarkReported records that the given failed messages were reported.
+func (s *Store) MarkReported(ids []string) error {
+	for _, id := range ids {
+		if _, err := s.exec(`UPDATE outbox SET reported = 1 WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) listOutbox(where string, args ...any) ([]*OutboxMessage, error) {
 	rows, err := s.q().QueryContext(context.Background(),
-		`SELECT id, session_id, message_id, subject, body, attachments, status, retry_count, next_retry_at, created_at, sent_at
-		 FROM outbox WHERE status = 'pending' AND (next_retry_at IS NULL OR next_retry_at <= datetime('now'))`,
+		`SELECT `+outboxColumns+` FROM outbox `+where, args...,
 	)
 	if err != nil {
 		return nil, err
@@ -72,46 +169,32 @@ func (s *Store) UpdateRetry(id string, retryCount int, nextRetryAt *time.Time) e
 }
 
 // GetSessionIDByOutboxMessageID looks up the session ID for an outbox message ID.
+// Returns an empty string if no session's message has that ID.
 func (s *Store) GetSessionIDByOutboxMessageID(messageID string) (string, error) {
-	var sessionID string
+	var sessionID sql.NullString
 	err := s.q().QueryRowContext(context.Background(),
-		`SELECT session_id FROM outbox WHERE message_id = ? LIMIT 1`, messageID,
+		`SELECT session_id FROM outbox WHERE message_id = ? AND session_id IS NOT NULL LIMIT 1`, messageID,
 	).Scan(&sessionID)
 	if err == sql.ErrNoRows {
 		return "", nil
 	}
-	return sessionID, err
-}
-
-// PurgeOldData removes old sent/processed data for ended sessions.
-func (s *Store) PurgeOldData(retentionDays int) error {
-	_, err := s.q().ExecContext(context.Background(), fmt.Sprintf(
-		`DELETE FROM outbox WHERE session_id IN (SELECT id FROM sessions WHERE status = 'ended')
-		 AND status = 'sent' AND sent_at < datetime('now', '-%d days')`, retentionDays),
-	)
-	if err != nil {
-		return err
-	}
-	_, err = s.q().ExecContext(context.Background(), fmt.Sprintf(
-		`DELETE FROM inbox WHERE session_id IN (SELECT id FROM sessions WHERE status = 'ended')
-		 AND processed = 1 AND created_at < datetime('now', '-%d days')`, retentionDays),
-	)
-	return err
+	return sessionID.String, err
 }
 
 func scanOutbox(row scanner) (*OutboxMessage, error) {
 	var msg OutboxMessage
-	var messageID, attachments sql.NullString
+	var sessionID, messageID, attachments sql.NullString
 	var nextRetryAt, sentAt sql.NullTime
 
 	err := row.Scan(
-		&msg.ID, &msg.SessionID, &messageID, &msg.Subject, &msg.Body, &attachments,
+		&msg.ID, &sessionID, &messageID, &msg.Subject, &msg.Body, &attachments,
 		&msg.Status, &msg.RetryCount, &nextRetryAt, &msg.CreatedAt, &sentAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	msg.SessionID = sessionID.String
 	if messageID.Valid {
 		msg.MessageID = &messageID.String
 	}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"database/sql"
 	"testing"
 	"time"
 
@@ -31,6 +32,24 @@ func TestCreateOutbox_GetPendingOutbox(t *testing.T) {
 	assert.Equal(t, "pending", pending[0].Status)
 }
 
+func TestCreateOutbox_WithoutSession(t *testing.T) {
+	store := newTestStore(t)
+	msgID := "<template@claude-postman>"
+
+	require.NoError(t, store.CreateOutbox(&OutboxMessage{
+		ID: "tmpl-out", MessageID: &msgID, Subject: "[claude-postman] New Session", Body: "body", Status: "pending",
+	}))
+
+	pending, err := store.GetPendingOutbox()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Empty(t, pending[0].SessionID, "세션 없는 메시지(템플릿)도 저장 가능해야 함")
+
+	sessionID, err := store.GetSessionIDByOutboxMessageID(msgID)
+	require.NoError(t, err)
+	assert.Empty(t, sessionID, "템플릿 메시지는 세션으로 매칭되지 않아야 함")
+}
+
 func TestMarkSent(t *testing.T) {
 	store := newTestStore(t)
 	createTestSession(t, store, "sess-1")
@@ -198,7 +217,7 @@ func TestPurgeOldData(t *testing.T) {
 	require.NoError(t, err)
 
 	// PurgeOldData 실행 (30일 보존)
-	err = store.PurgeOldData(30)
+	_, err = store.PurgeOldData(30)
 	require.NoError(t, err)
 
 	// 검증
@@ -229,3 +248,119 @@ func TestPurgeOldData(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 1, count, "미처리 inbox는 보존되어야 함")
 }
+
+func TestListOutbox_RequeueOutbox(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	for i, status := range []string{"sent", "failed", "pending"} {
+		require.NoError(t, store.CreateOutbox(&OutboxMessage{
+			ID: status, Subject: "s", Body: "b", Status: status, RetryCount: 5,
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	all, err := store.ListOutbox("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", all[0].ID, "최신순")
+	assert.Len(t, all, 3)
+
+	latest, err := store.ListOutbox("", 1)
+	require.NoError(t, err)
+	assert.Len(t, latest, 1)
+
+	failed, err := store.ListOutbox("failed", 0)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "failed", failed[0].ID)
+
+	require.NoError(t, store.RequeueOutbox("failed"))
+	msg, err := store.GetOutbox("failed")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", msg.Status)
+	assert.Equal(t, 0, msg.RetryCount)
+	assert.Nil(t, msg.NextRetryAt)
+
+	assert.Error(t, store.RequeueOutbox("missing"), "없는 메시지")
+}
+
+func TestFindOutbox(t *testing.T) {
+	store := newTestStore(t)
+	for _, id := range []string{"abc1", "abc2", "abd"} {
+		require.NoError(t, store.CreateOutbox(&OutboxMessage{ID: id, Subject: "s", Body: "b", Status: "failed"}))
+	}
+
+	msg, err := store.FindOutbox("abd")
+	require.NoError(t, err)
+	assert.Equal(t, "abd", msg.ID)
+
+	msg, err = store.FindOutbox("abc2")
+	require.NoError(t, err)
+	assert.Equal(t, "abc2", msg.ID)
+
+	_, err = store.FindOutbox("abc")
+	assert.ErrorIs(t, err, ErrAmbiguousID)
+
+	_, err = store.FindOutbox("zzz")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRequeueFailedOutbox_DropFailedOutbox(t *testing.T) {
+	store := newTestStore(t)
+	for _, id := range []string{"f1", "f2", "f3"} {
+		require.NoError(t, store.CreateOutbox(&OutboxMessage{ID: id, Subject: "s", Body: "b", Status: "failed", RetryCount: 5}))
+	}
+	require.NoError(t, store.CreateOutbox(&OutboxMessage{ID: "sent", Subject: "s", Body: "b", Status: "sent"}))
+
+	n, err := store.DropFailedOutbox("sent")
+	require.NoError(t, err)
+	assert.Zero(t, n, "failed가 아닌 메시지는 지우지 않음")
+
+	n, err = store.DropFailedOutbox("f1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+
+	n, err = store.RequeueFailedOutbox()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	pending, err := store.ListOutbox("pending", 0)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Zero(t, pending[0].RetryCount)
+
+	require.NoError(t, store.MarkFailed("f2", 5, nil))
+	n, err = store.DropFailedOutbox("")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	all, err := store.ListOutbox("", 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 2, "sent와 pending인 f3만 남음")
+}
+
+func TestListUnreportedFailed_MarkReported(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	for i, id := range []string{"old", "new"} {
+		require.NoError(t, store.CreateOutbox(&OutboxMessage{
+			ID: id, Subject: "s", Body: "b", Status: "failed", CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+	require.NoError(t, store.CreateOutbox(&OutboxMessage{ID: "pending", Subject: "s", Body: "b", Status: "pending"}))
+
+	msgs, err := store.ListUnreportedFailed()
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "old", msgs[0].ID, "오래된 순")
+
+	require.NoError(t, store.MarkReported([]string{"old", "new"}))
+	msgs, err = store.ListUnreportedFailed()
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+
+	// 다시 대기열에 넣었다가 또 실패하면 다시 보고
+	require.NoError(t, store.RequeueOutbox("old"))
+	require.NoError(t, store.MarkFailed("old", 5, nil))
+	msgs, err = store.ListUnreportedFailed()
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "old", msgs[0].ID)
+}
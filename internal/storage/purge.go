@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// PurgeStats reports how many rows PurgeOldData deleted.
+type PurgeStats struct {
+	Outbox    int64
+	Inbox     int64
+	Templates int64
+	Sessions  int64
+	// SessionIDs lists the ended sessions that were deleted.
+	SessionIDs []string
+}
+
+// PurgeOldData deletes data older than retentionDays in one transaction:
+//   - sent outbox rows of ended sessions or of no session (templates)
+//   - processed inbox rows of ended sessions
+//   - template records, except the most recent one of each name
+//   - ended sessions (with their turns, inbox and outbox) last updated before the cutoff
+//
+// Outbox rows of live sessions are kept because replies are matched to
+// sessions by their Message-ID.
+func (s *Store) PurgeOldData(retentionDays int) (*PurgeStats, error) {
+	cutoff := fmt.Sprintf("-%d days", retentionDays)
+	stats := &PurgeStats{}
+	err := s.Tx(context.Background(), func(tx *Store) error {
+		var err error
+		if stats.Outbox, err = tx.exec(
+			`DELETE FROM outbox
+			 WHERE (session_id IS NULL OR session_id IN (SELECT id FROM sessions WHERE status = 'ended'))
+			 AND status = 'sent' AND sent_at < datetime('now', ?)`, cutoff,
+		); err != nil {
+			return err
+		}
+		if stats.Inbox, err = tx.exec(
+			`DELETE FROM inbox WHERE session_id IN (SELECT id FROM sessions WHERE status = 'ended')
+			 AND processed = 1 AND created_at < datetime('now', ?)`, cutoff,
+		); err != nil {
+			return err
+		}
+		if stats.Templates, err = tx.exec(
+			`DELETE FROM template WHERE created_at < datetime('now', ?)
+			 AND created_at < (SELECT MAX(created_at) FROM template t WHERE t.name = template.name)`, cutoff,
+		); err != nil {
+			return err
+		}
+		return tx.purgeEndedSessions(cutoff, stats)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (s *Store) purgeEndedSessions(cutoff string, stats *PurgeStats) error {
+	rows, err := s.q().QueryContext(context.Background(),
+		`SELECT id FROM sessions WHERE status = 'ended' AND updated_at < datetime('now', ?)`, cutoff,
+	)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		for _, table := range []string{"turns", "inbox", "outbox"} {
+			if _, err := s.exec(`DELETE FROM `+table+` WHERE session_id = ?`, id); err != nil {
+				return err
+			}
+		}
+		if _, err := s.exec(`DELETE FROM sessions WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
+	stats.Sessions = int64(len(ids))
+	stats.SessionIDs = ids
+	return nil
+}
+
+// Compact reclaims the space freed by deletions and refreshes the query
+// planner statistics. It must not run inside a transaction.
+func (s *Store) Compact() error {
+	stmts := []string{"VACUUM", "PRAGMA optimize"}
+	if s.fts {
+		stmts = append([]string{`INSERT INTO turns_fts (turns_fts) VALUES ('optimize')`}, stmts...)
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.ExecContext(context.Background(), stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// exec runs a statement and returns the number of affected rows.
+func (s *Store) exec(query string, args ...any) (int64, error) {
+	res, err := s.q().ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
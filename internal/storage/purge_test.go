@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeOldData_EndedSessions(t *testing.T) {
+	store := newTestStore(t)
+
+	// 오래전에 종료된 세션 (purge 대상)
+	old := createTestSession(t, store, "old-ended")
+	require.NoError(t, store.CreateTurn(&Turn{ID: "old-turn", SessionID: "old-ended", Prompt: "old prompt"}))
+	require.NoError(t, store.CreateOutbox(&OutboxMessage{
+		ID: "old-pending", SessionID: "old-ended", Subject: "s", Body: "b", Status: "pending",
+	}))
+	old.Status = "ended"
+	require.NoError(t, store.UpdateSession(old))
+	_, err := store.db.Exec("UPDATE sessions SET updated_at = datetime('now', '-60 days') WHERE id = ?", "old-ended")
+	require.NoError(t, err)
+
+	// 최근 종료된 세션과 오래된 active 세션 (보존)
+	recent := createTestSession(t, store, "recent-ended")
+	recent.Status = "ended"
+	require.NoError(t, store.UpdateSession(recent))
+	createTestSession(t, store, "old-active")
+	_, err = store.db.Exec("UPDATE sessions SET updated_at = datetime('now', '-60 days') WHERE id = ?", "old-active")
+	require.NoError(t, err)
+
+	stats, err := store.PurgeOldData(30)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Sessions)
+	assert.Equal(t, []string{"old-ended"}, stats.SessionIDs)
+
+	_, err = store.GetSession("old-ended")
+	assert.Error(t, err, "오래된 ended 세션은 삭제되어야 함")
+	turns, err := store.ListTurns("old-ended")
+	require.NoError(t, err)
+	assert.Empty(t, turns, "세션의 턴 기록도 삭제되어야 함")
+
+	_, err = store.GetSession("recent-ended")
+	assert.NoError(t, err, "최근 ended 세션은 보존")
+	_, err = store.GetSession("old-active")
+	assert.NoError(t, err, "active 세션은 보존")
+}
+
+func TestPurgeOldData_KeepsLatestTemplate(t *testing.T) {
+	store := newTestStore(t)
+	old := time.Now().AddDate(0, 0, -60)
+	require.NoError(t, store.SaveTemplate(&Template{ID: "t1", MessageID: "<t1@mail>", CreatedAt: old}))
+	require.NoError(t, store.SaveTemplate(&Template{ID: "t2", MessageID: "<t2@mail>", CreatedAt: old.Add(time.Hour)}))
+	require.NoError(t, store.SaveTemplate(&Template{ID: "t3", MessageID: "<t3@mail>", Name: "infra", CreatedAt: old}))
+
+	stats, err := store.PurgeOldData(30)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Templates)
+
+	tmpl, err := store.GetTemplateByMessageID("<t3@mail>")
+	require.NoError(t, err)
+	assert.NotNil(t, tmpl, "이름별 최신 템플릿도 보존되어야 함")
+
+	tmpl, err = store.GetTemplateByMessageID("<t1@mail>")
+	require.NoError(t, err)
+	assert.Nil(t, tmpl, "오래된 템플릿은 삭제되어야 함")
+	tmpl, err = store.GetTemplateByMessageID("<t2@mail>")
+	require.NoError(t, err)
+	assert.NotNil(t, tmpl, "가장 최근 템플릿은 오래되어도 보존되어야 함")
+}
+
+func TestCompact(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+	require.NoError(t, store.CreateTurn(&Turn{ID: "turn-1", SessionID: "sess-1", Prompt: "hello"}))
+
+	require.NoError(t, store.Compact())
+
+	results, err := store.Search("hello", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 1, "compact 후에도 검색이 동작해야 함")
+}
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Snippet highlighting markers (Markdown bold).
+const (
+	snippetMark    = "**"
+	snippetContext = 60 // characters shown on each side of a match (LIKE fallback)
+)
+
+// SearchResult is a turn matching a search query.
+type SearchResult struct {
+	SessionID  string
+	WorkingDir string
+	Status     string // session status
+	TurnID     string
+	Seq        int
+	StartedAt  time.Time
+	Snippet    string
+}
+
+// searchTriggers keep turns_fts in sync with the turns table.
+const searchTriggers = `
+CREATE TRIGGER turns_fts_ai AFTER INSERT ON turns BEGIN
+    INSERT INTO turns_fts (rowid, prompt, result, working_dir)
+    SELECT NEW.rowid, NEW.prompt, NEW.result, working_dir FROM sessions WHERE id = NEW.session_id;
+END;
+CREATE TRIGGER turns_fts_au AFTER UPDATE OF prompt, result ON turns BEGIN
+    DELETE FROM turns_fts WHERE rowid = OLD.rowid;
+    INSERT INTO turns_fts (rowid, prompt, result, working_dir)
+    SELECT NEW.rowid, NEW.prompt, NEW.result, working_dir FROM sessions WHERE id = NEW.session_id;
+END;
+CREATE TRIGGER turns_fts_ad AFTER DELETE ON turns BEGIN
+    DELETE FROM turns_fts WHERE rowid = OLD.rowid;
+END;`
+
+// setupSearch creates the FTS5 index over turns when SQLite was built with
+// FTS5 (the sqlite_fts5 build tag). The index lives outside the versioned
+// migrations because it depends on the build: without FTS5 the sync triggers
+// are dropped (they would break every write to turns) and Search falls back
+// to LIKE. When FTS5 becomes available again, the index is rebuilt.
+func (s *Store) setupSearch() error {
+	ctx := context.Background()
+	var enabled bool
+	if err := s.db.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&enabled); err != nil {
+		return err
+	}
+	s.fts = enabled
+
+	return s.Tx(ctx, func(tx *Store) error {
+		if !enabled {
+			for _, name := range []string{"turns_fts_ai", "turns_fts_au", "turns_fts_ad"} {
+				if _, err := tx.q().ExecContext(ctx, `DROP TRIGGER IF EXISTS `+name); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		var triggers int
+		if err := tx.q().QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'turns_fts_%'`,
+		).Scan(&triggers); err != nil {
+			return err
+		}
+		if triggers == 3 {
+			return nil
+		}
+
+		stmts := []string{
+			`CREATE VIRTUAL TABLE IF NOT EXISTS turns_fts USING fts5(prompt, result, working_dir)`,
+			`DELETE FROM turns_fts`,
+			`INSERT INTO turns_fts (rowid, prompt, result, working_dir)
+			 SELECT t.rowid, t.prompt, t.result, s.working_dir FROM turns t JOIN sessions s ON s.id = t.session_id`,
+			`DROP TRIGGER IF EXISTS turns_fts_ai`,
+			`DROP TRIGGER IF EXISTS turns_fts_au`,
+			`DROP TRIGGER IF EXISTS turns_fts_ad`,
+			searchTriggers,
+		}
+		for _, stmt := range stmts {
+			if _, err := tx.q().ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Search finds turns whose prompt, result or session working directory
+// match all words of query, best matches first.
+// A trailing * on a word matches it as a prefix.
+func (s *Store) Search(query string, limit int) ([]*SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	if s.fts {
+		return s.searchFTS(terms, limit)
+	}
+	return s.searchLike(terms, limit)
+}
+
+func (s *Store) searchFTS(terms []string, limit int) ([]*SearchResult, error) {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		prefix := strings.HasSuffix(term, "*")
+		term = `"` + strings.ReplaceAll(strings.TrimSuffix(term, "*"), `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		quoted[i] = term
+	}
+
+	rows, err := s.q().QueryContext(context.Background(),
+		`SELECT t.session_id, s.working_dir, s.status, t.id, t.seq, t.started_at,
+		        snippet(turns_fts, -1, ?, ?, '…', 16)
+		 FROM turns_fts
+		 JOIN turns t ON t.rowid = turns_fts.rowid
+		 JOIN sessions s ON s.id = t.session_id
+		 WHERE turns_fts MATCH ?
+		 ORDER BY rank LIMIT ?`,
+		snippetMark, snippetMark, strings.Join(quoted, " "), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.SessionID, &r.WorkingDir, &r.Status, &r.TurnID, &r.Seq, &r.StartedAt, &r.Snippet); err != nil {
+			return nil, err
+		}
+		r.Snippet = collapseSpace(r.Snippet)
+		results = append(results, &r)
+	}
+	return results, rows.Err()
+}
+
+// searchLike is the fallback for SQLite builds without FTS5.
+// Matching is case-insensitive for ASCII only; newest turns come first.
+func (s *Store) searchLike(terms []string, limit int) ([]*SearchResult, error) {
+	const text = `(COALESCE(t.prompt, '') || ' ' || COALESCE(t.result, '') || ' ' || s.working_dir)`
+	var where []string
+	var args []any
+	for _, term := range terms {
+		where = append(where, text+` LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(strings.TrimSuffix(term, "*"))+"%")
+	}
+	args = append(args, limit)
+
+	rows, err := s.q().QueryContext(context.Background(),
+		`SELECT t.session_id, s.working_dir, s.status, t.id, t.seq, t.started_at,
+		        COALESCE(t.prompt, ''), COALESCE(t.result, '')
+		 FROM turns t JOIN sessions s ON s.id = t.session_id
+		 WHERE `+strings.Join(where, " AND ")+`
+		 ORDER BY t.started_at DESC, t.seq DESC LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	for rows.Next() {
+		var r SearchResult
+		var prompt, result string
+		if err := rows.Scan(&r.SessionID, &r.WorkingDir, &r.Status, &r.TurnID, &r.Seq, &r.StartedAt, &prompt, &result); err != nil {
+			return nil, err
+		}
+		r.Snippet = likeSnippet(strings.TrimSuffix(terms[0], "*"), prompt, result, r.WorkingDir)
+		results = append(results, &r)
+	}
+	return results, rows.Err()
+}
+
+// GroupBySession groups search results by session, keeping the order in
+// which each session first appears.
+func GroupBySession(results []*SearchResult) [][]*SearchResult {
+	index := make(map[string]int)
+	var groups [][]*SearchResult
+	for _, r := range results {
+		i, ok := index[r.SessionID]
+		if !ok {
+			i = len(groups)
+			index[r.SessionID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], r)
+	}
+	return groups
+}
+
+// searchTerms splits a query into words, dropping surrounding quotes.
+func searchTerms(query string) []string {
+	var terms []string
+	for _, f := range strings.Fields(query) {
+		f = strings.Trim(f, `"'`)
+		if f != "" && f != "*" {
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+// likeSnippet returns the text around the first match of term in the first
+// field containing it, with the match highlighted.
+func likeSnippet(term string, fields ...string) string {
+	lowerTerm := asciiLower(term)
+	for _, field := range fields {
+		idx := strings.Index(asciiLower(field), lowerTerm)
+		if idx < 0 {
+			continue
+		}
+		end := idx + len(term)
+		start := idx
+		for n := 0; start > 0 && n < snippetContext; n++ {
+			_, size := utf8.DecodeLastRuneInString(field[:start])
+			start -= size
+		}
+		stop := end
+		for n := 0; stop < len(field) && n < snippetContext; n++ {
+			_, size := utf8.DecodeRuneInString(field[stop:])
+			stop += size
+		}
+
+		var b strings.Builder
+		if start > 0 {
+			b.WriteString("…")
+		}
+		b.WriteString(field[start:idx])
+		b.WriteString(snippetMark + field[idx:end] + snippetMark)
+		b.WriteString(field[end:stop])
+		if stop < len(field) {
+			b.WriteString("…")
+		}
+		return collapseSpace(b.String())
+	}
+	return ""
+}
+
+// asciiLower lowercases ASCII letters only, like SQLite's LIKE, so byte
+// offsets stay valid for the original string.
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedSearchTurns(t *testing.T, store *Store) {
+	t.Helper()
+	require.NoError(t, store.CreateSession(&Session{
+		ID: "sess-db", TmuxName: "session-sess-db", WorkingDir: "/home/me/projects/api", Model: "sonnet", Status: "ended",
+	}))
+	require.NoError(t, store.CreateSession(&Session{
+		ID: "sess-ui", TmuxName: "session-sess-ui", WorkingDir: "/home/me/projects/web", Model: "sonnet", Status: "idle",
+	}))
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "turn-1", SessionID: "sess-db", Prompt: "The migration fails on startup",
+		Result: "Fixed the migration bug: the version table was read before it existed.",
+	}))
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "turn-2", SessionID: "sess-ui", Prompt: "Make the button blue",
+	}))
+}
+
+func TestSearch_MatchesPromptAndResult(t *testing.T) {
+	store := newTestStore(t)
+	seedSearchTurns(t, store)
+
+	results, err := store.Search("migration bug", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "sess-db", results[0].SessionID)
+	assert.Equal(t, "turn-1", results[0].TurnID)
+	assert.Equal(t, 1, results[0].Seq)
+	assert.Equal(t, "/home/me/projects/api", results[0].WorkingDir)
+	assert.Equal(t, "ended", results[0].Status)
+	assert.Contains(t, results[0].Snippet, "**", "매칭 부분이 강조되어야 함")
+	assert.NotContains(t, results[0].Snippet, "\n")
+}
+
+func TestSearch_MatchesWorkingDir(t *testing.T) {
+	store := newTestStore(t)
+	seedSearchTurns(t, store)
+
+	results, err := store.Search("web", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "sess-ui", results[0].SessionID)
+}
+
+func TestSearch_IndexFollowsUpdates(t *testing.T) {
+	store := newTestStore(t)
+	seedSearchTurns(t, store)
+
+	open, err := store.GetOpenTurn("sess-ui")
+	require.NoError(t, err)
+	open.Result = "Changed the stylesheet"
+	open.Outcome = "done"
+	require.NoError(t, store.FinishTurn(open))
+
+	results, err := store.Search("stylesheet", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1, "완료된 턴의 결과도 검색되어야 함")
+	assert.Equal(t, "turn-2", results[0].TurnID)
+}
+
+func TestSearch_NoMatchOrEmptyQuery(t *testing.T) {
+	store := newTestStore(t)
+	seedSearchTurns(t, store)
+
+	results, err := store.Search("kubernetes", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = store.Search(`  "" `, 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSearch_SpecialCharacters(t *testing.T) {
+	store := newTestStore(t)
+	seedSearchTurns(t, store)
+
+	// FTS5 문법 문자나 LIKE 와일드카드가 오류를 일으키지 않아야 함
+	for _, q := range []string{"fix-bug", `50%`, "a_b", `"unterminated`, "NOT AND OR"} {
+		_, err := store.Search(q, 10)
+		assert.NoError(t, err, q)
+	}
+}
+
+func TestLikeSnippet(t *testing.T) {
+	got := likeSnippet("Bug", "no match here", "the migration bug\nwas fixed")
+	assert.Equal(t, "the migration **bug** was fixed", got)
+
+	long := "가나다라마바사아자차카타파하 " + "x" + " 가나다라마바사아자차카타파하가나다라마바사아자차카타파하가나다라마바사아자차카타파하가나다라마바사아자차카타파하가나다라마바사아자차카타파하"
+	got = likeSnippet("x", long)
+	assert.Contains(t, got, "**x**")
+	assert.True(t, len([]rune(got)) < len([]rune(long)), "긴 텍스트는 잘려야 함")
+}
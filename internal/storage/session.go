@@ -7,6 +7,10 @@ import (
 	"time"
 )
 
+const sessionColumns = `id, tmux_name, working_dir, model, status, created_at, updated_at,
+	last_prompt, last_result, budget_ack_usd, permission_mode,
+	name, timeout_min, effort, notify, cc, budget_usd`
+
 // CreateSession inserts a new session record.
 func (s *Store) CreateSession(session *Session) error {
 	if session.CreatedAt.IsZero() {
@@ -16,11 +20,13 @@ func (s *Store) CreateSession(session *Session) error {
 		session.UpdatedAt = time.Now()
 	}
 	_, err := s.q().ExecContext(context.Background(),
-		`INSERT INTO sessions (id, tmux_name, working_dir, model, status, created_at, updated_at, last_prompt, last_result)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO sessions (`+sessionColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		session.ID, session.TmuxName, session.WorkingDir, session.Model, session.Status,
 		formatTime(session.CreatedAt), formatTime(session.UpdatedAt),
-		session.LastPrompt, session.LastResult,
+		session.LastPrompt, session.LastResult, session.BudgetAckUSD, session.PermissionMode,
+		session.Name, session.TimeoutMin, session.Effort, session.Notify,
+		strings.Join(session.CC, ","), session.BudgetUSD,
 	)
 	return err
 }
@@ -28,8 +34,7 @@ func (s *Store) CreateSession(session *Session) error {
 // GetSession retrieves a session by ID.
 func (s *Store) GetSession(id string) (*Session, error) {
 	row := s.q().QueryRowContext(context.Background(),
-		`SELECT id, tmux_name, working_dir, model, status, created_at, updated_at, last_prompt, last_result
-		 FROM sessions WHERE id = ?`, id,
+		`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id,
 	)
 	return scanSession(row)
 }
@@ -39,10 +44,10 @@ func (s *Store) UpdateSession(session *Session) error {
 	session.UpdatedAt = time.Now()
 	_, err := s.q().ExecContext(context.Background(),
 		`UPDATE sessions SET tmux_name = ?, working_dir = ?, model = ?, status = ?,
-		 updated_at = ?, last_prompt = ?, last_result = ? WHERE id = ?`,
+		 updated_at = ?, last_prompt = ?, last_result = ?, budget_ack_usd = ? WHERE id = ?`,
 		session.TmuxName, session.WorkingDir, session.Model, session.Status,
 		formatTime(session.UpdatedAt), session.LastPrompt, session.LastResult,
-		session.ID,
+		session.BudgetAckUSD, session.ID,
 	)
 	return err
 }
@@ -58,9 +63,22 @@ func (s *Store) ListSessionsByStatus(statuses ...string) ([]*Session, error) {
 		placeholders[i] = "?"
 		args[i] = st
 	}
-	query := `SELECT id, tmux_name, working_dir, model, status, created_at, updated_at, last_prompt, last_result
-	          FROM sessions WHERE status IN (` + strings.Join(placeholders, ",") + `)`
-	rows, err := s.q().QueryContext(context.Background(), query, args...)
+	return s.listSessions(`WHERE status IN (`+strings.Join(placeholders, ",")+`)`, args...)
+}
+
+// ListSessions retrieves the most recently updated sessions of any status,
+// newest first. limit <= 0 lists all.
+func (s *Store) ListSessions(limit int) ([]*Session, error) {
+	if limit > 0 {
+		return s.listSessions(`ORDER BY updated_at DESC, rowid DESC LIMIT ?`, limit)
+	}
+	return s.listSessions(`ORDER BY updated_at DESC, rowid DESC`)
+}
+
+func (s *Store) listSessions(where string, args ...any) ([]*Session, error) {
+	rows, err := s.q().QueryContext(context.Background(),
+		`SELECT `+sessionColumns+` FROM sessions `+where, args...,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -80,15 +98,20 @@ func (s *Store) ListSessionsByStatus(statuses ...string) ([]*Session, error) {
 func scanSession(row scanner) (*Session, error) {
 	var s Session
 	var lastPrompt, lastResult sql.NullString
+	var cc string
 
 	err := row.Scan(
 		&s.ID, &s.TmuxName, &s.WorkingDir, &s.Model, &s.Status,
-		&s.CreatedAt, &s.UpdatedAt, &lastPrompt, &lastResult,
+		&s.CreatedAt, &s.UpdatedAt, &lastPrompt, &lastResult, &s.BudgetAckUSD, &s.PermissionMode,
+		&s.Name, &s.TimeoutMin, &s.Effort, &s.Notify, &cc, &s.BudgetUSD,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if cc != "" {
+		s.CC = strings.Split(cc, ",")
+	}
 	if lastPrompt.Valid {
 		s.LastPrompt = &lastPrompt.String
 	}
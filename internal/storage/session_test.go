@@ -2,6 +2,7 @@ package storage
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,6 +17,15 @@ func TestCreateSession_GetSession(t *testing.T) {
 		WorkingDir: "/home/test/project",
 		Model:      "sonnet",
 		Status:     "creating",
+		// 권한 모드는 resume 시 재사용
+		PermissionMode: "plan",
+		// 세션 시작 메일의 지시어로 정한 설정
+		Name:       "Login bug",
+		TimeoutMin: 90,
+		Effort:     "high",
+		Notify:     "progress",
+		CC:         []string{"a@example.com", "b@example.com"},
+		BudgetUSD:  2.5,
 	}
 	err := store.CreateSession(session)
 	require.NoError(t, err)
@@ -27,6 +37,13 @@ func TestCreateSession_GetSession(t *testing.T) {
 	assert.Equal(t, "/home/test/project", got.WorkingDir)
 	assert.Equal(t, "sonnet", got.Model)
 	assert.Equal(t, "creating", got.Status)
+	assert.Equal(t, "plan", got.PermissionMode)
+	assert.Equal(t, "Login bug", got.Name)
+	assert.Equal(t, 90, got.TimeoutMin)
+	assert.Equal(t, "high", got.Effort)
+	assert.Equal(t, "progress", got.Notify)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, got.CC)
+	assert.Equal(t, 2.5, got.BudgetUSD)
 	assert.False(t, got.CreatedAt.IsZero(), "CreatedAt이 설정되어야 함")
 	assert.False(t, got.UpdatedAt.IsZero(), "UpdatedAt이 설정되어야 함")
 	assert.Nil(t, got.LastPrompt)
@@ -100,3 +117,22 @@ func TestGetSession_NotFound(t *testing.T) {
 	assert.Error(t, err, "존재하지 않는 ID 조회 시 에러 반환")
 	assert.Nil(t, got)
 }
+
+func TestListSessions(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	for i, id := range []string{"old", "ended", "new"} {
+		require.NoError(t, store.CreateSession(&Session{
+			ID: id, Status: "idle", UpdatedAt: now.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	sessions, err := store.ListSessions(0)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	assert.Equal(t, "new", sessions[0].ID, "최근 갱신순")
+
+	sessions, err = store.ListSessions(2)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+}
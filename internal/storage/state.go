@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// GetState returns the value stored under key, or "" if none.
+func (s *Store) GetState(key string) (string, error) {
+	var value string
+	err := s.q().QueryRowContext(context.Background(),
+		`SELECT value FROM state WHERE key = ?`, key,
+	).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetState stores value under key, replacing any previous value.
+func (s *Store) SetState(key, value string) error {
+	_, err := s.q().ExecContext(context.Background(),
+		`INSERT INTO state (key, value, updated_at) VALUES (?, ?, datetime('now'))
+		 ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		key, value,
+	)
+	return err
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState(t *testing.T) {
+	store := newTestStore(t)
+
+	v, err := store.GetState("digest_sent_at")
+	require.NoError(t, err)
+	assert.Empty(t, v, "없는 키는 빈 문자열")
+
+	require.NoError(t, store.SetState("digest_sent_at", "2026-03-01T08:00:00Z"))
+	require.NoError(t, store.SetState("digest_sent_at", "2026-03-02T08:00:00Z"))
+
+	v, err = store.GetState("digest_sent_at")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-02T08:00:00Z", v, "덮어쓰기")
+}
@@ -4,7 +4,10 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -15,8 +18,9 @@ const sqliteTimeFormat = "2006-01-02 15:04:05"
 
 // Store wraps an SQLite database connection.
 type Store struct {
-	db *sql.DB
-	tx *sql.Tx
+	db  *sql.DB
+	tx  *sql.Tx
+	fts bool // SQLite has FTS5; set by Migrate
 }
 
 // Session represents a Claude Code tmux session.
@@ -30,6 +34,18 @@ type Session struct {
 	UpdatedAt  time.Time
 	LastPrompt *string
 	LastResult *string
+	// BudgetAckUSD is the session cost at which the user last confirmed
+	// continuing past the budget.
+	BudgetAckUSD float64
+	// PermissionMode is passed to claude --permission-mode; "" skips
+	// permission checks.
+	PermissionMode string
+	Name           string   // human-readable title, "" if none
+	TimeoutMin     int      // session is ended this long after it started; 0 disables
+	Effort         string   // thinking effort: low, medium, high or "" (Claude Code default)
+	Notify         string   // "progress" also mails periodic progress; "" only results
+	CC             []string // extra recipients of the session's emails
+	BudgetUSD      float64  // session budget overriding the configured one; 0 uses the config
 }
 
 // OutboxMessage represents an outgoing email message.
@@ -51,16 +67,48 @@ type OutboxMessage struct {
 type InboxMessage struct {
 	ID        string
 	SessionID string
+	MessageID string // Message-ID of the email the body came from (may be empty)
 	Body      string
 	CreatedAt time.Time
 	Processed bool
 }
 
+// Usage aggregates token counts and the estimated cost in USD.
+type Usage struct {
+	InputTokens         int64
+	OutputTokens        int64
+	CacheCreationTokens int64
+	CacheReadTokens     int64
+	CostUSD             float64
+}
+
+// Turn represents one prompt/result exchange within a session.
+type Turn struct {
+	ID              string
+	SessionID       string
+	Seq             int
+	Prompt          string
+	Result          string
+	Outcome         string // running, done, waiting, ended
+	SourceMessageID string // Message-ID of the email that started the turn
+	Model           string
+	Usage
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	CreatedAt  time.Time
+	// RemindersSent counts the reminders mailed while the turn waited for
+	// input.
+	RemindersSent int
+}
+
 // Template represents an email template record.
 type Template struct {
 	ID        string
 	MessageID string
+	Name      string // preset name from config; "" is the default template
 	CreatedAt time.Time
+	ExpiresAt *time.Time // nil never expires
+	Revoked   bool
 }
 
 type querier interface {
@@ -110,20 +158,71 @@ func (s *Store) Close() error {
 }
 
 // Migrate runs pending database migrations.
+// Migration files are named NNN_description.sql and applied in order;
+// each file records its own version in schema_version.
 func (s *Store) Migrate() error {
+	current, err := s.schemaVersion()
+	if err != nil {
+		return err
+	}
+
+	entries, err := migrations.Files.ReadDir(".")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		version, err := migrationVersion(entry.Name())
+		if err != nil {
+			return err
+		}
+		if version <= current {
+			continue
+		}
+		content, err := migrations.Files.ReadFile(entry.Name())
+		if err != nil {
+			return err
+		}
+		if err := s.Tx(context.Background(), func(tx *Store) error {
+			_, execErr := tx.q().ExecContext(context.Background(), string(content))
+			return execErr
+		}); err != nil {
+			return fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+	}
+	if err := s.setupSearch(); err != nil {
+		return fmt.Errorf("search index: %w", err)
+	}
+	return nil
+}
+
+// schemaVersion returns the latest applied migration version (0 if none).
+func (s *Store) schemaVersion() (int, error) {
 	var name string
 	err := s.db.QueryRow(
 		"SELECT name FROM sqlite_master WHERE type='table' AND name='schema_version'",
 	).Scan(&name)
 	if err == sql.ErrNoRows {
-		content, err := migrations.Files.ReadFile("001_init.sql")
-		if err != nil {
-			return err
-		}
-		_, err = s.db.Exec(string(content))
-		return err
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+func migrationVersion(filename string) (int, error) {
+	prefix, _, ok := strings.Cut(filename, "_")
+	if !ok {
+		return 0, fmt.Errorf("invalid migration filename: %s", filename)
+	}
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("invalid migration filename: %s", filename)
 	}
-	return err
+	return version, nil
 }
 
 // Tx executes fn within a database transaction.
@@ -132,7 +231,7 @@ func (s *Store) Tx(ctx context.Context, fn func(tx *Store) error) error {
 	if err != nil {
 		return err
 	}
-	txStore := &Store{db: s.db, tx: sqlTx}
+	txStore := &Store{db: s.db, tx: sqlTx, fts: s.fts}
 	if err := fn(txStore); err != nil {
 		_ = sqlTx.Rollback()
 		return err
@@ -6,9 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/yhzion/claude-postman/internal/storage/migrations"
 )
 
 // newTestStore는 임시 디렉터리에 Store를 생성하고 마이그레이션을 적용한다.
@@ -64,7 +66,7 @@ func TestMigrate_CreatesTables(t *testing.T) {
 	store := newTestStore(t)
 
 	// 모든 테이블 존재 확인
-	tables := []string{"sessions", "outbox", "inbox", "template", "schema_version"}
+	tables := []string{"sessions", "outbox", "inbox", "template", "turns", "state", "schema_version"}
 	for _, table := range tables {
 		var name string
 		err := store.db.QueryRow(
@@ -86,10 +88,46 @@ func TestMigrate_Idempotent(t *testing.T) {
 func TestMigrate_SchemaVersion(t *testing.T) {
 	store := newTestStore(t)
 
+	entries, err := migrations.Files.ReadDir(".")
+	require.NoError(t, err)
+
 	var version int
-	err := store.db.QueryRow("SELECT version FROM schema_version").Scan(&version)
+	err = store.db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version)
+	require.NoError(t, err)
+	assert.Equal(t, len(entries), version, "schema_version이 마지막 마이그레이션 버전이어야 함")
+}
+
+func TestMigrate_UpgradesExistingDB(t *testing.T) {
+	dir := t.TempDir()
+	store, err := New(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	// v1 스키마만 있는 기존 DB
+	content, err := migrations.Files.ReadFile("001_init.sql")
+	require.NoError(t, err)
+	_, err = store.db.Exec(string(content))
+	require.NoError(t, err)
+	_, err = store.db.Exec(`INSERT INTO template (id, message_id, created_at) VALUES
+		('old', '<old@mail>', datetime('now', '-2 days')),
+		('latest', '<latest@mail>', datetime('now', '-1 days'))`)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Migrate())
+
+	var name string
+	err = store.db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='turns'").Scan(&name)
+	assert.NoError(t, err, "기존 DB에 이후 마이그레이션이 적용되어야 함")
+
+	// 기존 템플릿: 최신 것만 유효, 모두 만료일이 설정됨
+	old, err := store.GetTemplateByMessageID("<old@mail>")
+	require.NoError(t, err)
+	assert.True(t, old.Revoked, "이전 템플릿은 폐기되어야 함")
+	latest, err := store.GetTemplateByMessageID("<latest@mail>")
 	require.NoError(t, err)
-	assert.Equal(t, 1, version, "schema_version이 1이어야 함")
+	assert.False(t, latest.Revoked)
+	require.NotNil(t, latest.ExpiresAt)
+	assert.True(t, latest.Valid(time.Now()))
 }
 
 func TestClose(t *testing.T) {
@@ -2,29 +2,104 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
+const templateColumns = `id, message_id, name, created_at, expires_at, revoked`
+
 // SaveTemplate inserts a new template record.
 func (s *Store) SaveTemplate(tmpl *Template) error {
 	if tmpl.CreatedAt.IsZero() {
 		tmpl.CreatedAt = time.Now()
 	}
 	_, err := s.q().ExecContext(context.Background(),
-		`INSERT INTO template (id, message_id, created_at) VALUES (?, ?, ?)`,
-		tmpl.ID, tmpl.MessageID, formatTime(tmpl.CreatedAt),
+		`INSERT INTO template (`+templateColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		tmpl.ID, tmpl.MessageID, tmpl.Name, formatTime(tmpl.CreatedAt), formatNullableTime(tmpl.ExpiresAt),
+		boolToInt(tmpl.Revoked),
 	)
 	return err
 }
 
-// IsValidTemplateRef checks whether a template with the given messageID exists.
-func (s *Store) IsValidTemplateRef(messageID string) (bool, error) {
-	var count int
-	err := s.q().QueryRowContext(context.Background(),
-		`SELECT COUNT(*) FROM template WHERE message_id = ?`, messageID,
-	).Scan(&count)
+// GetTemplateByMessageID returns the template sent with messageID, or nil if
+// no such template exists. Revoked and expired templates are returned too.
+func (s *Store) GetTemplateByMessageID(messageID string) (*Template, error) {
+	row := s.q().QueryRowContext(context.Background(),
+		`SELECT `+templateColumns+` FROM template WHERE message_id = ?`, messageID,
+	)
+	tmpl, err := scanTemplate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return tmpl, err
+}
+
+// LatestValidTemplate returns the most recent template named name that is
+// neither revoked nor expired at now, or nil if there is none.
+// An empty name selects the default template.
+func (s *Store) LatestValidTemplate(name string, now time.Time) (*Template, error) {
+	row := s.q().QueryRowContext(context.Background(),
+		`SELECT `+templateColumns+` FROM template
+		 WHERE name = ? AND revoked = 0 AND (expires_at IS NULL OR expires_at > ?)
+		 ORDER BY created_at DESC, rowid DESC LIMIT 1`, name, formatTime(now),
+	)
+	tmpl, err := scanTemplate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return tmpl, err
+}
+
+// ListTemplates returns all templates, newest first.
+func (s *Store) ListTemplates() ([]*Template, error) {
+	rows, err := s.q().QueryContext(context.Background(),
+		`SELECT `+templateColumns+` FROM template ORDER BY created_at DESC, rowid DESC`,
+	)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*Template
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, rows.Err()
+}
+
+// RevokeTemplates revokes the templates whose ID starts with ref or whose
+// Message-ID equals ref. An empty ref revokes every template.
+// Returns the number of templates revoked.
+func (s *Store) RevokeTemplates(ref string) (int64, error) {
+	if ref == "" {
+		return s.exec(`UPDATE template SET revoked = 1 WHERE revoked = 0`)
+	}
+	return s.exec(
+		`UPDATE template SET revoked = 1
+		 WHERE revoked = 0 AND (substr(id, 1, length(?)) = ? OR message_id = ?)`,
+		ref, ref, ref,
+	)
+}
+
+// Valid reports whether the template can still be used to create sessions at now.
+func (t *Template) Valid(now time.Time) bool {
+	return !t.Revoked && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+func scanTemplate(row scanner) (*Template, error) {
+	var t Template
+	var expiresAt sql.NullTime
+	var revoked int
+	if err := row.Scan(&t.ID, &t.MessageID, &t.Name, &t.CreatedAt, &expiresAt, &revoked); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		t.ExpiresAt = &expiresAt.Time
 	}
-	return count > 0, nil
+	t.Revoked = revoked != 0
+	return &t, nil
 }
@@ -2,12 +2,13 @@ package storage
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestSaveTemplate_IsValidTemplateRef(t *testing.T) {
+func TestSaveTemplate_GetTemplateByMessageID(t *testing.T) {
 	store := newTestStore(t)
 
 	tmpl := &Template{
@@ -17,15 +18,94 @@ func TestSaveTemplate_IsValidTemplateRef(t *testing.T) {
 	err := store.SaveTemplate(tmpl)
 	require.NoError(t, err)
 
-	valid, err := store.IsValidTemplateRef("msg-id-12345")
+	got, err := store.GetTemplateByMessageID("msg-id-12345")
 	require.NoError(t, err)
-	assert.True(t, valid, "저장된 messageID는 유효해야 함")
+	require.NotNil(t, got, "저장된 messageID로 조회되어야 함")
+	assert.True(t, got.Valid(time.Now()), "저장된 템플릿은 유효해야 함")
 }
 
-func TestIsValidTemplateRef_NotFound(t *testing.T) {
+func TestGetTemplateByMessageID_NotFound(t *testing.T) {
 	store := newTestStore(t)
 
-	valid, err := store.IsValidTemplateRef("nonexistent-msg-id")
+	got, err := store.GetTemplateByMessageID("nonexistent-msg-id")
 	require.NoError(t, err)
-	assert.False(t, valid, "존재하지 않는 messageID는 false 반환")
+	assert.Nil(t, got, "존재하지 않는 messageID는 nil 반환")
+}
+
+func TestTemplateValid_ExpiredOrRevoked(t *testing.T) {
+	store := newTestStore(t)
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, store.SaveTemplate(&Template{ID: "expired", MessageID: "<expired@mail>", ExpiresAt: &past}))
+	require.NoError(t, store.SaveTemplate(&Template{ID: "revoked", MessageID: "<revoked@mail>", ExpiresAt: &future, Revoked: true}))
+
+	for _, id := range []string{"<expired@mail>", "<revoked@mail>"} {
+		tmpl, err := store.GetTemplateByMessageID(id)
+		require.NoError(t, err)
+		require.NotNil(t, tmpl, "무효한 템플릿도 조회는 가능해야 함")
+		assert.False(t, tmpl.Valid(time.Now()), "%s는 유효하지 않아야 함", id)
+	}
+}
+
+func TestRevokeTemplates(t *testing.T) {
+	store := newTestStore(t)
+	require.NoError(t, store.SaveTemplate(&Template{ID: "aaaa1111-x", MessageID: "<a@mail>"}))
+	require.NoError(t, store.SaveTemplate(&Template{ID: "bbbb2222-x", MessageID: "<b@mail>"}))
+	require.NoError(t, store.SaveTemplate(&Template{ID: "cccc3333-x", MessageID: "<c@mail>"}))
+
+	n, err := store.RevokeTemplates("aaaa1111")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "ID 접두사로 폐기")
+
+	n, err = store.RevokeTemplates("<b@mail>")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "Message-ID로 폐기")
+
+	n, err = store.RevokeTemplates("")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n, "빈 ref는 남은 템플릿 전체 폐기")
+
+	templates, err := store.ListTemplates()
+	require.NoError(t, err)
+	require.Len(t, templates, 3)
+	for _, tmpl := range templates {
+		assert.True(t, tmpl.Revoked, tmpl.ID)
+	}
+}
+
+func TestLatestValidTemplate(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	latest, err := store.LatestValidTemplate("", now)
+	require.NoError(t, err)
+	assert.Nil(t, latest, "템플릿이 없으면 nil")
+
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+	require.NoError(t, store.SaveTemplate(&Template{ID: "valid", MessageID: "<valid@mail>", ExpiresAt: &future, CreatedAt: now.Add(-3 * time.Hour)}))
+	require.NoError(t, store.SaveTemplate(&Template{ID: "expired", MessageID: "<expired@mail>", ExpiresAt: &past, CreatedAt: now.Add(-2 * time.Hour)}))
+	require.NoError(t, store.SaveTemplate(&Template{ID: "revoked", MessageID: "<revoked@mail>", Revoked: true, CreatedAt: now.Add(-time.Hour)}))
+
+	latest, err = store.LatestValidTemplate("", now)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "valid", latest.ID, "만료·폐기된 템플릿은 건너뛰어야 함")
+}
+
+func TestLatestValidTemplate_ByName(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	require.NoError(t, store.SaveTemplate(&Template{ID: "default", MessageID: "<default@mail>", CreatedAt: now.Add(-time.Hour)}))
+	require.NoError(t, store.SaveTemplate(&Template{ID: "infra", MessageID: "<infra@mail>", Name: "infra", CreatedAt: now}))
+
+	latest, err := store.LatestValidTemplate("", now)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "default", latest.ID, "이름 있는 템플릿은 기본 템플릿이 아님")
+
+	latest, err = store.LatestValidTemplate("infra", now)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "infra", latest.Name)
 }
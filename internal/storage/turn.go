@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const turnColumns = `id, session_id, seq, prompt, result, outcome, source_message_id, model,
+	input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cost_usd,
+	started_at, finished_at, created_at, reminders_sent`
+
+// CreateTurn inserts a new turn record.
+// If turn.Seq is zero, the next sequence number for the session is assigned.
+// StartedAt defaults to now and Outcome to "running".
+func (s *Store) CreateTurn(turn *Turn) error {
+	if turn.CreatedAt.IsZero() {
+		turn.CreatedAt = time.Now()
+	}
+	if turn.StartedAt.IsZero() {
+		turn.StartedAt = turn.CreatedAt
+	}
+	if turn.Outcome == "" {
+		turn.Outcome = "running"
+	}
+	if turn.Seq == 0 {
+		if err := s.q().QueryRowContext(context.Background(),
+			`SELECT COALESCE(MAX(seq), 0) + 1 FROM turns WHERE session_id = ?`, turn.SessionID,
+		).Scan(&turn.Seq); err != nil {
+			return err
+		}
+	}
+	_, err := s.q().ExecContext(context.Background(),
+		`INSERT INTO turns (`+turnColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		turn.ID, turn.SessionID, turn.Seq, nullIfEmpty(turn.Prompt), nullIfEmpty(turn.Result),
+		turn.Outcome, nullIfEmpty(turn.SourceMessageID), turn.Model,
+		turn.InputTokens, turn.OutputTokens, turn.CacheCreationTokens, turn.CacheReadTokens, turn.CostUSD,
+		formatTime(turn.StartedAt), formatNullableTime(turn.FinishedAt), formatTime(turn.CreatedAt),
+		turn.RemindersSent,
+	)
+	return err
+}
+
+// GetOpenTurn returns the latest running turn of a session, or nil if none.
+func (s *Store) GetOpenTurn(sessionID string) (*Turn, error) {
+	row := s.q().QueryRowContext(context.Background(),
+		`SELECT `+turnColumns+` FROM turns
+		 WHERE session_id = ? AND outcome = 'running' ORDER BY seq DESC LIMIT 1`, sessionID,
+	)
+	turn, err := scanTurn(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return turn, err
+}
+
+// GetLatestTurn returns the turn with the highest sequence number of a
+// session, or nil if the session has no turns.
+func (s *Store) GetLatestTurn(sessionID string) (*Turn, error) {
+	row := s.q().QueryRowContext(context.Background(),
+		`SELECT `+turnColumns+` FROM turns WHERE session_id = ? ORDER BY seq DESC LIMIT 1`, sessionID,
+	)
+	turn, err := scanTurn(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return turn, err
+}
+
+// SetRemindersSent records how many reminders were mailed for a turn.
+func (s *Store) SetRemindersSent(turnID string, n int) error {
+	_, err := s.q().ExecContext(context.Background(),
+		`UPDATE turns SET reminders_sent = ? WHERE id = ?`, n, turnID,
+	)
+	return err
+}
+
+// FinishTurn stores the result, outcome and usage of a turn.
+// FinishedAt defaults to now.
+func (s *Store) FinishTurn(turn *Turn) error {
+	if turn.FinishedAt == nil {
+		now := time.Now()
+		turn.FinishedAt = &now
+	}
+	_, err := s.q().ExecContext(context.Background(),
+		`UPDATE turns SET result = ?, outcome = ?, model = ?, input_tokens = ?, output_tokens = ?,
+		 cache_creation_tokens = ?, cache_read_tokens = ?, cost_usd = ?, finished_at = ?
+		 WHERE id = ?`,
+		nullIfEmpty(turn.Result), turn.Outcome, turn.Model, turn.InputTokens, turn.OutputTokens,
+		turn.CacheCreationTokens, turn.CacheReadTokens, turn.CostUSD, formatNullableTime(turn.FinishedAt),
+		turn.ID,
+	)
+	return err
+}
+
+// ListTurns retrieves all turns of a session ordered by sequence number.
+func (s *Store) ListTurns(sessionID string) ([]*Turn, error) {
+	rows, err := s.q().QueryContext(context.Background(),
+		`SELECT `+turnColumns+` FROM turns WHERE session_id = ? ORDER BY seq ASC`, sessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var turns []*Turn
+	for rows.Next() {
+		turn, err := scanTurn(rows)
+		if err != nil {
+			return nil, err
+		}
+		turns = append(turns, turn)
+	}
+	return turns, rows.Err()
+}
+
+// SessionUsage sums the usage of all turns of a session.
+func (s *Store) SessionUsage(sessionID string) (Usage, error) {
+	return s.sumUsage(`WHERE session_id = ?`, sessionID)
+}
+
+// UsageSince sums the usage of all turns that finished at or after since.
+// A turn's cost is incurred when it finishes, so a turn that started before
+// since counts if it ended after it; running turns count from their start.
+func (s *Store) UsageSince(since time.Time) (Usage, error) {
+	return s.sumUsage(`WHERE COALESCE(finished_at, created_at) >= ?`, formatTime(since))
+}
+
+func (s *Store) sumUsage(where string, args ...any) (Usage, error) {
+	var u Usage
+	err := s.q().QueryRowContext(context.Background(),
+		`SELECT COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0),
+		 COALESCE(SUM(cache_creation_tokens), 0), COALESCE(SUM(cache_read_tokens), 0),
+		 COALESCE(SUM(cost_usd), 0)
+		 FROM turns `+where, args...,
+	).Scan(&u.InputTokens, &u.OutputTokens, &u.CacheCreationTokens, &u.CacheReadTokens, &u.CostUSD)
+	return u, err
+}
+
+func scanTurn(row scanner) (*Turn, error) {
+	var t Turn
+	var prompt, result, sourceMessageID sql.NullString
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&t.ID, &t.SessionID, &t.Seq, &prompt, &result, &t.Outcome, &sourceMessageID, &t.Model,
+		&t.InputTokens, &t.OutputTokens, &t.CacheCreationTokens, &t.CacheReadTokens, &t.CostUSD,
+		&startedAt, &finishedAt, &t.CreatedAt, &t.RemindersSent,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Prompt = prompt.String
+	t.Result = result.String
+	t.SourceMessageID = sourceMessageID.String
+	if startedAt.Valid {
+		t.StartedAt = startedAt.Time
+	}
+	if finishedAt.Valid {
+		t.FinishedAt = &finishedAt.Time
+	}
+	return &t, nil
+}
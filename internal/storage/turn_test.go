@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTurn_AssignsSeq(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+
+	first := &Turn{ID: "turn-1", SessionID: "sess-1", Model: "claude-sonnet-4-5"}
+	require.NoError(t, store.CreateTurn(first))
+	second := &Turn{ID: "turn-2", SessionID: "sess-1"}
+	require.NoError(t, store.CreateTurn(second))
+
+	assert.Equal(t, 1, first.Seq)
+	assert.Equal(t, 2, second.Seq, "seq는 세션 내에서 증가해야 함")
+
+	turns, err := store.ListTurns("sess-1")
+	require.NoError(t, err)
+	require.Len(t, turns, 2)
+	assert.Equal(t, "turn-1", turns[0].ID)
+	assert.Equal(t, "claude-sonnet-4-5", turns[0].Model)
+	assert.Equal(t, "turn-2", turns[1].ID)
+}
+
+func TestGetOpenTurn_FinishTurn(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+
+	open, err := store.GetOpenTurn("sess-1")
+	require.NoError(t, err)
+	assert.Nil(t, open, "진행 중인 턴이 없으면 nil")
+
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "turn-1", SessionID: "sess-1", Prompt: "Fix the bug", SourceMessageID: "<m1@mail>",
+	}))
+
+	open, err = store.GetOpenTurn("sess-1")
+	require.NoError(t, err)
+	require.NotNil(t, open)
+	assert.Equal(t, "turn-1", open.ID)
+	assert.Equal(t, "running", open.Outcome)
+	assert.Equal(t, "Fix the bug", open.Prompt)
+	assert.Equal(t, "<m1@mail>", open.SourceMessageID)
+	assert.False(t, open.StartedAt.IsZero())
+	assert.Nil(t, open.FinishedAt)
+
+	open.Result = "Fixed"
+	open.Outcome = "done"
+	open.Usage = Usage{InputTokens: 10, CostUSD: 0.1}
+	require.NoError(t, store.FinishTurn(open))
+
+	again, err := store.GetOpenTurn("sess-1")
+	require.NoError(t, err)
+	assert.Nil(t, again, "완료된 턴은 진행 중이 아님")
+
+	turns, err := store.ListTurns("sess-1")
+	require.NoError(t, err)
+	require.Len(t, turns, 1)
+	assert.Equal(t, "Fixed", turns[0].Result)
+	assert.Equal(t, "done", turns[0].Outcome)
+	assert.Equal(t, int64(10), turns[0].InputTokens)
+	assert.NotNil(t, turns[0].FinishedAt)
+}
+
+func TestSessionUsage(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+	createTestSession(t, store, "sess-2")
+
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "t1", SessionID: "sess-1",
+		Usage: Usage{InputTokens: 100, OutputTokens: 10, CacheReadTokens: 1000, CostUSD: 0.5},
+	}))
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "t2", SessionID: "sess-1",
+		Usage: Usage{InputTokens: 200, OutputTokens: 20, CacheCreationTokens: 50, CostUSD: 0.25},
+	}))
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "t3", SessionID: "sess-2",
+		Usage: Usage{InputTokens: 1, CostUSD: 1},
+	}))
+
+	u, err := store.SessionUsage("sess-1")
+	require.NoError(t, err)
+	assert.Equal(t, Usage{
+		InputTokens: 300, OutputTokens: 30, CacheCreationTokens: 50, CacheReadTokens: 1000, CostUSD: 0.75,
+	}, u)
+
+	empty, err := store.SessionUsage("none")
+	require.NoError(t, err)
+	assert.Equal(t, Usage{}, empty, "턴이 없으면 0")
+}
+
+func TestUsageSince(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "old", SessionID: "sess-1", CreatedAt: time.Now().Add(-48 * time.Hour),
+		Usage: Usage{CostUSD: 10},
+	}))
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "new", SessionID: "sess-1", Usage: Usage{CostUSD: 2},
+	}))
+
+	u, err := store.UsageSince(time.Now().Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, u.CostUSD, 1e-9, "기간 밖의 턴은 제외")
+}
+
+func TestUsageSince_TurnSpanningMidnight(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+	midnight := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	started, finished := midnight.Add(-30*time.Minute), midnight.Add(20*time.Minute)
+
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "spanning", SessionID: "sess-1", CreatedAt: started, FinishedAt: &finished,
+		Usage: Usage{CostUSD: 3},
+	}))
+	prevFinished := midnight.Add(-time.Hour)
+	require.NoError(t, store.CreateTurn(&Turn{
+		ID: "yesterday", SessionID: "sess-1", CreatedAt: midnight.Add(-2 * time.Hour), FinishedAt: &prevFinished,
+		Usage: Usage{CostUSD: 5},
+	}))
+
+	u, err := store.UsageSince(midnight)
+	require.NoError(t, err)
+	assert.InDelta(t, 3.0, u.CostUSD, 1e-9, "자정을 넘겨 끝난 턴은 끝난 날의 비용")
+}
+
+func TestGetLatestTurn_Reminders(t *testing.T) {
+	store := newTestStore(t)
+	createTestSession(t, store, "sess-1")
+
+	latest, err := store.GetLatestTurn("sess-1")
+	require.NoError(t, err)
+	assert.Nil(t, latest, "턴이 없으면 nil")
+
+	require.NoError(t, store.CreateTurn(&Turn{ID: "turn-1", SessionID: "sess-1", Outcome: "done"}))
+	require.NoError(t, store.CreateTurn(&Turn{ID: "turn-2", SessionID: "sess-1", Outcome: "waiting"}))
+	require.NoError(t, store.SetRemindersSent("turn-2", 2))
+
+	latest, err = store.GetLatestTurn("sess-1")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "turn-2", latest.ID)
+	assert.Equal(t, 2, latest.RemindersSent)
+}